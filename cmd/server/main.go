@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/codingconcepts/env"
 	"github.com/gorilla/mux"
@@ -16,8 +18,13 @@ import (
 	"github.com/golden-vcr/tapes/gen/queries"
 	"github.com/golden-vcr/tapes/internal/admin"
 	"github.com/golden-vcr/tapes/internal/catalog"
+	tapesdb "github.com/golden-vcr/tapes/internal/db"
 	"github.com/golden-vcr/tapes/internal/favorites"
+	"github.com/golden-vcr/tapes/internal/sheets"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/golden-vcr/tapes/internal/syncer"
 	"github.com/golden-vcr/tapes/internal/users"
+	"github.com/golden-vcr/tapes/internal/users/helixfake"
 )
 
 type Config struct {
@@ -27,9 +34,21 @@ type Config struct {
 	SpacesBucketName     string `env:"SPACES_BUCKET_NAME" required:"true"`
 	SpacesEndpointOrigin string `env:"SPACES_ENDPOINT_URL" required:"true"`
 
-	TwitchClientId          string `env:"TWITCH_CLIENT_ID" required:"true"`
-	TwitchClientSecret      string `env:"TWITCH_CLIENT_SECRET" required:"true"`
-	TwitchExtensionClientId string `env:"TWITCH_EXTENSION_CLIENT_ID" required:"true"`
+	// Credentials for the spreadsheet and storage bucket are only required in order to
+	// run syncs from the server via POST /admin/sync
+	SheetsApiKey      string `env:"SHEETS_API_KEY"`
+	SpreadsheetId     string `env:"SPREADSHEET_ID"`
+	SpacesRegionName  string `env:"SPACES_REGION_NAME"`
+	SpacesAccessKeyId string `env:"SPACES_ACCESS_KEY_ID"`
+	SpacesSecretKey   string `env:"SPACES_SECRET_KEY"`
+
+	TwitchClientId          string        `env:"TWITCH_CLIENT_ID"`
+	TwitchClientSecret      string        `env:"TWITCH_CLIENT_SECRET"`
+	TwitchExtensionClientId string        `env:"TWITCH_EXTENSION_CLIENT_ID" required:"true"`
+	TwitchUserCacheTtl      time.Duration `env:"TWITCH_USER_CACHE_TTL" default:"24h"`
+
+	UserLookupProvider string `env:"USER_LOOKUP_PROVIDER" default:"twitch"`
+	UserLookupFile     string `env:"USER_LOOKUP_FILE"`
 
 	AuthURL string `env:"AUTH_URL" default:"http://localhost:5002"`
 
@@ -75,9 +94,10 @@ func main() {
 	}
 	q := queries.New(db)
 
-	// We use a simple Twitch API client in order to resolve user-facing display names
-	// (from Twitch User IDs) for tapes that were contributed by a specific user
-	lookup, err := users.NewLookup(config.TwitchClientId, config.TwitchClientSecret)
+	// We need to resolve user-facing display names (from Twitch User IDs) for tapes that
+	// were contributed by a specific user: by default we use the Twitch API, but for
+	// local development we can read names from a file or run a fake Twitch API instead
+	lookup, err := initUserLookup(app.Context(), &config, q)
 	if err != nil {
 		app.Fail("Failed to initialize user lookup", err)
 	}
@@ -98,6 +118,14 @@ func main() {
 		imageHostUrl := fmt.Sprintf("https://%s.%s", config.SpacesBucketName, config.SpacesEndpointOrigin)
 		catalogServer := catalog.NewServer(q, lookup, imageHostUrl)
 		catalogServer.RegisterRoutes(r.PathPrefix("/catalog").Subrouter())
+
+		// The default catalog listing is served from an in-memory snapshot, which is
+		// rebuilt whenever Postgres notifies us that catalog data has changed
+		changes, err := tapesdb.ListenForCatalogChanges(app.Context(), connectionString)
+		if err != nil {
+			app.Fail("Failed to listen for catalog changes", err)
+		}
+		go catalogServer.RunSnapshotUpdates(app.Context(), changes)
 	}
 
 	// Once logged in, users can hit GET /favorites to get the set of tape IDs that a
@@ -108,11 +136,79 @@ func main() {
 		favoritesServer.RegisterRoutes(authClient, r.PathPrefix("/favorites").Subrouter())
 	}
 
-	// Quick and dirty endpoints for managing tape data as the broadcaster
+	// Quick and dirty endpoints for managing tape data as the broadcaster, including
+	// triggering a sync if we have the necessary credentials
 	{
-		adminServer := admin.NewServer(q)
+		var adminSyncer admin.Syncer
+		if config.SheetsApiKey != "" && config.SpreadsheetId != "" && config.SpacesRegionName != "" && config.SpacesAccessKeyId != "" && config.SpacesSecretKey != "" {
+			sheetsClient := sheets.NewClient(config.SheetsApiKey, config.SpreadsheetId, os.Stdout)
+			storageClient, err := storage.NewClient(
+				config.SpacesAccessKeyId,
+				config.SpacesSecretKey,
+				config.SpacesEndpointOrigin,
+				config.SpacesRegionName,
+				config.SpacesBucketName,
+			)
+			if err != nil {
+				app.Fail("Failed to initialize storage client", err)
+			}
+			adminSyncer = syncer.New(db, sheetsClient, storageClient, os.Stdout)
+		}
+		adminServer := admin.NewServer(q, adminSyncer)
 		adminServer.RegisterRoutes(authClient, r.PathPrefix("/admin").Subrouter())
 	}
 
 	entry.RunServer(app, r, config.BindAddr, int(config.ListenPort))
 }
+
+func initUserLookup(ctx context.Context, config *Config, q *queries.Queries) (users.Lookup, error) {
+	switch config.UserLookupProvider {
+	case "twitch":
+		// Use the Twitch API, caching names in the database and refreshing them in the
+		// background once they're older than the configured TTL
+		if config.TwitchClientId == "" || config.TwitchClientSecret == "" {
+			return nil, fmt.Errorf("TWITCH_CLIENT_ID and TWITCH_CLIENT_SECRET are required when USER_LOOKUP_PROVIDER is 'twitch'")
+		}
+		fetcher, err := users.NewTwitchFetcher(config.TwitchClientId, config.TwitchClientSecret, nil)
+		if err != nil {
+			return nil, err
+		}
+		lookup := users.NewCachedLookup(q, fetcher, config.TwitchUserCacheTtl)
+		go lookup.Run(ctx)
+		return lookup, nil
+	case "static":
+		// Serve names directly from a local JSON file
+		if config.UserLookupFile == "" {
+			return nil, fmt.Errorf("USER_LOOKUP_FILE is required when USER_LOOKUP_PROVIDER is 'static'")
+		}
+		displayNamesById, err := users.LoadDisplayNames(config.UserLookupFile)
+		if err != nil {
+			return nil, err
+		}
+		return users.NewStaticLookup(displayNamesById), nil
+	case "fake":
+		// Run an in-process fake of the Twitch API, optionally seeded with names from a
+		// local JSON file, so that we exercise the same code paths as in production
+		displayNamesById := make(map[string]string)
+		if config.UserLookupFile != "" {
+			var err error
+			displayNamesById, err = users.LoadDisplayNames(config.UserLookupFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		server := helixfake.NewServer(displayNamesById)
+		go func() {
+			<-ctx.Done()
+			server.Close()
+		}()
+		fetcher, err := users.NewTwitchFetcher("fake-client-id", "fake-client-secret", server.HTTPClient())
+		if err != nil {
+			return nil, err
+		}
+		lookup := users.NewCachedLookup(q, fetcher, config.TwitchUserCacheTtl)
+		go lookup.Run(ctx)
+		return lookup, nil
+	}
+	return nil, fmt.Errorf("unsupported USER_LOOKUP_PROVIDER '%s' (must be one of: twitch, static, fake)", config.UserLookupProvider)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/golden-vcr/tapes/internal/diff"
+	"github.com/golden-vcr/tapes/internal/syncer"
+)
+
+// computeDiff compares the data gathered for a sync against the current contents of
+// the tapes database, in order to determine what changes the sync would make
+func computeDiff(ctx context.Context, data *syncer.SourceData, q *queries.Queries) (*diff.Diff, error) {
+	// Get the current state of every tape in the database, including retired tapes
+	rows, err := q.GetSyncedTapes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current tape data from database: %w", err)
+	}
+	current := make([]diff.Tape, 0, len(rows))
+	for _, row := range rows {
+		images, err := db.ParseTapeImageArray(row.Images)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse images for tape %d: %w", row.ID, err)
+		}
+		thumbnail, err := db.ParseTapeThumbnail(row.Thumbnail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse thumbnail for tape %d: %w", row.ID, err)
+		}
+		tape := diff.Tape{
+			Id:          int(row.ID),
+			Title:       row.Title,
+			Year:        int(row.Year.Int32),
+			Runtime:     int(row.Runtime.Int32),
+			Contributor: row.ContributorID.String,
+			Retired:     row.IsRetired,
+			Tags:        row.Tags,
+			Images:      make([]diff.Image, 0, len(images)),
+		}
+		if thumbnail != nil {
+			tape.Thumbnail = &diff.Thumbnail{
+				Color:       thumbnail.Color,
+				Width:       int(thumbnail.Width),
+				Height:      int(thumbnail.Height),
+				Blurhash:    thumbnail.Blurhash,
+				ContentHash: thumbnail.ContentHash,
+			}
+		}
+		for _, image := range images {
+			variants := make([]string, 0, len(image.Variants))
+			for _, variant := range image.Variants {
+				variants = append(variants, formatVariant(variant.Format, int(variant.Width)))
+			}
+			tape.Images = append(tape.Images, diff.Image{
+				Index:    int(image.Index),
+				Color:    image.Color,
+				Width:    int(image.Width),
+				Height:   int(image.Height),
+				Rotated:  image.Rotated,
+				Blurhash: image.Blurhash,
+				Variants: variants,
+			})
+		}
+		current = append(current, tape)
+	}
+
+	// Build the state that each tape would have after syncing
+	tapesToSync, warnings := data.Plan()
+	synced := make([]diff.Tape, 0, len(tapesToSync))
+	for _, t := range tapesToSync {
+		tape := diff.Tape{
+			Id:          t.Tape.Id,
+			Title:       t.Tape.Title,
+			Year:        t.Tape.Year,
+			Runtime:     t.Tape.Runtime,
+			Contributor: t.Tape.Contributor,
+			Tags:        t.Tape.Tags,
+			Images:      make([]diff.Image, 0, len(t.GalleryImages)),
+		}
+		if thumbnail := t.Thumbnail.ThumbnailData; thumbnail != nil {
+			tape.Thumbnail = &diff.Thumbnail{
+				Color:       string(thumbnail.Metadata.Color),
+				Width:       thumbnail.Metadata.Width,
+				Height:      thumbnail.Metadata.Height,
+				Blurhash:    thumbnail.Metadata.Blurhash,
+				ContentHash: thumbnail.ContentHash,
+			}
+		}
+		for _, image := range t.GalleryImages {
+			md := image.GalleryData.Metadata
+			variants := make([]string, 0, len(image.GalleryData.Variants))
+			for _, variant := range image.GalleryData.Variants {
+				variants = append(variants, formatVariant(string(variant.Format), variant.Width))
+			}
+			tape.Images = append(tape.Images, diff.Image{
+				Index:    image.GalleryData.Index,
+				Color:    string(md.Color),
+				Width:    md.Width,
+				Height:   md.Height,
+				Rotated:  md.Rotated,
+				Blurhash: md.Blurhash,
+				Variants: variants,
+			})
+		}
+		synced = append(synced, tape)
+	}
+
+	// A tape that's listed in the spreadsheet but excluded from the sync would have
+	// all of its images (including its thumbnail) deleted, with its other details left
+	// as-is
+	currentById := make(map[int]diff.Tape, len(current))
+	for _, tape := range current {
+		currentById[tape.Id] = tape
+	}
+	for _, tapeId := range data.ExcludedTapeIds(tapesToSync) {
+		if tape, ok := currentById[tapeId]; ok && !tape.Retired {
+			tape.Images = nil
+			tape.Thumbnail = nil
+			synced = append(synced, tape)
+		}
+	}
+
+	d := diff.Compute(current, synced, data.ListedTapeIds())
+	d.Warnings = syncer.FormatWarnings(warnings)
+	return &d, nil
+}
+
+// formatVariant describes an image variant for display in a diff, e.g. 'webp@800w'
+func formatVariant(format string, width int) string {
+	return fmt.Sprintf("%s@%dw", format, width)
+}
+
+// writeDiff writes the given diff to w, either as human-readable text or as JSON
+func writeDiff(w io.Writer, d *diff.Diff, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	return d.WriteText(w)
+}
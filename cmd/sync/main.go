@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
+	"time"
 
 	"github.com/codingconcepts/env"
 	"github.com/google/uuid"
@@ -19,17 +22,23 @@ import (
 	"github.com/golden-vcr/tapes/gen/queries"
 	"github.com/golden-vcr/tapes/internal/sheets"
 	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/golden-vcr/tapes/internal/syncer"
 )
 
 type Config struct {
-	SheetsApiKey  string `env:"SHEETS_API_KEY" required:"true"`
-	SpreadsheetId string `env:"SPREADSHEET_ID" required:"true"`
-
-	SpacesBucketName     string `env:"SPACES_BUCKET_NAME" required:"true"`
-	SpacesRegionName     string `env:"SPACES_REGION_NAME" required:"true"`
-	SpacesEndpointOrigin string `env:"SPACES_ENDPOINT_URL" required:"true"`
-	SpacesAccessKeyId    string `env:"SPACES_ACCESS_KEY_ID" required:"true"`
-	SpacesSecretKey      string `env:"SPACES_SECRET_KEY" required:"true"`
+	// Tapes are read from the Google Sheets API, unless --inventory is used to read
+	// them from a local CSV or XLSX export instead
+	SheetsApiKey  string `env:"SHEETS_API_KEY"`
+	SpreadsheetId string `env:"SPREADSHEET_ID"`
+
+	// Images are read from a DigitalOcean Spaces bucket, unless LOCAL_STORAGE_DIR is
+	// set, in which case they're read from that directory instead
+	LocalStorageDir      string `env:"LOCAL_STORAGE_DIR"`
+	SpacesBucketName     string `env:"SPACES_BUCKET_NAME"`
+	SpacesRegionName     string `env:"SPACES_REGION_NAME"`
+	SpacesEndpointOrigin string `env:"SPACES_ENDPOINT_URL"`
+	SpacesAccessKeyId    string `env:"SPACES_ACCESS_KEY_ID"`
+	SpacesSecretKey      string `env:"SPACES_SECRET_KEY"`
 
 	DatabaseHost     string `env:"PGHOST" required:"true"`
 	DatabasePort     int    `env:"PGPORT" required:"true"`
@@ -40,6 +49,33 @@ type Config struct {
 }
 
 func main() {
+	// Parse command-line flags
+	dryRun := flag.Bool("dry-run", false, "Print the changes that a sync would make, without modifying the database")
+	format := flag.String("format", "text", "Output format for --dry-run: 'text' or 'json'")
+	watch := flag.Bool("watch", false, "Keep running, syncing periodically whenever the spreadsheet or storage bucket has changed")
+	interval := flag.Duration("interval", 15*time.Minute, "How often to check for changes in --watch mode")
+	inventory := flag.String("inventory", "", "Path to a .csv or .xlsx export of the inventory spreadsheet, to be read instead of the Google Sheets API")
+	flag.Parse()
+	if *format != "text" && *format != "json" {
+		log.Fatalf("invalid --format '%s': must be 'text' or 'json'", *format)
+	}
+	if *format != "text" && !*dryRun {
+		log.Fatalf("--format is only supported with --dry-run")
+	}
+	if *watch && *dryRun {
+		log.Fatalf("--watch and --dry-run may not be used together")
+	}
+	if *interval <= 0 {
+		log.Fatalf("invalid --interval '%s': must be positive", *interval)
+	}
+
+	// In JSON mode, stdout is reserved for the diff itself: route all other output
+	// (including progress messages printed while listing tapes and images) to stderr
+	var progress io.Writer = os.Stdout
+	if *format == "json" {
+		progress = os.Stderr
+	}
+
 	// Load config from .env
 	err := godotenv.Load()
 	if err != nil && !os.IsNotExist(err) {
@@ -73,201 +109,105 @@ func main() {
 	}
 	q := queries.New(db)
 
-	// Record the start of a sync in the database (outside of a transaction; we want
-	// this recorded immediately
-	syncUuid := uuid.New()
-	if err := q.CreateSync(ctx, syncUuid); err != nil {
-		log.Fatalf("failed to record new sync in database: %v", err)
-	}
-	fmt.Printf("sync uuid: %s\n", syncUuid)
-
-	// Start a transaction so that we only commit tape/image changes when finished
-	// syncing everything
-	tx, err := db.BeginTx(ctx, nil)
+	// Initialize clients for the Google Sheets API and for the S3-compatible bucket
+	// where we store scanned images of tapes
+	sheetsClient, err := initSheetsClient(&config, *inventory, progress)
 	if err != nil {
-		log.Fatalf("failed to begin database transaction: %v", err)
+		log.Fatalf("error initializing sheets client: %v", err)
 	}
-	defer tx.Rollback()
-	txQueries := queries.New(tx)
-
-	// Run the sync, and commit the database transaction on success
-	numTapesSynced, warningLines, err := runSync(ctx, &config, txQueries)
-	if err == nil {
-		err = tx.Commit()
+	storageClient, err := initStorageClient(&config, progress)
+	if err != nil {
+		log.Fatalf("error initializing storage client: %v", err)
 	}
 
-	// Update the database to record the results of our sync
-	var recordResultErr error
-	if err == nil {
-		recordResultErr = q.RecordSuccessfulSync(ctx, queries.RecordSuccessfulSyncParams{
-			Uuid:     syncUuid,
-			NumTapes: int32(numTapesSynced),
-			Warnings: strings.Join(warningLines, "\n"),
-		})
-	} else {
-		recordResultErr = q.RecordFailedSync(ctx, queries.RecordFailedSyncParams{
-			Uuid:  syncUuid,
-			Error: err.Error(),
-		})
+	// In dry-run mode, just report what would change, without recording anything in
+	// the database: previously-cached image metadata is used, but not updated
+	if *dryRun {
+		data, err := syncer.ListSources(ctx, sheetsClient, storageClient, syncer.NewReadOnlyMetadataCache(q), progress)
+		if err != nil {
+			log.Fatalf("Dry run failed: %v", err)
+		}
+		d, err := computeDiff(ctx, data, q)
+		if err != nil {
+			log.Fatalf("Dry run failed: %v", err)
+		}
+		if err := writeDiff(os.Stdout, d, *format); err != nil {
+			log.Fatalf("Failed to write diff: %v", err)
+		}
+		return
 	}
 
-	// Treat that final DB update as a fatal error if the sync was successful; otherwise
-	// log it as a warning so we don't supersede the actual sync error
-	if recordResultErr != nil {
-		if err == nil {
-			log.Fatalf("Sync results were not recorded: %v", recordResultErr)
-		} else {
-			fmt.Printf("WARNING: Sync results were not recorded: %v\n", recordResultErr)
-		}
+	// In watch mode, sync periodically until interrupted
+	s := syncer.New(db, sheetsClient, storageClient, progress)
+	if *watch {
+		runWatch(ctx, s, *interval)
+		return
 	}
 
-	// Report results and exit
+	// Otherwise, run a single sync, recording its results in the database
+	syncUuid, err := s.Run(ctx)
 	if err != nil {
+		if syncUuid == uuid.Nil {
+			log.Fatalf("Sync failed: %v", err)
+		}
 		log.Fatalf("Sync %s failed: %v", syncUuid, err)
 	}
 	fmt.Printf("Sync %s finished.\n", syncUuid)
 }
 
-func runSync(ctx context.Context, config *Config, q *queries.Queries) (int, []string, error) {
-	// Initialize a Google sheets API client and get a listing of all tapes with valid
-	// rows in the inventory spreadsheet
-	fmt.Printf("Listing tapes in the Golden VCR Inventory spreadsheet (%s)...\n", config.SpreadsheetId)
-	sheetsClient := sheets.NewClient(config.SheetsApiKey, config.SpreadsheetId)
-	tapes, sheetWarnings, err := sheets.ListTapes(ctx, sheetsClient)
-	if err != nil {
-		return -1, nil, fmt.Errorf("error listing tapes from spreadsheet: %w", err)
-	}
-	fmt.Printf("Got %d tapes:\n", len(tapes))
-	for _, tape := range tapes {
-		fmt.Printf("- %3d | %4d | %3d | %s\n", tape.Id, tape.Year, tape.Runtime, tape.Title)
-	}
-
-	// Initialize an S3 client so we can get image URLs and metadata from our Spaces
-	// bucket
-	fmt.Printf("Retrieving image filenames and metadata from storage bucket (%s)...\n", config.SpacesBucketName)
-	storageClient, err := storage.NewClient(
-		config.SpacesAccessKeyId,
-		config.SpacesSecretKey,
-		config.SpacesEndpointOrigin,
-		config.SpacesRegionName,
-		config.SpacesBucketName,
-	)
-	if err != nil {
-		return -1, nil, fmt.Errorf("error initializing client for S3-compatible storage: %w", err)
-	}
-	images, imageWarnings, err := storage.ListImages(ctx, storageClient)
-	if err != nil {
-		return -1, nil, fmt.Errorf("error retrieving image data from storage bucket: %w", err)
-	}
-	fmt.Printf("Got %d images:\n", len(images))
-	for _, image := range images {
-		summary := fmt.Sprintf("%3d | %-9s | %s", image.TapeId, image.Type, image.Filename)
-		if image.Type == storage.ImageTypeGallery {
-			index := image.GalleryData.Index
-			md := image.GalleryData.Metadata
-			flag := ""
-			if md.Rotated {
-				flag = "rotated"
-			}
-			fmt.Printf("- %s | %d | %d x %d | %s | %s\n", summary, index, md.Width, md.Height, md.Color, flag)
-		} else {
-			fmt.Printf("- %s\n", summary)
+// runWatch checks for changes in the spreadsheet and storage bucket every interval,
+// running a sync whenever anything has changed, until the given context is canceled.
+// Failed syncs are reported but do not stop the loop.
+func runWatch(ctx context.Context, s *syncer.Syncer, interval time.Duration) {
+	fmt.Printf("Watching for changes every %s.\n", interval)
+	for {
+		syncUuid, ok, err := s.RunIfChanged(ctx)
+		if errors.Is(err, syncer.ErrSyncInProgress) {
+			fmt.Printf("Another sync is already in progress; skipping.\n")
+		} else if err != nil && syncUuid == uuid.Nil {
+			fmt.Printf("Sync failed: %v\n", err)
+		} else if err != nil {
+			fmt.Printf("Sync %s failed: %v\n", syncUuid, err)
+		} else if ok {
+			fmt.Printf("Sync %s finished.\n", syncUuid)
 		}
-	}
 
-	// We don't actually record anything in the database for thumbnail images: we just
-	// require that a tape have a thumbnail image before we record that the tape exists,
-	// so we can assume that every tape has a thumbnail image at %04d_thumb.jpg. Collect
-	// all of the gallery images that we need to record for each tape.
-	galleryImagesByTapeId := make(map[int][]storage.Image)
-	for _, image := range images {
-		if image.Type == storage.ImageTypeGallery {
-			galleryImagesByTapeId[image.TapeId] = append(galleryImagesByTapeId[image.TapeId], image)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
 		}
 	}
+}
 
-	// Collect a list of all warnings, line-by-line as strings, so we can present a
-	// summary when finished syncing
-	warningLines := make([]string, 0, len(sheetWarnings)+len(imageWarnings))
-	for _, warning := range sheetWarnings {
-		warningLines = append(warningLines, fmt.Sprintf("Spreadsheet row %d: %s", warning.RowNumber, warning.Message))
+// initSheetsClient returns a client that reads the inventory file at the given path if
+// set, or that reads our spreadsheet from the Google Sheets API otherwise
+func initSheetsClient(config *Config, inventoryPath string, progress io.Writer) (sheets.Client, error) {
+	if inventoryPath != "" {
+		fmt.Fprintf(progress, "Using inventory from local file %s.\n", inventoryPath)
+		return sheets.NewFileClient(inventoryPath)
 	}
-	for _, warning := range imageWarnings {
-		warningLines = append(warningLines, fmt.Sprintf("Image file %s: %s", warning.Filename, warning.Message))
+	if config.SheetsApiKey == "" || config.SpreadsheetId == "" {
+		return nil, fmt.Errorf("SHEETS_API_KEY and SPREADSHEET_ID are required unless --inventory is used")
 	}
+	return sheets.NewClient(config.SheetsApiKey, config.SpreadsheetId, progress), nil
+}
 
-	// Iterate over all tapes in the spreadsheet
-	fmt.Printf("Syncing tape and image data to the tapes database...\n")
-	numTapesSynced := 0
-	for _, tape := range tapes {
-		// Don't sync a tape unless it has at least one gallery image stored
-		galleryImages, ok := galleryImagesByTapeId[tape.Id]
-		if !ok || len(galleryImages) == 0 {
-			warningLines = append(warningLines, fmt.Sprintf("Tape %d has no gallery images; ignoring it.", tape.Id))
-			continue
-		}
-
-		// Store year and runtime as NULL if not specified
-		yearValue := sql.NullInt32{}
-		if tape.Year > 0 {
-			yearValue.Valid = true
-			yearValue.Int32 = int32(tape.Year)
-		}
-		runtimeValue := sql.NullInt32{}
-		if tape.Runtime > 0 {
-			runtimeValue.Valid = true
-			runtimeValue.Int32 = int32(tape.Runtime)
-		}
-		contributorValue := sql.NullString{}
-		if tape.Contributor != "" {
-			contributorValue.Valid = true
-			contributorValue.String = tape.Contributor
-		}
-
-		// Upsert into the tape table to register our tape with its latest details
-		if err := q.SyncTape(ctx, queries.SyncTapeParams{
-			ID:            int32(tape.Id),
-			Title:         tape.Title,
-			Year:          yearValue,
-			Runtime:       runtimeValue,
-			ContributorID: contributorValue,
-		}); err != nil {
-			return -1, nil, fmt.Errorf("failed to sync tape %d: %w", tape.Id, err)
-		}
-
-		// Update tape_to_tag records for this tape ID, ensuring that the set of tags
-		// associated with this tape matches exactly what we parsed from the spreadsheet
-		if err := q.SyncTapeTags(ctx, queries.SyncTapeTagsParams{
-			TapeID:   int32(tape.Id),
-			TagNames: tape.Tags,
-		}); err != nil {
-			return -1, nil, fmt.Errorf("failed to sync tags for tape %d: %w", tape.Id, err)
-		}
-
-		// Get the metadata for all images associated with this tape, and register each
-		// of those images
-		for _, image := range galleryImages {
-			// Upsert into the image table to register the latest image metadata
-			if err := q.SyncImage(ctx, queries.SyncImageParams{
-				TapeID:  int32(tape.Id),
-				Index:   int32(image.GalleryData.Index),
-				Color:   string(image.GalleryData.Metadata.Color),
-				Width:   int32(image.GalleryData.Metadata.Width),
-				Height:  int32(image.GalleryData.Metadata.Height),
-				Rotated: image.GalleryData.Metadata.Rotated,
-			}); err != nil {
-				return -1, nil, fmt.Errorf("failed to sync image %d for tape %d: %w", image.GalleryData.Index, tape.Id, err)
-			}
-		}
-		numTapesSynced++
+// initStorageClient returns a client for the local directory named by
+// LOCAL_STORAGE_DIR if set, or for our Spaces bucket otherwise
+func initStorageClient(config *Config, progress io.Writer) (storage.Client, error) {
+	if config.LocalStorageDir != "" {
+		fmt.Fprintf(progress, "Using images from local directory %s.\n", config.LocalStorageDir)
+		return storage.NewLocalClient(config.LocalStorageDir)
 	}
-
-	fmt.Printf("Synced data for %d tape(s).\n", numTapesSynced)
-	if len(warningLines) > 0 {
-		fmt.Printf("Encountered %d warning(s):\n", len(warningLines))
-		for _, line := range warningLines {
-			fmt.Printf("- %s\n", line)
-		}
+	if config.SpacesBucketName == "" || config.SpacesRegionName == "" || config.SpacesEndpointOrigin == "" || config.SpacesAccessKeyId == "" || config.SpacesSecretKey == "" {
+		return nil, fmt.Errorf("SPACES_BUCKET_NAME, SPACES_REGION_NAME, SPACES_ENDPOINT_URL, SPACES_ACCESS_KEY_ID, and SPACES_SECRET_KEY are required unless LOCAL_STORAGE_DIR is set")
 	}
-	return numTapesSynced, warningLines, nil
+	return storage.NewClient(
+		config.SpacesAccessKeyId,
+		config.SpacesSecretKey,
+		config.SpacesEndpointOrigin,
+		config.SpacesRegionName,
+		config.SpacesBucketName,
+	)
 }
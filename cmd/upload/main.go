@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codingconcepts/env"
+	"github.com/joho/godotenv"
+
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/golden-vcr/tapes/internal/upload"
+)
+
+type Config struct {
+	// Images are written to a DigitalOcean Spaces bucket, unless LOCAL_STORAGE_DIR is
+	// set, in which case they're written to that directory instead
+	LocalStorageDir      string `env:"LOCAL_STORAGE_DIR"`
+	SpacesBucketName     string `env:"SPACES_BUCKET_NAME"`
+	SpacesRegionName     string `env:"SPACES_REGION_NAME"`
+	SpacesEndpointOrigin string `env:"SPACES_ENDPOINT_URL"`
+	SpacesAccessKeyId    string `env:"SPACES_ACCESS_KEY_ID"`
+	SpacesSecretKey      string `env:"SPACES_SECRET_KEY"`
+}
+
+func main() {
+	// Parse command-line flags: all positional arguments are paths to scanned images,
+	// in gallery order
+	tapeId := flag.Int("tape", 0, "ID of the tape whose images are being uploaded")
+	replace := flag.Bool("replace", false, "Replace any existing images for the tape, deleting those that aren't overwritten")
+	thumbnailSize := flag.Int("thumbnail-size", upload.DefaultThumbnailSize, "Size in pixels of the longest side of the generated thumbnail")
+	dryRun := flag.Bool("dry-run", false, "Print the files that would be uploaded, without uploading them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s --tape=<id> [flags] <scan> [<scan>...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *tapeId <= 0 {
+		log.Fatalf("--tape is required and must be a positive tape ID")
+	}
+	if flag.NArg() == 0 {
+		log.Fatalf("at least one scanned image is required")
+	}
+
+	// Load config from .env
+	err := godotenv.Load()
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("error loading .env file: %v", err)
+	}
+	config := Config{}
+	if err := env.Set(&config); err != nil {
+		log.Fatalf("error loading config: %v", err)
+	}
+
+	// Terminate on SIGINT etc.
+	ctx, close := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer close()
+
+	// Read each scan and convert them to the set of files we need to upload
+	scans := make([]upload.Scan, 0, flag.NArg())
+	for _, path := range flag.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("error reading scan: %v", err)
+		}
+		scans = append(scans, upload.Scan{Name: path, Data: data})
+	}
+	files, err := upload.PrepareFiles(*tapeId, scans, *thumbnailSize)
+	if err != nil {
+		log.Fatalf("error preparing images: %v", err)
+	}
+
+	// In dry-run mode, just report what we'd upload
+	if *dryRun {
+		for _, file := range files {
+			fmt.Printf("%s (%d bytes)", file.Filename, len(file.Data))
+			if file.Metadata != nil {
+				fmt.Printf(": %sx%s, color %s, rotated %s", file.Metadata["Width"], file.Metadata["Height"], file.Metadata["Color"], file.Metadata["Rotated"])
+			}
+			fmt.Printf("\n")
+		}
+		return
+	}
+
+	uploader, err := initUploader(&config)
+	if err != nil {
+		log.Fatalf("error initializing storage client: %v", err)
+	}
+	result, err := upload.Upload(ctx, uploader, *tapeId, files, *replace)
+	if result != nil {
+		for _, filename := range result.Uploaded {
+			fmt.Printf("Uploaded %s.\n", filename)
+		}
+		for _, filename := range result.Deleted {
+			fmt.Printf("Deleted %s.\n", filename)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Upload failed: %v", err)
+	}
+	fmt.Printf("Uploaded %d images for tape %d.\n", len(result.Uploaded), *tapeId)
+}
+
+// initUploader returns an uploader for the local directory named by LOCAL_STORAGE_DIR
+// if set, or for our Spaces bucket otherwise
+func initUploader(config *Config) (storage.Uploader, error) {
+	if config.LocalStorageDir != "" {
+		fmt.Printf("Using images in local directory %s.\n", config.LocalStorageDir)
+		return storage.NewLocalUploader(config.LocalStorageDir)
+	}
+	if config.SpacesBucketName == "" || config.SpacesRegionName == "" || config.SpacesEndpointOrigin == "" || config.SpacesAccessKeyId == "" || config.SpacesSecretKey == "" {
+		return nil, fmt.Errorf("SPACES_BUCKET_NAME, SPACES_REGION_NAME, SPACES_ENDPOINT_URL, SPACES_ACCESS_KEY_ID, and SPACES_SECRET_KEY are required unless LOCAL_STORAGE_DIR is set")
+	}
+	return storage.NewUploader(
+		config.SpacesAccessKeyId,
+		config.SpacesSecretKey,
+		config.SpacesEndpointOrigin,
+		config.SpacesRegionName,
+		config.SpacesBucketName,
+	)
+}
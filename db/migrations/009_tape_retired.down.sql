@@ -0,0 +1,6 @@
+begin;
+
+alter table tapes.tape
+    drop column retired_at;
+
+commit;
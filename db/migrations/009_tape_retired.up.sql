@@ -0,0 +1,11 @@
+begin;
+
+alter table tapes.tape
+    add column retired_at timestamptz;
+
+comment on column tapes.tape.retired_at is
+    'Timestamp at which this tape was found to be missing from the spreadsheet during '
+    'a sync, in which case it is no longer listed in the catalog; or NULL if the tape '
+    'is still active.';
+
+commit;
@@ -0,0 +1,9 @@
+begin;
+
+drop index tapes.tape_to_tag_tag_name_trgm_idx;
+drop index tapes.tape_series_name_trgm_idx;
+drop index tapes.tape_title_trgm_idx;
+
+-- The pg_trgm extension is left in place, since it's not specific to the tapes schema
+
+commit;
@@ -0,0 +1,14 @@
+begin;
+
+create extension if not exists pg_trgm;
+
+create index tape_title_trgm_idx
+    on tapes.tape using gin (title gin_trgm_ops);
+
+create index tape_series_name_trgm_idx
+    on tapes.tape using gin (series_name gin_trgm_ops);
+
+create index tape_to_tag_tag_name_trgm_idx
+    on tapes.tape_to_tag using gin (tag_name gin_trgm_ops);
+
+commit;
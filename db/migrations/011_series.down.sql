@@ -0,0 +1,21 @@
+begin;
+
+drop index tapes.series_name_trgm_idx;
+
+alter table tapes.tape
+    add column series_name text not null default '';
+
+update tapes.tape set series_name = series.name
+from tapes.series
+where series.id = tape.series_id;
+
+create index tape_series_name_trgm_idx
+    on tapes.tape using gin (series_name gin_trgm_ops);
+
+alter table tapes.tape
+    drop column series_index,
+    drop column series_id;
+
+drop table tapes.series;
+
+commit;
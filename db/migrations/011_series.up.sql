@@ -0,0 +1,82 @@
+begin;
+
+create table tapes.series (
+    id          serial primary key,
+    created_at  timestamptz not null default now(),
+    name        text not null,
+    description text not null default '',
+    ordering    integer not null default 0
+);
+
+alter table tapes.series
+    add constraint series_name_unique
+    unique (name);
+
+alter table tapes.series
+    add constraint series_name_must_not_be_empty
+    check (name != '');
+
+comment on table tapes.series is
+    'A named series of tapes that belong together, e.g. a multi-part instructional '
+    'program.';
+comment on column tapes.series.id is
+    'Unique, auto-incrementing ID of the series.';
+comment on column tapes.series.created_at is
+    'Timestamp at which this series was first created.';
+comment on column tapes.series.name is
+    'Unique, human-readable name of the series.';
+comment on column tapes.series.description is
+    'Optional description of the series, or an empty string if not set.';
+comment on column tapes.series.ordering is
+    'Value used to order series relative to one another in listings, ascending; ties '
+    'are broken by name.';
+
+alter table tapes.tape
+    add column series_id integer,
+    add column series_index integer;
+
+alter table tapes.tape
+    add constraint tape_series_id_fk
+    foreign key (series_id) references tapes.series (id);
+
+alter table tapes.tape
+    add constraint tape_series_index_unique
+    unique (series_id, series_index)
+    deferrable;
+
+alter table tapes.tape
+    add constraint tape_series_index_must_match_series_id
+    check ((series_id is null) = (series_index is null) and (series_index is null or series_index > 0));
+
+comment on column tapes.tape.series_id is
+    'ID of the series to which this tape belongs; or NULL if not part of a series.';
+comment on column tapes.tape.series_index is
+    '1-based position of this tape within its series; or NULL if not part of a series.';
+
+-- Migrate existing free-text series names into the new table, numbering the tapes in
+-- each series in order of tape ID
+insert into tapes.series (name)
+select distinct series_name from tapes.tape where series_name != '';
+
+update tapes.tape set
+    series_id = numbered.series_id,
+    series_index = numbered.series_index
+from (
+    select
+        tape.id as tape_id,
+        series.id as series_id,
+        row_number() over (partition by series.id order by tape.id) as series_index
+    from tapes.tape
+    join tapes.series on series.name = tape.series_name
+) as numbered
+where tape.id = numbered.tape_id;
+
+drop index tapes.tape_series_name_trgm_idx;
+
+alter table tapes.tape
+    drop column series_name;
+
+create index series_name_trgm_idx
+    on tapes.series using gin (name gin_trgm_ops);
+
+commit;
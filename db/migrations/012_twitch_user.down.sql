@@ -0,0 +1,5 @@
+begin;
+
+drop table tapes.twitch_user;
+
+commit;
@@ -0,0 +1,20 @@
+begin;
+
+create table tapes.twitch_user (
+    twitch_user_id text primary key,
+    display_name   text not null,
+    updated_at     timestamptz not null default now()
+);
+
+comment on table tapes.twitch_user is
+    'Cached details of a Twitch user, so that we can display human-readable names for '
+    'tape contributors without hitting the Twitch API on every request.';
+comment on column tapes.twitch_user.twitch_user_id is
+    'Numeric Twitch User ID, as a string.';
+comment on column tapes.twitch_user.display_name is
+    'Display name most recently fetched from the Twitch API for this user.';
+comment on column tapes.twitch_user.updated_at is
+    'Timestamp at which display_name was last fetched from the Twitch API; used to '
+    'determine when the cached value should be refreshed.';
+
+commit;
@@ -0,0 +1,14 @@
+begin;
+
+drop trigger twitch_user_bump_catalog_revision on tapes.twitch_user;
+drop trigger series_bump_catalog_revision on tapes.series;
+drop trigger favorite_bump_catalog_revision on tapes.favorite;
+drop trigger tape_to_tag_bump_catalog_revision on tapes.tape_to_tag;
+drop trigger image_bump_catalog_revision on tapes.image;
+drop trigger tape_bump_catalog_revision on tapes.tape;
+
+drop function tapes.bump_catalog_revision;
+
+drop table tapes.catalog_revision;
+
+commit;
@@ -0,0 +1,62 @@
+begin;
+
+create table tapes.catalog_revision (
+    id         boolean primary key default true,
+    revision   bigint not null default 0,
+    updated_at timestamptz not null default now()
+);
+
+alter table tapes.catalog_revision
+    add constraint catalog_revision_single_row
+    check (id);
+
+insert into tapes.catalog_revision (id) values (true);
+
+comment on table tapes.catalog_revision is
+    'Single-row table holding a counter that is incremented whenever any data exposed '
+    'via the catalog API changes, so that clients can cache catalog responses until '
+    'the revision changes.';
+comment on column tapes.catalog_revision.id is
+    'Always true; ensures that the table contains only a single row.';
+comment on column tapes.catalog_revision.revision is
+    'Number that is incremented by any statement that modifies catalog data.';
+comment on column tapes.catalog_revision.updated_at is
+    'Time at which the revision was last incremented.';
+
+create function tapes.bump_catalog_revision() returns trigger as $$
+begin
+    update tapes.catalog_revision set
+        revision = revision + 1,
+        updated_at = now();
+    return null;
+end;
+$$ language plpgsql;
+
+comment on function tapes.bump_catalog_revision is
+    'Trigger function that increments tapes.catalog_revision.revision.';
+
+create trigger tape_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.tape
+    for each statement execute function tapes.bump_catalog_revision();
+
+create trigger image_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.image
+    for each statement execute function tapes.bump_catalog_revision();
+
+create trigger tape_to_tag_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.tape_to_tag
+    for each statement execute function tapes.bump_catalog_revision();
+
+create trigger favorite_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.favorite
+    for each statement execute function tapes.bump_catalog_revision();
+
+create trigger series_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.series
+    for each statement execute function tapes.bump_catalog_revision();
+
+create trigger twitch_user_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.twitch_user
+    for each statement execute function tapes.bump_catalog_revision();
+
+commit;
@@ -0,0 +1,15 @@
+begin;
+
+create or replace function tapes.bump_catalog_revision() returns trigger as $$
+begin
+    update tapes.catalog_revision set
+        revision = revision + 1,
+        updated_at = now();
+    return null;
+end;
+$$ language plpgsql;
+
+comment on function tapes.bump_catalog_revision is
+    'Trigger function that increments tapes.catalog_revision.revision.';
+
+commit;
@@ -0,0 +1,21 @@
+begin;
+
+create or replace function tapes.bump_catalog_revision() returns trigger as $$
+declare
+    new_revision bigint;
+begin
+    update tapes.catalog_revision set
+        revision = revision + 1,
+        updated_at = now()
+    returning revision into new_revision;
+    perform pg_notify('tapes_catalog_changed', new_revision::text);
+    return null;
+end;
+$$ language plpgsql;
+
+comment on function tapes.bump_catalog_revision is
+    'Trigger function that increments tapes.catalog_revision.revision and sends a '
+    'notification with the new revision on the tapes_catalog_changed channel, which '
+    'is delivered to listeners once the transaction commits.';
+
+commit;
@@ -0,0 +1,5 @@
+begin;
+
+drop table tapes.sync_warning;
+
+commit;
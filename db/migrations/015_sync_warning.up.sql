@@ -0,0 +1,54 @@
+begin;
+
+create table tapes.sync_warning (
+    sync_uuid  uuid not null,
+    index      integer not null,
+    source     text not null,
+    kind       text not null,
+    tape_id    integer,
+    row_number integer,
+    filename   text,
+    message    text not null
+);
+
+comment on table tapes.sync_warning is
+    'Record of a single non-fatal problem encountered during a sync, typically '
+    'resulting in a tape or image being excluded from the catalog.';
+comment on column tapes.sync_warning.sync_uuid is
+    'UUID of the sync that emitted this warning.';
+comment on column tapes.sync_warning.index is
+    'Order in which this warning was emitted during the sync, starting at 0.';
+comment on column tapes.sync_warning.source is
+    'Where the problem was found: ''spreadsheet'' for a row in the inventory '
+    'spreadsheet, ''storage'' for an image file in the storage bucket, or ''sync'' for '
+    'a problem found while reconciling the two.';
+comment on column tapes.sync_warning.kind is
+    'Machine-readable code identifying the type of problem, e.g. '
+    '''missing_thumbnail''.';
+comment on column tapes.sync_warning.tape_id is
+    'ID of the tape that the warning applies to, if known. Not a foreign key, since '
+    'the tape may never have been synced.';
+comment on column tapes.sync_warning.row_number is
+    'For spreadsheet warnings, the user-facing row number (starting at 1 for the '
+    'heading row) at which the problem occurred.';
+comment on column tapes.sync_warning.filename is
+    'For storage warnings, the name of the image file in question.';
+comment on column tapes.sync_warning.message is
+    'Human-readable message describing the problem.';
+
+alter table tapes.sync_warning
+    add constraint sync_warning_sync_uuid_fk
+    foreign key (sync_uuid) references tapes.sync (uuid)
+    on delete cascade;
+
+alter table tapes.sync_warning
+    add constraint sync_warning_sync_uuid_index_unique
+    unique (sync_uuid, index);
+
+alter table tapes.sync_warning
+    add constraint sync_warning_source_check
+    check (source in ('spreadsheet', 'storage', 'sync'));
+
+create index sync_warning_tape_id_idx on tapes.sync_warning (tape_id);
+
+commit;
@@ -0,0 +1,6 @@
+begin;
+
+alter table tapes.sync
+    drop column source_fingerprint;
+
+commit;
@@ -0,0 +1,10 @@
+begin;
+
+alter table tapes.sync
+    add column source_fingerprint text;
+
+comment on column tapes.sync.source_fingerprint is
+    'Hash of all data gathered from the inventory spreadsheet and storage bucket for a '
+    'successful sync, used to skip scheduled syncs when nothing has changed.';
+
+commit;
@@ -0,0 +1,25 @@
+begin;
+
+create table tapes.image_metadata (
+    filename      text primary key,
+    etag          text not null,
+    last_modified timestamptz not null,
+    metadata      jsonb not null,
+    updated_at    timestamptz not null default now()
+);
+
+comment on table tapes.image_metadata is
+    'Cached metadata for an image file in the storage bucket, so that syncs only need '
+    'to request metadata for files that have changed.';
+comment on column tapes.image_metadata.filename is
+    'Name of the image file, i.e. its key in the storage bucket.';
+comment on column tapes.image_metadata.etag is
+    'ETag of the file at the time its metadata was retrieved.';
+comment on column tapes.image_metadata.last_modified is
+    'Last-modified time of the file at the time its metadata was retrieved.';
+comment on column tapes.image_metadata.metadata is
+    'Raw key/value pairs retrieved as metadata for the file, as a JSON object.';
+comment on column tapes.image_metadata.updated_at is
+    'Time at which this metadata was retrieved.';
+
+commit;
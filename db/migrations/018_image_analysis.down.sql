@@ -0,0 +1,7 @@
+begin;
+
+alter table tapes.image_metadata
+    drop column analysis,
+    drop column analysis_version;
+
+commit;
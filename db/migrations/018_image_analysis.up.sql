@@ -0,0 +1,24 @@
+begin;
+
+-- Previously-cached metadata doesn't include an analysis of the image itself, so it's
+-- recorded with an analysis_version of 0: images that require analysis will be
+-- analyzed on the next sync, but images that don't will continue to use cached values
+alter table tapes.image_metadata
+    add column analysis_version integer not null default 0,
+    add column analysis jsonb not null default 'null'::jsonb;
+
+alter table tapes.image_metadata
+    alter column analysis_version drop default,
+    alter column analysis drop default;
+
+comment on column tapes.image_metadata.analysis_version is
+    'Version of the analysis that was performed on the file''s pixel data, or 0 if the '
+    'file was not analyzed: cached analyses older than the current version are '
+    'recomputed as needed, rather than discarding all cached metadata whenever new '
+    'details are added to the analysis.';
+comment on column tapes.image_metadata.analysis is
+    'Details derived from the pixel data of the file (its dimensions, dominant color, '
+    'orientation, etc.), as a JSON object, or JSON null if the file was not analyzed or '
+    'could not be decoded as an image.';
+
+commit;
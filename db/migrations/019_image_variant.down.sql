@@ -0,0 +1,6 @@
+begin;
+
+alter table tapes.image
+    drop column variants;
+
+commit;
@@ -0,0 +1,15 @@
+begin;
+
+alter table tapes.image
+    add column variants jsonb not null default '[]'::jsonb;
+
+alter table tapes.image
+    add constraint image_variants_must_be_array
+    check (jsonb_typeof(variants) = 'array');
+
+comment on column tapes.image.variants is
+    'Alternate versions of the image that are available in the storage bucket, as a '
+    'JSON array of objects with ''format'' (e.g. ''webp'') and ''width'' (in pixels) '
+    'keys, sorted by format and then width.';
+
+commit;
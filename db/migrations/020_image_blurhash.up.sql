@@ -0,0 +1,11 @@
+begin;
+
+alter table tapes.image
+    add column blurhash text not null default '';
+
+comment on column tapes.image.blurhash is
+    'Compact encoding of the image (see https://blurha.sh), from which the frontend can '
+    'render a blurred placeholder while the full image loads. Empty if the image could '
+    'not be decoded and its metadata did not supply a blurhash.';
+
+commit;
@@ -0,0 +1,39 @@
+begin;
+
+create table tapes.thumbnail (
+    tape_id      integer primary key,
+
+    color        text not null,
+    width        integer not null,
+    height       integer not null,
+    blurhash     text not null,
+    content_hash text not null
+);
+
+alter table tapes.thumbnail
+    add constraint thumbnail_tape_id_fk
+    foreign key (tape_id) references tapes.tape (id);
+
+alter table tapes.thumbnail
+    add constraint thumbnail_color_must_be_hex
+    check (color ~* '^#[a-f0-9]{3}[a-f0-9]{3}$');
+
+comment on table tapes.thumbnail is
+    'Metadata for the single low-res thumbnail image that represents a specific tape.';
+comment on column tapes.thumbnail.color is
+    'The dominant color in the thumbnail, hex-formatted (with hash prefix).';
+comment on column tapes.thumbnail.width is
+    'Width of the thumbnail image file, in pixels.';
+comment on column tapes.thumbnail.height is
+    'Height of the thumbnail image file, in pixels.';
+comment on column tapes.thumbnail.blurhash is
+    'Compact encoding of the thumbnail (see https://blurha.sh), from which the frontend '
+    'can render a blurred placeholder while the thumbnail loads.';
+comment on column tapes.thumbnail.content_hash is
+    'Hex-encoded SHA-256 digest of the thumbnail image file, as of the last sync.';
+
+create trigger thumbnail_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.thumbnail
+    for each statement execute function tapes.bump_catalog_revision();
+
+commit;
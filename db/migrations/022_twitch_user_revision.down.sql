@@ -0,0 +1,11 @@
+begin;
+
+drop trigger twitch_user_truncate_bump_catalog_revision on tapes.twitch_user;
+drop trigger twitch_user_rename_bump_catalog_revision on tapes.twitch_user;
+drop trigger twitch_user_bump_catalog_revision on tapes.twitch_user;
+
+create trigger twitch_user_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.twitch_user
+    for each statement execute function tapes.bump_catalog_revision();
+
+commit;
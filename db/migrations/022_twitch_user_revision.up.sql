@@ -0,0 +1,23 @@
+begin;
+
+-- Display names are re-recorded whenever they're refreshed from Twitch, which updates
+-- updated_at even if the name hasn't changed: since the catalog only exposes display
+-- names, the catalog revision should only be bumped when a user is added or removed,
+-- or when their display name actually changes. Statement-level triggers fire even for
+-- statements that modify no rows, so these triggers are row-level instead.
+drop trigger twitch_user_bump_catalog_revision on tapes.twitch_user;
+
+create trigger twitch_user_bump_catalog_revision
+    after insert or delete on tapes.twitch_user
+    for each row execute function tapes.bump_catalog_revision();
+
+create trigger twitch_user_rename_bump_catalog_revision
+    after update on tapes.twitch_user
+    for each row when (old.display_name is distinct from new.display_name)
+    execute function tapes.bump_catalog_revision();
+
+create trigger twitch_user_truncate_bump_catalog_revision
+    after truncate on tapes.twitch_user
+    for each statement execute function tapes.bump_catalog_revision();
+
+commit;
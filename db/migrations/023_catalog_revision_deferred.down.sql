@@ -0,0 +1,84 @@
+begin;
+
+drop trigger tape_truncate_bump_catalog_revision on tapes.tape;
+drop trigger tape_update_bump_catalog_revision on tapes.tape;
+drop trigger tape_bump_catalog_revision on tapes.tape;
+
+create trigger tape_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.tape
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger image_truncate_bump_catalog_revision on tapes.image;
+drop trigger image_update_bump_catalog_revision on tapes.image;
+drop trigger image_bump_catalog_revision on tapes.image;
+
+create trigger image_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.image
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger tape_to_tag_truncate_bump_catalog_revision on tapes.tape_to_tag;
+drop trigger tape_to_tag_update_bump_catalog_revision on tapes.tape_to_tag;
+drop trigger tape_to_tag_bump_catalog_revision on tapes.tape_to_tag;
+
+create trigger tape_to_tag_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.tape_to_tag
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger favorite_truncate_bump_catalog_revision on tapes.favorite;
+drop trigger favorite_update_bump_catalog_revision on tapes.favorite;
+drop trigger favorite_bump_catalog_revision on tapes.favorite;
+
+create trigger favorite_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.favorite
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger series_truncate_bump_catalog_revision on tapes.series;
+drop trigger series_update_bump_catalog_revision on tapes.series;
+drop trigger series_bump_catalog_revision on tapes.series;
+
+create trigger series_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.series
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger thumbnail_truncate_bump_catalog_revision on tapes.thumbnail;
+drop trigger thumbnail_update_bump_catalog_revision on tapes.thumbnail;
+drop trigger thumbnail_bump_catalog_revision on tapes.thumbnail;
+
+create trigger thumbnail_bump_catalog_revision
+    after insert or update or delete or truncate on tapes.thumbnail
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger twitch_user_rename_bump_catalog_revision on tapes.twitch_user;
+drop trigger twitch_user_bump_catalog_revision on tapes.twitch_user;
+
+create trigger twitch_user_bump_catalog_revision
+    after insert or delete on tapes.twitch_user
+    for each row execute function tapes.bump_catalog_revision();
+
+create trigger twitch_user_rename_bump_catalog_revision
+    after update on tapes.twitch_user
+    for each row when (old.display_name is distinct from new.display_name)
+    execute function tapes.bump_catalog_revision();
+
+comment on column tapes.catalog_revision.revision is
+    'Number that is incremented by any statement that modifies catalog data.';
+
+create or replace function tapes.bump_catalog_revision() returns trigger as $$
+declare
+    new_revision bigint;
+begin
+    update tapes.catalog_revision set
+        revision = revision + 1,
+        updated_at = now()
+    returning revision into new_revision;
+    perform pg_notify('tapes_catalog_changed', new_revision::text);
+    return null;
+end;
+$$ language plpgsql;
+
+comment on function tapes.bump_catalog_revision is
+    'Trigger function that increments tapes.catalog_revision.revision and sends a '
+    'notification with the new revision on the tapes_catalog_changed channel, which '
+    'is delivered to listeners once the transaction commits.';
+
+commit;
@@ -0,0 +1,154 @@
+begin;
+
+-- Statement-level triggers bumped the catalog revision (and notified listeners) once
+-- for every statement that touched catalog data, including upserts that left rows
+-- unchanged, and the update held a lock on the catalog_revision row until the end of
+-- the transaction: so a sync would bump the revision thousands of times, and would
+-- block any concurrent writes to favorites until it finished. Catalog revision triggers
+-- are now deferred constraint triggers that fire only for rows that actually changed,
+-- and bump_catalog_revision only bumps the revision once per firing statement: since
+-- deferred triggers all fire as the transaction commits, that's once per transaction.
+create or replace function tapes.bump_catalog_revision() returns trigger as $$
+declare
+    new_revision bigint;
+begin
+    if current_setting('tapes.catalog_revision_bumped_at', true) = statement_timestamp()::text then
+        return null;
+    end if;
+    perform set_config('tapes.catalog_revision_bumped_at', statement_timestamp()::text, true);
+
+    update tapes.catalog_revision set
+        revision = revision + 1,
+        updated_at = now()
+    returning revision into new_revision;
+    perform pg_notify('tapes_catalog_changed', new_revision::text);
+    return null;
+end;
+$$ language plpgsql;
+
+comment on function tapes.bump_catalog_revision is
+    'Trigger function that increments tapes.catalog_revision.revision and sends a '
+    'notification with the new revision on the tapes_catalog_changed channel, which '
+    'is delivered to listeners once the transaction commits. Has no effect if the '
+    'revision has already been bumped by the current statement.';
+
+comment on column tapes.catalog_revision.revision is
+    'Number that is incremented once by any transaction that modifies catalog data.';
+
+drop trigger tape_bump_catalog_revision on tapes.tape;
+
+create constraint trigger tape_bump_catalog_revision
+    after insert or delete on tapes.tape
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger tape_update_bump_catalog_revision
+    after update on tapes.tape
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger tape_truncate_bump_catalog_revision
+    after truncate on tapes.tape
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger image_bump_catalog_revision on tapes.image;
+
+create constraint trigger image_bump_catalog_revision
+    after insert or delete on tapes.image
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger image_update_bump_catalog_revision
+    after update on tapes.image
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger image_truncate_bump_catalog_revision
+    after truncate on tapes.image
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger tape_to_tag_bump_catalog_revision on tapes.tape_to_tag;
+
+create constraint trigger tape_to_tag_bump_catalog_revision
+    after insert or delete on tapes.tape_to_tag
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger tape_to_tag_update_bump_catalog_revision
+    after update on tapes.tape_to_tag
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger tape_to_tag_truncate_bump_catalog_revision
+    after truncate on tapes.tape_to_tag
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger favorite_bump_catalog_revision on tapes.favorite;
+
+create constraint trigger favorite_bump_catalog_revision
+    after insert or delete on tapes.favorite
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger favorite_update_bump_catalog_revision
+    after update on tapes.favorite
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger favorite_truncate_bump_catalog_revision
+    after truncate on tapes.favorite
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger series_bump_catalog_revision on tapes.series;
+
+create constraint trigger series_bump_catalog_revision
+    after insert or delete on tapes.series
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger series_update_bump_catalog_revision
+    after update on tapes.series
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger series_truncate_bump_catalog_revision
+    after truncate on tapes.series
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger thumbnail_bump_catalog_revision on tapes.thumbnail;
+
+create constraint trigger thumbnail_bump_catalog_revision
+    after insert or delete on tapes.thumbnail
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger thumbnail_update_bump_catalog_revision
+    after update on tapes.thumbnail
+    deferrable initially deferred
+    for each row when (old.* is distinct from new.*)
+    execute function tapes.bump_catalog_revision();
+
+create trigger thumbnail_truncate_bump_catalog_revision
+    after truncate on tapes.thumbnail
+    for each statement execute function tapes.bump_catalog_revision();
+
+drop trigger twitch_user_rename_bump_catalog_revision on tapes.twitch_user;
+drop trigger twitch_user_bump_catalog_revision on tapes.twitch_user;
+
+create constraint trigger twitch_user_bump_catalog_revision
+    after insert or delete on tapes.twitch_user
+    deferrable initially deferred
+    for each row execute function tapes.bump_catalog_revision();
+
+create constraint trigger twitch_user_rename_bump_catalog_revision
+    after update on tapes.twitch_user
+    deferrable initially deferred
+    for each row when (old.display_name is distinct from new.display_name)
+    execute function tapes.bump_catalog_revision();
+
+commit;
@@ -1,3 +1,42 @@
--- name: ApplySeries :execresult
-update tapes.tape set series_name = @series_name
-where tape.id = any(sqlc.arg('tape_ids')::integer[]);
+-- name: ApplySeries :one
+with created_series as (
+    insert into tapes.series (name, description, ordering)
+    select
+        sqlc.narg('series_name')::text,
+        coalesce(sqlc.narg('description')::text, ''),
+        coalesce(sqlc.narg('ordering')::integer, 0)
+    where sqlc.narg('series_id')::integer is null
+    on conflict (name) do update set
+        description = coalesce(sqlc.narg('description')::text, series.description),
+        ordering = coalesce(sqlc.narg('ordering')::integer, series.ordering)
+    returning series.id
+),
+updated_series as (
+    update tapes.series set
+        name = coalesce(sqlc.narg('series_name')::text, series.name),
+        description = coalesce(sqlc.narg('description')::text, series.description),
+        ordering = coalesce(sqlc.narg('ordering')::integer, series.ordering)
+    where series.id = sqlc.narg('series_id')::integer
+    returning series.id
+),
+target_series as (
+    select created_series.id from created_series
+    union all
+    select updated_series.id from updated_series
+),
+updated_tapes as (
+    update tapes.tape set
+        series_id = case
+            when tape.id = any(sqlc.arg('tape_ids')::integer[]) then target_series.id
+            else null
+        end,
+        series_index = case
+            when tape.id = any(sqlc.arg('tape_ids')::integer[]) then array_position(sqlc.arg('tape_ids')::integer[], tape.id)
+            else null
+        end
+    from target_series
+    where tape.series_id = target_series.id
+        or tape.id = any(sqlc.arg('tape_ids')::integer[])
+    returning tape.id
+)
+select target_series.id from target_series;
@@ -0,0 +1,24 @@
+-- name: GetContributors :many
+select
+    tape.contributor_id::text as contributor_id,
+    count(*) as num_tapes,
+    min(tape.created_at)::timestamptz as first_contributed_at,
+    max(tape.created_at)::timestamptz as last_contributed_at
+from tapes.tape
+where tape.contributor_id is not null
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape.contributor_id
+order by count(*) desc, min(tape.created_at), tape.contributor_id;
+
+-- name: GetContributor :one
+select
+    tape.contributor_id::text as contributor_id,
+    count(*) as num_tapes,
+    min(tape.created_at)::timestamptz as first_contributed_at,
+    max(tape.created_at)::timestamptz as last_contributed_at
+from tapes.tape
+where tape.contributor_id = @contributor_id::text
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape.contributor_id;
@@ -4,15 +4,30 @@ select
     tape.title,
     tape.year,
     tape.runtime,
-    tape.series_name,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
     tape.contributor_id,
+    tape.created_at,
     (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
     jsonb_agg(jsonb_build_object(
         'index', image.index,
         'color', image.color,
         'width', image.width,
         'height', image.height,
-        'rotated', image.rotated
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
     ) order by image.index) as images,
     array(
         select tag_name
@@ -22,8 +37,60 @@ select
     )::text[] as tags
 from tapes.tape
 join tapes.image on image.tape_id = tape.id
-group by tape.id
-order by tape.id;
+left join tapes.series on series.id = tape.series_id
+cross join lateral (
+    select
+        case when @sort_by::text = 'title' then lower(tape.title) end as text_value,
+        case
+            when @sort_by::text = 'year' then tape.year::bigint
+            when @sort_by::text = 'runtime' then tape.runtime::bigint
+            when @sort_by::text = 'favorites' then (select count(*) from tapes.favorite where favorite.tape_id = tape.id)
+            when @sort_by::text = 'series' then tape.series_index::bigint
+        end as int_value,
+        case when @sort_by::text = 'added' then tape.created_at end as time_value
+) as sort_key
+where tape.retired_at is null
+    and (sqlc.narg('tag')::text is null or exists (
+        select 1 from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+            and tape_to_tag.tag_name = sqlc.narg('tag')::text
+    ))
+    and (sqlc.narg('series_name')::text is null or series.name = sqlc.narg('series_name')::text)
+    and (sqlc.narg('series_id')::integer is null or tape.series_id = sqlc.narg('series_id')::integer)
+    and (sqlc.narg('contributor_id')::text is null or tape.contributor_id = sqlc.narg('contributor_id')::text)
+    and (sqlc.narg('year_min')::integer is null or tape.year >= sqlc.narg('year_min')::integer)
+    and (sqlc.narg('year_max')::integer is null or tape.year <= sqlc.narg('year_max')::integer)
+    and (sqlc.narg('runtime_min')::integer is null or tape.runtime >= sqlc.narg('runtime_min')::integer)
+    and (sqlc.narg('runtime_max')::integer is null or tape.runtime <= sqlc.narg('runtime_max')::integer)
+    and (sqlc.narg('after_id')::integer is null or (
+        case when @descending::boolean then (
+            sort_key.text_value < lower(sqlc.narg('after_text')::text)
+            or sort_key.int_value < sqlc.narg('after_int')::bigint
+            or sort_key.time_value < sqlc.narg('after_time')::timestamptz
+        ) else (
+            sort_key.text_value > lower(sqlc.narg('after_text')::text)
+            or sort_key.int_value > sqlc.narg('after_int')::bigint
+            or sort_key.time_value > sqlc.narg('after_time')::timestamptz
+        ) end
+        or (sort_key.int_value is null and sqlc.narg('after_int')::bigint is not null)
+        or (
+            sort_key.text_value is not distinct from lower(sqlc.narg('after_text')::text)
+            and sort_key.int_value is not distinct from sqlc.narg('after_int')::bigint
+            and sort_key.time_value is not distinct from sqlc.narg('after_time')::timestamptz
+            and case when @descending::boolean then tape.id < sqlc.narg('after_id')::integer else tape.id > sqlc.narg('after_id')::integer end
+        )
+    ))
+group by tape.id, series.id, sort_key.text_value, sort_key.int_value, sort_key.time_value
+order by
+    case when not @descending::boolean then sort_key.text_value end asc,
+    case when @descending::boolean then sort_key.text_value end desc,
+    case when not @descending::boolean then sort_key.int_value end asc nulls last,
+    case when @descending::boolean then sort_key.int_value end desc nulls last,
+    case when not @descending::boolean then sort_key.time_value end asc,
+    case when @descending::boolean then sort_key.time_value end desc,
+    case when @descending::boolean then tape.id end desc,
+    tape.id asc
+limit sqlc.narg('page_size')::integer;
 
 -- name: GetTape :one
 select
@@ -31,15 +98,30 @@ select
     tape.title,
     tape.year,
     tape.runtime,
-    tape.series_name,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
     tape.contributor_id,
+    tape.created_at,
     (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
     jsonb_agg(jsonb_build_object(
         'index', image.index,
         'color', image.color,
         'width', image.width,
         'height', image.height,
-        'rotated', image.rotated
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
     ) order by image.index) as images,
     array(
         select tag_name
@@ -49,12 +131,27 @@ select
     )::text[] as tags
 from tapes.tape
 join tapes.image on image.tape_id = tape.id
+left join tapes.series on series.id = tape.series_id
 where tape.id = @tape_id
-group by tape.id
+    and tape.retired_at is null
+group by tape.id, series.id
 order by tape.id;
 
 -- name: GetTapeContributorIds :many
 select
     distinct tape.contributor_id::text
 from tapes.tape
-where tape.contributor_id is not null;
+where tape.contributor_id is not null
+    and tape.retired_at is null;
+
+-- name: GetCatalogRevision :one
+select
+    catalog_revision.revision,
+    coalesce((
+        select sync.uuid from tapes.sync
+        where sync.finished_at is not null
+            and sync.error is null
+        order by sync.finished_at desc
+        limit 1
+    ), '00000000-0000-0000-0000-000000000000')::uuid as latest_sync_uuid
+from tapes.catalog_revision;
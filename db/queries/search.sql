@@ -0,0 +1,61 @@
+-- name: SearchTapes :many
+select
+    tape.id,
+    tape.title,
+    tape.year,
+    tape.runtime,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
+    tape.contributor_id,
+    (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
+    jsonb_agg(jsonb_build_object(
+        'index', image.index,
+        'color', image.color,
+        'width', image.width,
+        'height', image.height,
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
+    ) order by image.index) as images,
+    array(
+        select tag_name
+        from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+        order by tag_name
+    )::text[] as tags,
+    greatest(
+        word_similarity(@query::text, tape.title),
+        word_similarity(@query::text, series.name),
+        coalesce((
+            select max(word_similarity(@query::text, tape_to_tag.tag_name))
+            from tapes.tape_to_tag
+            where tape_to_tag.tape_id = tape.id
+        ), 0)
+    )::real as rank
+from tapes.tape
+join tapes.image on image.tape_id = tape.id
+left join tapes.series on series.id = tape.series_id
+where tape.retired_at is null
+    and (
+        @query::text <% tape.title
+        or @query::text <% series.name
+        or exists (
+            select 1 from tapes.tape_to_tag
+            where tape_to_tag.tape_id = tape.id
+                and @query::text <% tape_to_tag.tag_name
+        )
+    )
+group by tape.id, series.id
+order by rank desc, tape.id
+limit @max_results::integer;
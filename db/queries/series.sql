@@ -0,0 +1,20 @@
+-- name: GetSeriesList :many
+select
+    series.id,
+    series.name,
+    series.description,
+    count(tape.id) as num_tapes
+from tapes.series
+join tapes.tape on tape.series_id = series.id
+where tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by series.id
+order by series.ordering, series.name;
+
+-- name: GetSeries :one
+select
+    series.id,
+    series.name,
+    series.description
+from tapes.series
+where series.id = @series_id;
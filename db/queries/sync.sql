@@ -1,3 +1,17 @@
+-- name: TryAcquireSyncLock :one
+select pg_try_advisory_lock('tapes'::regnamespace::oid::bigint)::boolean as acquired;
+
+-- name: ReleaseSyncLock :exec
+select pg_advisory_unlock('tapes'::regnamespace::oid::bigint);
+
+-- name: GetLatestSyncFingerprint :one
+select sync.source_fingerprint
+from tapes.sync
+where sync.finished_at is not null
+    and sync.error is null
+order by sync.started_at desc
+limit 1;
+
 -- name: CreateSync :exec
 insert into tapes.sync (
     uuid,
@@ -19,7 +33,8 @@ where
 update tapes.sync set
     finished_at = now(),
     num_tapes = @num_tapes::integer,
-    warnings = @warnings::text
+    warnings = @warnings::text,
+    source_fingerprint = sqlc.narg('source_fingerprint')
 where
     sync.uuid = @uuid
     and finished_at is null;
@@ -44,7 +59,14 @@ on conflict (id) do update set
     title = excluded.title,
     year = excluded.year,
     runtime = excluded.runtime,
-    contributor_id = excluded.contributor_id;
+    contributor_id = excluded.contributor_id,
+    retired_at = null;
+
+-- name: RetireTapes :execrows
+update tapes.tape set retired_at = now()
+where
+    tape.retired_at is null
+    and not (tape.id = any(@active_tape_ids::integer[]));
 
 -- name: SyncTapeTags :exec
 with deleted as (
@@ -65,17 +87,218 @@ insert into tapes.image (
     color,
     width,
     height,
-    rotated
+    rotated,
+    blurhash,
+    variants
 ) values (
     @tape_id,
     @index,
     @color,
     @width,
     @height,
-    @rotated
+    @rotated,
+    @blurhash,
+    coalesce(@variants::jsonb, '[]'::jsonb)
 )
 on conflict (tape_id, index) do update set
     color = excluded.color,
     width = excluded.width,
     height = excluded.height,
-    rotated = excluded.rotated;
+    rotated = excluded.rotated,
+    blurhash = excluded.blurhash,
+    variants = excluded.variants;
+
+-- name: SyncThumbnail :exec
+insert into tapes.thumbnail (
+    tape_id,
+    color,
+    width,
+    height,
+    blurhash,
+    content_hash
+) values (
+    @tape_id,
+    @color,
+    @width,
+    @height,
+    @blurhash,
+    @content_hash
+)
+on conflict (tape_id) do update set
+    color = excluded.color,
+    width = excluded.width,
+    height = excluded.height,
+    blurhash = excluded.blurhash,
+    content_hash = excluded.content_hash;
+
+-- name: DeleteThumbnail :execrows
+delete from tapes.thumbnail
+where thumbnail.tape_id = @tape_id;
+
+-- name: DeleteStaleImages :execrows
+delete from tapes.image
+where
+    image.tape_id = @tape_id
+    and not (image.index = any(@indices::integer[]));
+
+-- name: GetSyncedTapes :many
+select
+    tape.id,
+    tape.title,
+    tape.year,
+    tape.runtime,
+    tape.contributor_id,
+    (tape.retired_at is not null)::boolean as is_retired,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash,
+            'content_hash', thumbnail.content_hash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
+    coalesce(
+        jsonb_agg(jsonb_build_object(
+            'index', image.index,
+            'color', image.color,
+            'width', image.width,
+            'height', image.height,
+            'rotated', image.rotated,
+            'blurhash', image.blurhash,
+            'variants', image.variants
+        ) order by image.index) filter (where image.tape_id is not null),
+        '[]'::jsonb
+    )::jsonb as images,
+    array(
+        select tag_name
+        from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+        order by tag_name
+    )::text[] as tags
+from tapes.tape
+left join tapes.image on image.tape_id = tape.id
+group by tape.id
+order by tape.id;
+
+-- name: GetSyncs :many
+select
+    sync.uuid,
+    sync.started_at,
+    sync.finished_at,
+    sync.error,
+    sync.num_tapes,
+    coalesce(
+        nullif((select count(*) from tapes.sync_warning where sync_warning.sync_uuid = sync.uuid), 0),
+        array_length(string_to_array(nullif(sync.warnings, ''), E'\n'), 1),
+        0
+    )::integer as num_warnings
+from tapes.sync
+where sqlc.narg('after_uuid')::uuid is null
+    or sync.started_at < @after_started_at::timestamptz
+    or (sync.started_at = @after_started_at::timestamptz and sync.uuid > sqlc.narg('after_uuid')::uuid)
+order by sync.started_at desc, sync.uuid
+limit @page_size::integer;
+
+-- name: GetSync :one
+select
+    sync.uuid,
+    sync.started_at,
+    sync.finished_at,
+    sync.error,
+    sync.num_tapes,
+    sync.warnings
+from tapes.sync
+where sync.uuid = @uuid;
+
+-- name: RecordSyncWarning :exec
+insert into tapes.sync_warning (
+    sync_uuid,
+    index,
+    source,
+    kind,
+    tape_id,
+    row_number,
+    filename,
+    message
+) values (
+    @sync_uuid,
+    @index,
+    @source,
+    @kind,
+    sqlc.narg('tape_id'),
+    sqlc.narg('row_number'),
+    sqlc.narg('filename'),
+    @message
+);
+
+-- name: GetSyncWarnings :many
+select
+    sync_warning.source,
+    sync_warning.kind,
+    sync_warning.tape_id,
+    sync_warning.row_number,
+    sync_warning.filename,
+    sync_warning.message
+from tapes.sync_warning
+where sync_warning.sync_uuid = @sync_uuid
+order by sync_warning.index;
+
+-- name: GetRecentlyWarnedTapes :many
+with recent_sync as (
+    select sync.uuid, sync.started_at
+    from tapes.sync
+    where sync.finished_at is not null
+        and sync.error is null
+    order by sync.started_at desc
+    limit @num_syncs::integer
+)
+select
+    sync_warning.tape_id::integer as tape_id,
+    count(distinct recent_sync.uuid)::integer as num_syncs,
+    max(recent_sync.started_at)::timestamptz as last_warned_at,
+    array_agg(distinct sync_warning.kind order by sync_warning.kind)::text[] as kinds
+from tapes.sync_warning
+join recent_sync on recent_sync.uuid = sync_warning.sync_uuid
+where sync_warning.tape_id is not null
+group by sync_warning.tape_id
+order by sync_warning.tape_id;
+
+-- name: GetImageMetadata :many
+select
+    image_metadata.filename,
+    image_metadata.etag,
+    image_metadata.last_modified,
+    image_metadata.metadata,
+    image_metadata.analysis_version,
+    image_metadata.analysis
+from tapes.image_metadata
+where image_metadata.filename = any(@filenames::text[]);
+
+-- name: RecordImageMetadata :exec
+insert into tapes.image_metadata (
+    filename,
+    etag,
+    last_modified,
+    metadata,
+    analysis_version,
+    analysis,
+    updated_at
+) values (
+    @filename,
+    @etag,
+    @last_modified,
+    @metadata,
+    @analysis_version,
+    @analysis,
+    now()
+)
+on conflict (filename) do update set
+    etag = excluded.etag,
+    last_modified = excluded.last_modified,
+    metadata = excluded.metadata,
+    analysis_version = excluded.analysis_version,
+    analysis = excluded.analysis,
+    updated_at = excluded.updated_at;
@@ -0,0 +1,19 @@
+-- name: GetTagCounts :many
+select
+    tape_to_tag.tag_name as name,
+    count(*) as num_tapes
+from tapes.tape_to_tag
+join tapes.tape on tape.id = tape_to_tag.tape_id
+where tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape_to_tag.tag_name
+order by tape_to_tag.tag_name;
+
+-- name: GetTagCount :one
+select
+    count(*) as num_tapes
+from tapes.tape_to_tag
+join tapes.tape on tape.id = tape_to_tag.tape_id
+where tape_to_tag.tag_name = @tag_name
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id);
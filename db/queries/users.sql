@@ -0,0 +1,21 @@
+-- name: GetTwitchUsers :many
+select
+    twitch_user.twitch_user_id,
+    twitch_user.display_name,
+    twitch_user.updated_at
+from tapes.twitch_user
+where twitch_user.twitch_user_id = any(@twitch_user_ids::text[]);
+
+-- name: RecordTwitchUser :exec
+insert into tapes.twitch_user (
+    twitch_user_id,
+    display_name,
+    updated_at
+) values (
+    @twitch_user_id,
+    @display_name,
+    now()
+)
+on conflict (twitch_user_id) do update set
+    display_name = excluded.display_name,
+    updated_at = excluded.updated_at;
@@ -12,16 +12,67 @@ import (
 	"github.com/lib/pq"
 )
 
-const applySeries = `-- name: ApplySeries :execresult
-update tapes.tape set series_name = $1
-where tape.id = any($2::integer[])
+const applySeries = `-- name: ApplySeries :one
+with created_series as (
+    insert into tapes.series (name, description, ordering)
+    select
+        $1::text,
+        coalesce($2::text, ''),
+        coalesce($3::integer, 0)
+    where $4::integer is null
+    on conflict (name) do update set
+        description = coalesce($2::text, series.description),
+        ordering = coalesce($3::integer, series.ordering)
+    returning series.id
+),
+updated_series as (
+    update tapes.series set
+        name = coalesce($1::text, series.name),
+        description = coalesce($2::text, series.description),
+        ordering = coalesce($3::integer, series.ordering)
+    where series.id = $4::integer
+    returning series.id
+),
+target_series as (
+    select created_series.id from created_series
+    union all
+    select updated_series.id from updated_series
+),
+updated_tapes as (
+    update tapes.tape set
+        series_id = case
+            when tape.id = any($5::integer[]) then target_series.id
+            else null
+        end,
+        series_index = case
+            when tape.id = any($5::integer[]) then array_position($5::integer[], tape.id)
+            else null
+        end
+    from target_series
+    where tape.series_id = target_series.id
+        or tape.id = any($5::integer[])
+    returning tape.id
+)
+select target_series.id from target_series
 `
 
 type ApplySeriesParams struct {
-	SeriesName string
-	TapeIds    []int32
+	SeriesName  sql.NullString
+	Description sql.NullString
+	Ordering    sql.NullInt32
+	SeriesID    sql.NullInt32
+	TapeIds     []int32
 }
 
-func (q *Queries) ApplySeries(ctx context.Context, arg ApplySeriesParams) (sql.Result, error) {
-	return q.db.ExecContext(ctx, applySeries, arg.SeriesName, pq.Array(arg.TapeIds))
+func (q *Queries) ApplySeries(ctx context.Context, arg ApplySeriesParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, applySeries,
+		arg.SeriesName,
+		arg.Description,
+		arg.Ordering,
+		arg.SeriesID,
+		pq.Array(arg.TapeIds),
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
 }
@@ -0,0 +1,92 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: contributors.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const getContributor = `-- name: GetContributor :one
+select
+    tape.contributor_id::text as contributor_id,
+    count(*) as num_tapes,
+    min(tape.created_at)::timestamptz as first_contributed_at,
+    max(tape.created_at)::timestamptz as last_contributed_at
+from tapes.tape
+where tape.contributor_id = $1::text
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape.contributor_id
+`
+
+type GetContributorRow struct {
+	ContributorID      string
+	NumTapes           int64
+	FirstContributedAt time.Time
+	LastContributedAt  time.Time
+}
+
+func (q *Queries) GetContributor(ctx context.Context, contributorID string) (GetContributorRow, error) {
+	row := q.db.QueryRowContext(ctx, getContributor, contributorID)
+	var i GetContributorRow
+	err := row.Scan(
+		&i.ContributorID,
+		&i.NumTapes,
+		&i.FirstContributedAt,
+		&i.LastContributedAt,
+	)
+	return i, err
+}
+
+const getContributors = `-- name: GetContributors :many
+select
+    tape.contributor_id::text as contributor_id,
+    count(*) as num_tapes,
+    min(tape.created_at)::timestamptz as first_contributed_at,
+    max(tape.created_at)::timestamptz as last_contributed_at
+from tapes.tape
+where tape.contributor_id is not null
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape.contributor_id
+order by count(*) desc, min(tape.created_at), tape.contributor_id
+`
+
+type GetContributorsRow struct {
+	ContributorID      string
+	NumTapes           int64
+	FirstContributedAt time.Time
+	LastContributedAt  time.Time
+}
+
+func (q *Queries) GetContributors(ctx context.Context) ([]GetContributorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getContributors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetContributorsRow
+	for rows.Next() {
+		var i GetContributorsRow
+		if err := rows.Scan(
+			&i.ContributorID,
+			&i.NumTapes,
+			&i.FirstContributedAt,
+			&i.LastContributedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
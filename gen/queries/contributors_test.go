@@ -0,0 +1,57 @@
+package queries_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/server-common/querytest"
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetContributors(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title, contributor_id, retired_at) VALUES
+			(1, '2023-09-01 12:00:00+00', 'Tape one', '1234', NULL),
+			(2, '2023-09-15 12:00:00+00', 'Tape two', '5678', NULL),
+			(3, '2023-10-01 12:00:00+00', 'Tape three', '1234', NULL),
+			(4, '2023-10-15 12:00:00+00', 'Tape four', '1234', now()),
+			(5, '2023-10-15 12:00:00+00', 'Tape five', NULL, NULL),
+			(6, '2023-11-01 12:00:00+00', 'Tape six', '9999', NULL)
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.image (tape_id, index, color, width, height, rotated) VALUES
+			(1, 0, '#ffffff', 100, 200, false),
+			(2, 0, '#ffffff', 100, 200, false),
+			(3, 0, '#ffffff', 100, 200, false),
+			(4, 0, '#ffffff', 100, 200, false),
+			(5, 0, '#ffffff', 100, 200, false)
+	`)
+	assert.NoError(t, err)
+
+	// Retired tapes and tapes with no images aren't listed in the catalog, so they
+	// don't count toward a user's contributions
+	rows, err := q.GetContributors(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "1234", rows[0].ContributorID)
+	assert.Equal(t, int64(2), rows[0].NumTapes)
+	assert.True(t, rows[0].FirstContributedAt.Equal(time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)))
+	assert.True(t, rows[0].LastContributedAt.Equal(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "5678", rows[1].ContributorID)
+	assert.Equal(t, int64(1), rows[1].NumTapes)
+
+	row, err := q.GetContributor(context.Background(), "5678")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), row.NumTapes)
+	assert.True(t, row.FirstContributedAt.Equal(time.Date(2023, 9, 15, 12, 0, 0, 0, time.UTC)))
+
+	_, err = q.GetContributor(context.Background(), "9999")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -9,25 +9,67 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+const getCatalogRevision = `-- name: GetCatalogRevision :one
+select
+    catalog_revision.revision,
+    coalesce((
+        select sync.uuid from tapes.sync
+        where sync.finished_at is not null
+            and sync.error is null
+        order by sync.finished_at desc
+        limit 1
+    ), '00000000-0000-0000-0000-000000000000')::uuid as latest_sync_uuid
+from tapes.catalog_revision
+`
+
+type GetCatalogRevisionRow struct {
+	Revision       int64
+	LatestSyncUuid uuid.UUID
+}
+
+func (q *Queries) GetCatalogRevision(ctx context.Context) (GetCatalogRevisionRow, error) {
+	row := q.db.QueryRowContext(ctx, getCatalogRevision)
+	var i GetCatalogRevisionRow
+	err := row.Scan(&i.Revision, &i.LatestSyncUuid)
+	return i, err
+}
+
 const getTape = `-- name: GetTape :one
 select
     tape.id,
     tape.title,
     tape.year,
     tape.runtime,
-    tape.series_name,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
     tape.contributor_id,
+    tape.created_at,
     (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
     jsonb_agg(jsonb_build_object(
         'index', image.index,
         'color', image.color,
         'width', image.width,
         'height', image.height,
-        'rotated', image.rotated
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
     ) order by image.index) as images,
     array(
         select tag_name
@@ -37,8 +79,10 @@ select
     )::text[] as tags
 from tapes.tape
 join tapes.image on image.tape_id = tape.id
+left join tapes.series on series.id = tape.series_id
 where tape.id = $1
-group by tape.id
+    and tape.retired_at is null
+group by tape.id, series.id
 order by tape.id
 `
 
@@ -48,8 +92,12 @@ type GetTapeRow struct {
 	Year          sql.NullInt32
 	Runtime       sql.NullInt32
 	SeriesName    string
+	SeriesID      sql.NullInt32
+	SeriesIndex   sql.NullInt32
 	ContributorID sql.NullString
+	CreatedAt     time.Time
 	NumFavorites  int64
+	Thumbnail     json.RawMessage
 	Images        json.RawMessage
 	Tags          []string
 }
@@ -63,8 +111,12 @@ func (q *Queries) GetTape(ctx context.Context, tapeID int32) (GetTapeRow, error)
 		&i.Year,
 		&i.Runtime,
 		&i.SeriesName,
+		&i.SeriesID,
+		&i.SeriesIndex,
 		&i.ContributorID,
+		&i.CreatedAt,
 		&i.NumFavorites,
+		&i.Thumbnail,
 		&i.Images,
 		pq.Array(&i.Tags),
 	)
@@ -76,6 +128,7 @@ select
     distinct tape.contributor_id::text
 from tapes.tape
 where tape.contributor_id is not null
+    and tape.retired_at is null
 `
 
 func (q *Queries) GetTapeContributorIds(ctx context.Context) ([]string, error) {
@@ -107,15 +160,30 @@ select
     tape.title,
     tape.year,
     tape.runtime,
-    tape.series_name,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
     tape.contributor_id,
+    tape.created_at,
     (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
     jsonb_agg(jsonb_build_object(
         'index', image.index,
         'color', image.color,
         'width', image.width,
         'height', image.height,
-        'rotated', image.rotated
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
     ) order by image.index) as images,
     array(
         select tag_name
@@ -125,24 +193,114 @@ select
     )::text[] as tags
 from tapes.tape
 join tapes.image on image.tape_id = tape.id
-group by tape.id
-order by tape.id
+left join tapes.series on series.id = tape.series_id
+cross join lateral (
+    select
+        case when $1::text = 'title' then lower(tape.title) end as text_value,
+        case
+            when $1::text = 'year' then tape.year::bigint
+            when $1::text = 'runtime' then tape.runtime::bigint
+            when $1::text = 'favorites' then (select count(*) from tapes.favorite where favorite.tape_id = tape.id)
+            when $1::text = 'series' then tape.series_index::bigint
+        end as int_value,
+        case when $1::text = 'added' then tape.created_at end as time_value
+) as sort_key
+where tape.retired_at is null
+    and ($2::text is null or exists (
+        select 1 from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+            and tape_to_tag.tag_name = $2::text
+    ))
+    and ($3::text is null or series.name = $3::text)
+    and ($4::integer is null or tape.series_id = $4::integer)
+    and ($5::text is null or tape.contributor_id = $5::text)
+    and ($6::integer is null or tape.year >= $6::integer)
+    and ($7::integer is null or tape.year <= $7::integer)
+    and ($8::integer is null or tape.runtime >= $8::integer)
+    and ($9::integer is null or tape.runtime <= $9::integer)
+    and ($10::integer is null or (
+        case when $11::boolean then (
+            sort_key.text_value < lower($12::text)
+            or sort_key.int_value < $13::bigint
+            or sort_key.time_value < $14::timestamptz
+        ) else (
+            sort_key.text_value > lower($12::text)
+            or sort_key.int_value > $13::bigint
+            or sort_key.time_value > $14::timestamptz
+        ) end
+        or (sort_key.int_value is null and $13::bigint is not null)
+        or (
+            sort_key.text_value is not distinct from lower($12::text)
+            and sort_key.int_value is not distinct from $13::bigint
+            and sort_key.time_value is not distinct from $14::timestamptz
+            and case when $11::boolean then tape.id < $10::integer else tape.id > $10::integer end
+        )
+    ))
+group by tape.id, series.id, sort_key.text_value, sort_key.int_value, sort_key.time_value
+order by
+    case when not $11::boolean then sort_key.text_value end asc,
+    case when $11::boolean then sort_key.text_value end desc,
+    case when not $11::boolean then sort_key.int_value end asc nulls last,
+    case when $11::boolean then sort_key.int_value end desc nulls last,
+    case when not $11::boolean then sort_key.time_value end asc,
+    case when $11::boolean then sort_key.time_value end desc,
+    case when $11::boolean then tape.id end desc,
+    tape.id asc
+limit $15::integer
 `
 
+type GetTapesParams struct {
+	SortBy        string
+	Tag           sql.NullString
+	SeriesName    sql.NullString
+	SeriesID      sql.NullInt32
+	ContributorID sql.NullString
+	YearMin       sql.NullInt32
+	YearMax       sql.NullInt32
+	RuntimeMin    sql.NullInt32
+	RuntimeMax    sql.NullInt32
+	AfterID       sql.NullInt32
+	Descending    bool
+	AfterText     sql.NullString
+	AfterInt      sql.NullInt64
+	AfterTime     sql.NullTime
+	PageSize      sql.NullInt32
+}
+
 type GetTapesRow struct {
 	ID            int32
 	Title         string
 	Year          sql.NullInt32
 	Runtime       sql.NullInt32
 	SeriesName    string
+	SeriesID      sql.NullInt32
+	SeriesIndex   sql.NullInt32
 	ContributorID sql.NullString
+	CreatedAt     time.Time
 	NumFavorites  int64
+	Thumbnail     json.RawMessage
 	Images        json.RawMessage
 	Tags          []string
 }
 
-func (q *Queries) GetTapes(ctx context.Context) ([]GetTapesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTapes)
+func (q *Queries) GetTapes(ctx context.Context, arg GetTapesParams) ([]GetTapesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTapes,
+		arg.SortBy,
+		arg.Tag,
+		arg.SeriesName,
+		arg.SeriesID,
+		arg.ContributorID,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.AfterID,
+		arg.Descending,
+		arg.AfterText,
+		arg.AfterInt,
+		arg.AfterTime,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -156,8 +314,12 @@ func (q *Queries) GetTapes(ctx context.Context) ([]GetTapesRow, error) {
 			&i.Year,
 			&i.Runtime,
 			&i.SeriesName,
+			&i.SeriesID,
+			&i.SeriesIndex,
 			&i.ContributorID,
+			&i.CreatedAt,
 			&i.NumFavorites,
+			&i.Thumbnail,
 			&i.Images,
 			pq.Array(&i.Tags),
 		); err != nil {
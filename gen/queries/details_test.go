@@ -8,6 +8,7 @@ import (
 	"github.com/golden-vcr/server-common/querytest"
 	"github.com/golden-vcr/tapes/gen/queries"
 	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,6 +52,15 @@ func Test_GetTapes(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
+	err = q.SyncThumbnail(context.Background(), queries.SyncThumbnailParams{
+		TapeID:      1,
+		Color:       "#ff0000",
+		Width:       128,
+		Height:      256,
+		ContentHash: "aaaa",
+	})
+	assert.NoError(t, err)
+
 	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.tape")
 	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.tape_to_tag")
 	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.image")
@@ -62,7 +72,7 @@ func Test_GetTapes(t *testing.T) {
 	`)
 	assert.NoError(t, err)
 
-	rows, err := q.GetTapes(context.Background())
+	rows, err := q.GetTapes(context.Background(), queries.GetTapesParams{})
 	assert.NoError(t, err)
 	assert.Len(t, rows, 1)
 
@@ -72,23 +82,267 @@ func Test_GetTapes(t *testing.T) {
 	assert.Equal(t, int64(2), row.NumFavorites)
 	assert.Equal(t, sql.NullInt32{Valid: true, Int32: 1994}, row.Year)
 	assert.Equal(t, sql.NullInt32{}, row.Runtime)
+	thumbnail, err := db.ParseTapeThumbnail(row.Thumbnail)
+	assert.NoError(t, err)
+	assert.Equal(t, &db.TapeThumbnail{Color: "#ff0000", Width: 128, Height: 256}, thumbnail)
 	images, err := db.ParseTapeImageArray(row.Images)
 	assert.NoError(t, err)
 	assert.Equal(t, []db.TapeImage{
 		{
-			Index:   0,
-			Color:   "#ff0000",
-			Width:   500,
-			Height:  1000,
-			Rotated: false,
+			Index:    0,
+			Color:    "#ff0000",
+			Width:    500,
+			Height:   1000,
+			Rotated:  false,
+			Variants: []db.TapeImageVariant{},
 		},
 		{
-			Index:   1,
-			Color:   "#00ff00",
-			Width:   501,
-			Height:  1001,
-			Rotated: true,
+			Index:    1,
+			Color:    "#00ff00",
+			Width:    501,
+			Height:   1001,
+			Rotated:  true,
+			Variants: []db.TapeImageVariant{},
 		},
 	}, images)
 	assert.Equal(t, []string{"fitness", "instructional"}, row.Tags)
 }
+
+func Test_GetTapes_excludesRetiredTapes(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title, retired_at) VALUES
+			(1, now(), 'Tape 1', NULL),
+			(2, now(), 'Tape 2', now())
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.image (tape_id, index, color, width, height, rotated) VALUES
+			(1, 0, '#ffffff', 100, 200, false),
+			(2, 0, '#ffffff', 100, 200, false)
+	`)
+	assert.NoError(t, err)
+
+	rows, err := q.GetTapes(context.Background(), queries.GetTapesParams{})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, int32(1), rows[0].ID)
+
+	_, err = q.GetTape(context.Background(), 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_GetTapes_filterSortAndPaginate(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.series (id, name) VALUES
+			(1, 'Jazzercise')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title, year, runtime, series_id, series_index, contributor_id) VALUES
+			(1, now(), 'Charlie', 1985, 30, 1, 2, '1234'),
+			(2, now(), 'alpha', 1991, 60, NULL, NULL, NULL),
+			(3, now(), 'Bravo', NULL, 90, 1, 1, NULL),
+			(4, now(), 'Delta', 1988, NULL, NULL, NULL, '1234')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.image (tape_id, index, color, width, height, rotated) VALUES
+			(1, 0, '#ffffff', 100, 200, false),
+			(2, 0, '#ffffff', 100, 200, false),
+			(3, 0, '#ffffff', 100, 200, false),
+			(4, 0, '#ffffff', 100, 200, false)
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.tape_to_tag (tape_id, tag_name) VALUES
+			(1, 'fitness'),
+			(3, 'fitness'),
+			(4, 'christmas')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.favorite (tape_id, twitch_user_id) VALUES
+			(3, 'a'),
+			(3, 'b'),
+			(2, 'a')
+	`)
+	assert.NoError(t, err)
+
+	getIds := func(arg queries.GetTapesParams) []int32 {
+		rows, err := q.GetTapes(context.Background(), arg)
+		assert.NoError(t, err)
+		ids := make([]int32, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int32{1, 2, 3, 4}, getIds(queries.GetTapesParams{}))
+	assert.Equal(t, []int32{1, 3}, getIds(queries.GetTapesParams{Tag: sql.NullString{Valid: true, String: "fitness"}}))
+	assert.Equal(t, []int32{1, 3}, getIds(queries.GetTapesParams{SeriesName: sql.NullString{Valid: true, String: "Jazzercise"}}))
+	assert.Equal(t, []int32{3, 1}, getIds(queries.GetTapesParams{SeriesID: sql.NullInt32{Valid: true, Int32: 1}, SortBy: "series"}))
+	assert.Equal(t, []int32{1, 4}, getIds(queries.GetTapesParams{ContributorID: sql.NullString{Valid: true, String: "1234"}}))
+	assert.Equal(t, []int32{1, 4}, getIds(queries.GetTapesParams{
+		YearMin: sql.NullInt32{Valid: true, Int32: 1980},
+		YearMax: sql.NullInt32{Valid: true, Int32: 1989},
+	}))
+	assert.Equal(t, []int32{2, 3}, getIds(queries.GetTapesParams{RuntimeMin: sql.NullInt32{Valid: true, Int32: 60}}))
+	assert.Equal(t, []int32{2, 3, 1, 4}, getIds(queries.GetTapesParams{SortBy: "title"}))
+	assert.Equal(t, []int32{2, 4, 1, 3}, getIds(queries.GetTapesParams{SortBy: "year", Descending: true}))
+	assert.Equal(t, []int32{3, 2, 4, 1}, getIds(queries.GetTapesParams{SortBy: "favorites", Descending: true}))
+	assert.Equal(t, []int32{4, 3, 2, 1}, getIds(queries.GetTapesParams{Descending: true}))
+	assert.Equal(t, []int32{2, 3}, getIds(queries.GetTapesParams{
+		AfterID:  sql.NullInt32{Valid: true, Int32: 1},
+		PageSize: sql.NullInt32{Valid: true, Int32: 2},
+	}))
+
+	// Pages resume after the sort key and ID of the last tape on the previous page,
+	// with NULL values sorted last regardless of order
+	assert.Equal(t, []int32{1, 4}, getIds(queries.GetTapesParams{
+		SortBy:    "title",
+		AfterID:   sql.NullInt32{Valid: true, Int32: 3},
+		AfterText: sql.NullString{Valid: true, String: "Bravo"},
+	}))
+	assert.Equal(t, []int32{1, 3}, getIds(queries.GetTapesParams{
+		SortBy:     "year",
+		Descending: true,
+		AfterID:    sql.NullInt32{Valid: true, Int32: 4},
+		AfterInt:   sql.NullInt64{Valid: true, Int64: 1988},
+	}))
+	assert.Equal(t, []int32{3}, getIds(queries.GetTapesParams{
+		SortBy:   "year",
+		AfterID:  sql.NullInt32{Valid: true, Int32: 2},
+		AfterInt: sql.NullInt64{Valid: true, Int64: 1991},
+	}))
+	assert.Equal(t, []int32{}, getIds(queries.GetTapesParams{
+		SortBy:  "year",
+		AfterID: sql.NullInt32{Valid: true, Int32: 3},
+	}))
+	assert.Equal(t, []int32{4, 1}, getIds(queries.GetTapesParams{
+		SortBy:     "favorites",
+		Descending: true,
+		AfterID:    sql.NullInt32{Valid: true, Int32: 2},
+		AfterInt:   sql.NullInt64{Valid: true, Int64: 1},
+	}))
+
+	// All tapes were inserted in the same transaction, so they share a creation time
+	// and are ordered by ID
+	rows, err := q.GetTapes(context.Background(), queries.GetTapesParams{SortBy: "added"})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []int32{3, 4}, getIds(queries.GetTapesParams{
+		SortBy:    "added",
+		AfterID:   sql.NullInt32{Valid: true, Int32: 2},
+		AfterTime: sql.NullTime{Valid: true, Time: rows[1].CreatedAt},
+	}))
+}
+
+func Test_GetCatalogRevision(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	// Revision triggers are deferred until commit; fire them at the end of each
+	// statement instead so that we can observe each change within this transaction
+	_, err := tx.Exec("SET CONSTRAINTS ALL IMMEDIATE")
+	assert.NoError(t, err)
+
+	initial, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Nil, initial.LatestSyncUuid)
+
+	// Any change to catalog data should increment the revision
+	err = q.SyncTape(context.Background(), queries.SyncTapeParams{
+		ID:    1,
+		Title: "Tape one",
+	})
+	assert.NoError(t, err)
+	afterSync, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, afterSync.Revision, initial.Revision)
+
+	_, err = tx.Exec("INSERT INTO tapes.favorite (tape_id, twitch_user_id) VALUES (1, 'a')")
+	assert.NoError(t, err)
+	afterFavorite, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, afterFavorite.Revision, afterSync.Revision)
+
+	err = q.SyncThumbnail(context.Background(), queries.SyncThumbnailParams{
+		TapeID:      1,
+		Color:       "#ffffff",
+		Width:       128,
+		Height:      256,
+		ContentHash: "aaaa",
+	})
+	assert.NoError(t, err)
+	afterThumbnail, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, afterThumbnail.Revision, afterFavorite.Revision)
+
+	// Upserting a tape without changing it should not increment the revision
+	err = q.SyncTape(context.Background(), queries.SyncTapeParams{
+		ID:    1,
+		Title: "Tape one",
+	})
+	assert.NoError(t, err)
+	afterNoop, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, afterThumbnail.Revision, afterNoop.Revision)
+
+	// The latest successful sync should be reported
+	syncUuid := uuid.New()
+	err = q.CreateSync(context.Background(), syncUuid)
+	assert.NoError(t, err)
+	err = q.RecordSuccessfulSync(context.Background(), queries.RecordSuccessfulSyncParams{
+		Uuid:     syncUuid,
+		NumTapes: 1,
+		Warnings: "",
+	})
+	assert.NoError(t, err)
+	failedSyncUuid := uuid.New()
+	err = q.CreateSync(context.Background(), failedSyncUuid)
+	assert.NoError(t, err)
+	err = q.RecordFailedSync(context.Background(), queries.RecordFailedSyncParams{
+		Uuid:  failedSyncUuid,
+		Error: "oh no",
+	})
+	assert.NoError(t, err)
+	latest, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, syncUuid, latest.LatestSyncUuid)
+}
+
+func Test_GetCatalogRevision_oncePerTransaction(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	initial, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+
+	// Changes to catalog data shouldn't bump the revision until the transaction commits
+	for _, id := range []int32{1, 2, 3} {
+		err = q.SyncTape(context.Background(), queries.SyncTapeParams{
+			ID:    id,
+			Title: "A tape",
+		})
+		assert.NoError(t, err)
+	}
+	_, err = tx.Exec("INSERT INTO tapes.favorite (tape_id, twitch_user_id) VALUES (1, 'a')")
+	assert.NoError(t, err)
+	pending, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, initial.Revision, pending.Revision)
+
+	// Once the deferred triggers fire, the revision should be bumped only once
+	_, err = tx.Exec("SET CONSTRAINTS ALL IMMEDIATE")
+	assert.NoError(t, err)
+	fired, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, initial.Revision+1, fired.Revision)
+}
@@ -6,11 +6,22 @@ package queries
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Single-row table holding a counter that is incremented whenever any data exposed via the catalog API changes, so that clients can cache catalog responses until the revision changes.
+type TapesCatalogRevision struct {
+	// Always true; ensures that the table contains only a single row.
+	ID bool
+	// Number that is incremented once by any transaction that modifies catalog data.
+	Revision int64
+	// Time at which the revision was last incremented.
+	UpdatedAt time.Time
+}
+
 // Records the fact that a specific user has marked a single tape as one of their favorite tapes.
 type TapesFavorite struct {
 	// ID of the user who marked this tape as a favorite.
@@ -32,6 +43,42 @@ type TapesImage struct {
 	Height int32
 	// Whether the image was rotated 90 degrees CCW in order to have a vertical aspect ratio, in which case it may be displayed with a 90-degree CW rotation applied in order for any text in the image to be legible.
 	Rotated bool
+	// Alternate versions of the image that are available in the storage bucket, as a JSON array of objects with 'format' (e.g. 'webp') and 'width' (in pixels) keys, sorted by format and then width.
+	Variants json.RawMessage
+	// Compact encoding of the image (see https://blurha.sh), from which the frontend can render a blurred placeholder while the full image loads. Empty if the image could not be decoded and its metadata did not supply a blurhash.
+	Blurhash string
+}
+
+// Cached metadata for an image file in the storage bucket, so that syncs only need to request metadata for files that have changed.
+type TapesImageMetadatum struct {
+	// Name of the image file, i.e. its key in the storage bucket.
+	Filename string
+	// ETag of the file at the time its metadata was retrieved.
+	Etag string
+	// Last-modified time of the file at the time its metadata was retrieved.
+	LastModified time.Time
+	// Raw key/value pairs retrieved as metadata for the file, as a JSON object.
+	Metadata json.RawMessage
+	// Time at which this metadata was retrieved.
+	UpdatedAt time.Time
+	// Version of the analysis that was performed on the file's pixel data, or 0 if the file was not analyzed: cached analyses older than the current version are recomputed as needed, rather than discarding all cached metadata whenever new details are added to the analysis.
+	AnalysisVersion int32
+	// Details derived from the pixel data of the file (its dimensions, dominant color, orientation, etc.), as a JSON object, or JSON null if the file was not analyzed or could not be decoded as an image.
+	Analysis json.RawMessage
+}
+
+// A named series of tapes that belong together, e.g. a multi-part instructional program.
+type TapesSeries struct {
+	// Unique, auto-incrementing ID of the series.
+	ID int32
+	// Timestamp at which this series was first created.
+	CreatedAt time.Time
+	// Unique, human-readable name of the series.
+	Name string
+	// Optional description of the series, or an empty string if not set.
+	Description string
+	// Value used to order series relative to one another in listings, ascending; ties are broken by name.
+	Ordering int32
 }
 
 // Record of an attempt to sync tape and image data to the GVCR database.
@@ -48,6 +95,28 @@ type TapesSync struct {
 	NumTapes sql.NullInt32
 	// Newline-delimited string containing all warning lines emitted during the sync.
 	Warnings sql.NullString
+	// Hash of all data gathered from the inventory spreadsheet and storage bucket for a successful sync, used to skip scheduled syncs when nothing has changed.
+	SourceFingerprint sql.NullString
+}
+
+// Record of a single non-fatal problem encountered during a sync, typically resulting in a tape or image being excluded from the catalog.
+type TapesSyncWarning struct {
+	// UUID of the sync that emitted this warning.
+	SyncUuid uuid.UUID
+	// Order in which this warning was emitted during the sync, starting at 0.
+	Index int32
+	// Where the problem was found: 'spreadsheet' for a row in the inventory spreadsheet, 'storage' for an image file in the storage bucket, or 'sync' for a problem found while reconciling the two.
+	Source string
+	// Machine-readable code identifying the type of problem, e.g. 'missing_thumbnail'.
+	Kind string
+	// ID of the tape that the warning applies to, if known. Not a foreign key, since the tape may never have been synced.
+	TapeID sql.NullInt32
+	// For spreadsheet warnings, the user-facing row number (starting at 1 for the heading row) at which the problem occurred.
+	RowNumber sql.NullInt32
+	// For storage warnings, the name of the image file in question.
+	Filename sql.NullString
+	// Human-readable message describing the problem.
+	Message string
 }
 
 // Details of a single VHS tape in the Golden VCR library.
@@ -64,7 +133,12 @@ type TapesTape struct {
 	Runtime sql.NullInt32
 	// Twitch User ID of the viewer who contributed this tape to the library, if any.
 	ContributorID sql.NullString
-	SeriesName    string
+	// Timestamp at which this tape was found to be missing from the spreadsheet during a sync, in which case it is no longer listed in the catalog; or NULL if the tape is still active.
+	RetiredAt sql.NullTime
+	// ID of the series to which this tape belongs; or NULL if not part of a series.
+	SeriesID sql.NullInt32
+	// 1-based position of this tape within its series; or NULL if not part of a series.
+	SeriesIndex sql.NullInt32
 }
 
 // Association of a specific tag name with a given tape.
@@ -74,3 +148,28 @@ type TapesTapeToTag struct {
 	// Canonical name of the tag. Tags are identified solely by a lowercase string, e.g. "instructional", "arts+crafts", "christmas".
 	TagName string
 }
+
+// Metadata for the single low-res thumbnail image that represents a specific tape.
+type TapesThumbnail struct {
+	TapeID int32
+	// The dominant color in the thumbnail, hex-formatted (with hash prefix).
+	Color string
+	// Width of the thumbnail image file, in pixels.
+	Width int32
+	// Height of the thumbnail image file, in pixels.
+	Height int32
+	// Compact encoding of the thumbnail (see https://blurha.sh), from which the frontend can render a blurred placeholder while the thumbnail loads.
+	Blurhash string
+	// Hex-encoded SHA-256 digest of the thumbnail image file, as of the last sync.
+	ContentHash string
+}
+
+// Cached details of a Twitch user, so that we can display human-readable names for tape contributors without hitting the Twitch API on every request.
+type TapesTwitchUser struct {
+	// Numeric Twitch User ID, as a string.
+	TwitchUserID string
+	// Display name most recently fetched from the Twitch API for this user.
+	DisplayName string
+	// Timestamp at which display_name was last fetched from the Twitch API; used to determine when the cached value should be refreshed.
+	UpdatedAt time.Time
+}
@@ -0,0 +1,135 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: search.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+const searchTapes = `-- name: SearchTapes :many
+select
+    tape.id,
+    tape.title,
+    tape.year,
+    tape.runtime,
+    coalesce(series.name, '')::text as series_name,
+    tape.series_id,
+    tape.series_index,
+    tape.contributor_id,
+    (select count(*) from tapes.favorite where favorite.tape_id = tape.id) as num_favorites,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
+    jsonb_agg(jsonb_build_object(
+        'index', image.index,
+        'color', image.color,
+        'width', image.width,
+        'height', image.height,
+        'rotated', image.rotated,
+        'blurhash', image.blurhash,
+        'variants', image.variants
+    ) order by image.index) as images,
+    array(
+        select tag_name
+        from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+        order by tag_name
+    )::text[] as tags,
+    greatest(
+        word_similarity($1::text, tape.title),
+        word_similarity($1::text, series.name),
+        coalesce((
+            select max(word_similarity($1::text, tape_to_tag.tag_name))
+            from tapes.tape_to_tag
+            where tape_to_tag.tape_id = tape.id
+        ), 0)
+    )::real as rank
+from tapes.tape
+join tapes.image on image.tape_id = tape.id
+left join tapes.series on series.id = tape.series_id
+where tape.retired_at is null
+    and (
+        $1::text <% tape.title
+        or $1::text <% series.name
+        or exists (
+            select 1 from tapes.tape_to_tag
+            where tape_to_tag.tape_id = tape.id
+                and $1::text <% tape_to_tag.tag_name
+        )
+    )
+group by tape.id, series.id
+order by rank desc, tape.id
+limit $2::integer
+`
+
+type SearchTapesParams struct {
+	Query      string
+	MaxResults int32
+}
+
+type SearchTapesRow struct {
+	ID            int32
+	Title         string
+	Year          sql.NullInt32
+	Runtime       sql.NullInt32
+	SeriesName    string
+	SeriesID      sql.NullInt32
+	SeriesIndex   sql.NullInt32
+	ContributorID sql.NullString
+	NumFavorites  int64
+	Thumbnail     json.RawMessage
+	Images        json.RawMessage
+	Tags          []string
+	Rank          float32
+}
+
+func (q *Queries) SearchTapes(ctx context.Context, arg SearchTapesParams) ([]SearchTapesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTapes, arg.Query, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTapesRow
+	for rows.Next() {
+		var i SearchTapesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Year,
+			&i.Runtime,
+			&i.SeriesName,
+			&i.SeriesID,
+			&i.SeriesIndex,
+			&i.ContributorID,
+			&i.NumFavorites,
+			&i.Thumbnail,
+			&i.Images,
+			pq.Array(&i.Tags),
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
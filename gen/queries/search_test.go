@@ -0,0 +1,81 @@
+package queries_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golden-vcr/server-common/querytest"
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SearchTapes(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	tapes := []struct {
+		id         int32
+		title      string
+		seriesName string
+		tags       []string
+	}{
+		{1, "Sweatin' to the Oldies", "Richard Simmons", []string{"fitness"}},
+		{2, "Jazzercise: Total Body Workout", "", []string{"fitness", "dance"}},
+		{3, "Learn to Play Guitar", "", []string{"instructional", "music"}},
+	}
+	for _, tape := range tapes {
+		err := q.SyncTape(context.Background(), queries.SyncTapeParams{
+			ID:    tape.id,
+			Title: tape.title,
+		})
+		assert.NoError(t, err)
+		if tape.seriesName != "" {
+			_, err = q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+				SeriesName: sql.NullString{Valid: true, String: tape.seriesName},
+				TapeIds:    []int32{tape.id},
+			})
+			assert.NoError(t, err)
+		}
+		err = q.SyncTapeTags(context.Background(), queries.SyncTapeTagsParams{
+			TapeID:   tape.id,
+			TagNames: tape.tags,
+		})
+		assert.NoError(t, err)
+		err = q.SyncImage(context.Background(), queries.SyncImageParams{
+			TapeID: tape.id,
+			Index:  0,
+			Width:  500,
+			Height: 1000,
+			Color:  "#ff0000",
+		})
+		assert.NoError(t, err)
+	}
+
+	search := func(query string) []int32 {
+		rows, err := q.SearchTapes(context.Background(), queries.SearchTapesParams{
+			Query:      query,
+			MaxResults: 10,
+		})
+		assert.NoError(t, err)
+		ids := make([]int32, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+
+	// Title, series name, and tags should all be searchable, with some tolerance for
+	// typos
+	assert.Equal(t, []int32{1}, search("oldies"))
+	assert.Equal(t, []int32{1}, search("simmons"))
+	assert.Equal(t, []int32{1}, search("richard simmonds"))
+	assert.Equal(t, []int32{3}, search("guitar"))
+	assert.ElementsMatch(t, []int32{1, 2}, search("fitness"))
+	assert.Equal(t, []int32{}, search("zzzzzz"))
+
+	// Retired tapes should be excluded from search results
+	_, err := q.RetireTapes(context.Background(), []int32{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{}, search("jazzercise"))
+}
@@ -0,0 +1,81 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: series.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const getSeries = `-- name: GetSeries :one
+select
+    series.id,
+    series.name,
+    series.description
+from tapes.series
+where series.id = $1
+`
+
+type GetSeriesRow struct {
+	ID          int32
+	Name        string
+	Description string
+}
+
+func (q *Queries) GetSeries(ctx context.Context, seriesID int32) (GetSeriesRow, error) {
+	row := q.db.QueryRowContext(ctx, getSeries, seriesID)
+	var i GetSeriesRow
+	err := row.Scan(&i.ID, &i.Name, &i.Description)
+	return i, err
+}
+
+const getSeriesList = `-- name: GetSeriesList :many
+select
+    series.id,
+    series.name,
+    series.description,
+    count(tape.id) as num_tapes
+from tapes.series
+join tapes.tape on tape.series_id = series.id
+where tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by series.id
+order by series.ordering, series.name
+`
+
+type GetSeriesListRow struct {
+	ID          int32
+	Name        string
+	Description string
+	NumTapes    int64
+}
+
+func (q *Queries) GetSeriesList(ctx context.Context) ([]GetSeriesListRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSeriesList)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSeriesListRow
+	for rows.Next() {
+		var i GetSeriesListRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.NumTapes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,133 @@
+package queries_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golden-vcr/server-common/querytest"
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApplySeries(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	for _, tapeId := range []int32{1, 2, 3, 4} {
+		err := q.SyncTape(context.Background(), queries.SyncTapeParams{
+			ID:    tapeId,
+			Title: "Tape",
+		})
+		assert.NoError(t, err)
+		err = q.SyncImage(context.Background(), queries.SyncImageParams{
+			TapeID: tapeId,
+			Index:  0,
+			Width:  500,
+			Height: 1000,
+			Color:  "#ff0000",
+		})
+		assert.NoError(t, err)
+	}
+
+	getSeriesMembers := func(seriesId int32) []int32 {
+		rows, err := q.GetTapes(context.Background(), queries.GetTapesParams{
+			SeriesID: sql.NullInt32{Valid: true, Int32: seriesId},
+			SortBy:   "series",
+		})
+		assert.NoError(t, err)
+		ids := make([]int32, 0, len(rows))
+		for _, row := range rows {
+			assert.Equal(t, sql.NullInt32{Valid: true, Int32: int32(len(ids) + 1)}, row.SeriesIndex)
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+
+	// Applying a new series should create it, with tapes numbered in the order given
+	seriesId, err := q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesName:  sql.NullString{Valid: true, String: "Jazzercise"},
+		Description: sql.NullString{Valid: true, String: "Dance your way to fitness"},
+		TapeIds:     []int32{3, 1, 2},
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.series")
+	list, err := q.GetSeriesList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "Jazzercise", list[0].Name)
+	assert.Equal(t, "Dance your way to fitness", list[0].Description)
+	assert.Equal(t, int64(3), list[0].NumTapes)
+	assert.Equal(t, list[0].ID, seriesId)
+	assert.Equal(t, []int32{3, 1, 2}, getSeriesMembers(seriesId))
+
+	// Re-applying the same series should reorder its tapes and drop any that aren't
+	// listed, leaving the description intact if not specified
+	_, err = q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesName: sql.NullString{Valid: true, String: "Jazzercise"},
+		TapeIds:    []int32{1, 3},
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.series")
+	assert.Equal(t, []int32{1, 3}, getSeriesMembers(seriesId))
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.tape WHERE id = 2 AND series_id IS NOT NULL")
+	series, err := q.GetSeries(context.Background(), seriesId)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dance your way to fitness", series.Description)
+
+	// Applying a different series should move tapes out of their existing series
+	otherSeriesId, err := q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesName: sql.NullString{Valid: true, String: "Richard Simmons"},
+		TapeIds:    []int32{3, 4},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1}, getSeriesMembers(seriesId))
+	list, err = q.GetSeriesList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, otherSeriesId, list[1].ID)
+	assert.Equal(t, []int32{3, 4}, getSeriesMembers(otherSeriesId))
+
+	// A series may be identified by ID, in which case it can be renamed, and its
+	// description and ordering can be updated
+	appliedId, err := q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesID:    sql.NullInt32{Valid: true, Int32: otherSeriesId},
+		SeriesName:  sql.NullString{Valid: true, String: "Sweatin' to the Oldies"},
+		Description: sql.NullString{Valid: true, String: "Disco fitness"},
+		Ordering:    sql.NullInt32{Valid: true, Int32: -1},
+		TapeIds:     []int32{4, 3, 2},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, otherSeriesId, appliedId)
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.series")
+	assert.Equal(t, []int32{4, 3, 2}, getSeriesMembers(otherSeriesId))
+	list, err = q.GetSeriesList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, otherSeriesId, list[0].ID)
+	assert.Equal(t, "Sweatin' to the Oldies", list[0].Name)
+	assert.Equal(t, "Disco fitness", list[0].Description)
+
+	// Applying a series by name updates its ordering if specified
+	_, err = q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesName: sql.NullString{Valid: true, String: "Jazzercise"},
+		Ordering:   sql.NullInt32{Valid: true, Int32: -2},
+		TapeIds:    []int32{1},
+	})
+	assert.NoError(t, err)
+	list, err = q.GetSeriesList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, seriesId, list[0].ID)
+
+	// Applying a series by ID fails if no such series exists
+	_, err = q.ApplySeries(context.Background(), queries.ApplySeriesParams{
+		SeriesID: sql.NullInt32{Valid: true, Int32: 9999},
+		TapeIds:  []int32{1},
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, []int32{1}, getSeriesMembers(seriesId))
+
+	_, err = q.GetSeries(context.Background(), 9999)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -8,6 +8,8 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -28,6 +30,402 @@ func (q *Queries) CreateSync(ctx context.Context, argUuid uuid.UUID) error {
 	return err
 }
 
+const deleteStaleImages = `-- name: DeleteStaleImages :execrows
+delete from tapes.image
+where
+    image.tape_id = $1
+    and not (image.index = any($2::integer[]))
+`
+
+type DeleteStaleImagesParams struct {
+	TapeID  int32
+	Indices []int32
+}
+
+func (q *Queries) DeleteStaleImages(ctx context.Context, arg DeleteStaleImagesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStaleImages, arg.TapeID, pq.Array(arg.Indices))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteThumbnail = `-- name: DeleteThumbnail :execrows
+delete from tapes.thumbnail
+where thumbnail.tape_id = $1
+`
+
+func (q *Queries) DeleteThumbnail(ctx context.Context, tapeID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteThumbnail, tapeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getImageMetadata = `-- name: GetImageMetadata :many
+select
+    image_metadata.filename,
+    image_metadata.etag,
+    image_metadata.last_modified,
+    image_metadata.metadata,
+    image_metadata.analysis_version,
+    image_metadata.analysis
+from tapes.image_metadata
+where image_metadata.filename = any($1::text[])
+`
+
+type GetImageMetadataRow struct {
+	Filename        string
+	Etag            string
+	LastModified    time.Time
+	Metadata        json.RawMessage
+	AnalysisVersion int32
+	Analysis        json.RawMessage
+}
+
+func (q *Queries) GetImageMetadata(ctx context.Context, filenames []string) ([]GetImageMetadataRow, error) {
+	rows, err := q.db.QueryContext(ctx, getImageMetadata, pq.Array(filenames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetImageMetadataRow
+	for rows.Next() {
+		var i GetImageMetadataRow
+		if err := rows.Scan(
+			&i.Filename,
+			&i.Etag,
+			&i.LastModified,
+			&i.Metadata,
+			&i.AnalysisVersion,
+			&i.Analysis,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestSyncFingerprint = `-- name: GetLatestSyncFingerprint :one
+select sync.source_fingerprint
+from tapes.sync
+where sync.finished_at is not null
+    and sync.error is null
+order by sync.started_at desc
+limit 1
+`
+
+func (q *Queries) GetLatestSyncFingerprint(ctx context.Context) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSyncFingerprint)
+	var source_fingerprint sql.NullString
+	err := row.Scan(&source_fingerprint)
+	return source_fingerprint, err
+}
+
+const getRecentlyWarnedTapes = `-- name: GetRecentlyWarnedTapes :many
+with recent_sync as (
+    select sync.uuid, sync.started_at
+    from tapes.sync
+    where sync.finished_at is not null
+        and sync.error is null
+    order by sync.started_at desc
+    limit $1::integer
+)
+select
+    sync_warning.tape_id::integer as tape_id,
+    count(distinct recent_sync.uuid)::integer as num_syncs,
+    max(recent_sync.started_at)::timestamptz as last_warned_at,
+    array_agg(distinct sync_warning.kind order by sync_warning.kind)::text[] as kinds
+from tapes.sync_warning
+join recent_sync on recent_sync.uuid = sync_warning.sync_uuid
+where sync_warning.tape_id is not null
+group by sync_warning.tape_id
+order by sync_warning.tape_id
+`
+
+type GetRecentlyWarnedTapesRow struct {
+	TapeID       int32
+	NumSyncs     int32
+	LastWarnedAt time.Time
+	Kinds        []string
+}
+
+func (q *Queries) GetRecentlyWarnedTapes(ctx context.Context, numSyncs int32) ([]GetRecentlyWarnedTapesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentlyWarnedTapes, numSyncs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentlyWarnedTapesRow
+	for rows.Next() {
+		var i GetRecentlyWarnedTapesRow
+		if err := rows.Scan(
+			&i.TapeID,
+			&i.NumSyncs,
+			&i.LastWarnedAt,
+			pq.Array(&i.Kinds),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSync = `-- name: GetSync :one
+select
+    sync.uuid,
+    sync.started_at,
+    sync.finished_at,
+    sync.error,
+    sync.num_tapes,
+    sync.warnings
+from tapes.sync
+where sync.uuid = $1
+`
+
+type GetSyncRow struct {
+	Uuid       uuid.UUID
+	StartedAt  time.Time
+	FinishedAt sql.NullTime
+	Error      sql.NullString
+	NumTapes   sql.NullInt32
+	Warnings   sql.NullString
+}
+
+func (q *Queries) GetSync(ctx context.Context, argUuid uuid.UUID) (GetSyncRow, error) {
+	row := q.db.QueryRowContext(ctx, getSync, argUuid)
+	var i GetSyncRow
+	err := row.Scan(
+		&i.Uuid,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Error,
+		&i.NumTapes,
+		&i.Warnings,
+	)
+	return i, err
+}
+
+const getSyncWarnings = `-- name: GetSyncWarnings :many
+select
+    sync_warning.source,
+    sync_warning.kind,
+    sync_warning.tape_id,
+    sync_warning.row_number,
+    sync_warning.filename,
+    sync_warning.message
+from tapes.sync_warning
+where sync_warning.sync_uuid = $1
+order by sync_warning.index
+`
+
+type GetSyncWarningsRow struct {
+	Source    string
+	Kind      string
+	TapeID    sql.NullInt32
+	RowNumber sql.NullInt32
+	Filename  sql.NullString
+	Message   string
+}
+
+func (q *Queries) GetSyncWarnings(ctx context.Context, syncUuid uuid.UUID) ([]GetSyncWarningsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSyncWarnings, syncUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSyncWarningsRow
+	for rows.Next() {
+		var i GetSyncWarningsRow
+		if err := rows.Scan(
+			&i.Source,
+			&i.Kind,
+			&i.TapeID,
+			&i.RowNumber,
+			&i.Filename,
+			&i.Message,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncedTapes = `-- name: GetSyncedTapes :many
+select
+    tape.id,
+    tape.title,
+    tape.year,
+    tape.runtime,
+    tape.contributor_id,
+    (tape.retired_at is not null)::boolean as is_retired,
+    coalesce((
+        select jsonb_build_object(
+            'color', thumbnail.color,
+            'width', thumbnail.width,
+            'height', thumbnail.height,
+            'blurhash', thumbnail.blurhash,
+            'content_hash', thumbnail.content_hash
+        )
+        from tapes.thumbnail
+        where thumbnail.tape_id = tape.id
+    ), 'null'::jsonb)::jsonb as thumbnail,
+    coalesce(
+        jsonb_agg(jsonb_build_object(
+            'index', image.index,
+            'color', image.color,
+            'width', image.width,
+            'height', image.height,
+            'rotated', image.rotated,
+            'blurhash', image.blurhash,
+            'variants', image.variants
+        ) order by image.index) filter (where image.tape_id is not null),
+        '[]'::jsonb
+    )::jsonb as images,
+    array(
+        select tag_name
+        from tapes.tape_to_tag
+        where tape_to_tag.tape_id = tape.id
+        order by tag_name
+    )::text[] as tags
+from tapes.tape
+left join tapes.image on image.tape_id = tape.id
+group by tape.id
+order by tape.id
+`
+
+type GetSyncedTapesRow struct {
+	ID            int32
+	Title         string
+	Year          sql.NullInt32
+	Runtime       sql.NullInt32
+	ContributorID sql.NullString
+	IsRetired     bool
+	Thumbnail     json.RawMessage
+	Images        json.RawMessage
+	Tags          []string
+}
+
+func (q *Queries) GetSyncedTapes(ctx context.Context) ([]GetSyncedTapesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSyncedTapes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSyncedTapesRow
+	for rows.Next() {
+		var i GetSyncedTapesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Year,
+			&i.Runtime,
+			&i.ContributorID,
+			&i.IsRetired,
+			&i.Thumbnail,
+			&i.Images,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncs = `-- name: GetSyncs :many
+select
+    sync.uuid,
+    sync.started_at,
+    sync.finished_at,
+    sync.error,
+    sync.num_tapes,
+    coalesce(
+        nullif((select count(*) from tapes.sync_warning where sync_warning.sync_uuid = sync.uuid), 0),
+        array_length(string_to_array(nullif(sync.warnings, ''), E'\n'), 1),
+        0
+    )::integer as num_warnings
+from tapes.sync
+where $1::uuid is null
+    or sync.started_at < $2::timestamptz
+    or (sync.started_at = $2::timestamptz and sync.uuid > $1::uuid)
+order by sync.started_at desc, sync.uuid
+limit $3::integer
+`
+
+type GetSyncsParams struct {
+	AfterUuid      uuid.NullUUID
+	AfterStartedAt time.Time
+	PageSize       int32
+}
+
+type GetSyncsRow struct {
+	Uuid        uuid.UUID
+	StartedAt   time.Time
+	FinishedAt  sql.NullTime
+	Error       sql.NullString
+	NumTapes    sql.NullInt32
+	NumWarnings int32
+}
+
+func (q *Queries) GetSyncs(ctx context.Context, arg GetSyncsParams) ([]GetSyncsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSyncs, arg.AfterUuid, arg.AfterStartedAt, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSyncsRow
+	for rows.Next() {
+		var i GetSyncsRow
+		if err := rows.Scan(
+			&i.Uuid,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Error,
+			&i.NumTapes,
+			&i.NumWarnings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const recordFailedSync = `-- name: RecordFailedSync :exec
 update tapes.sync set
     finished_at = now(),
@@ -47,27 +445,153 @@ func (q *Queries) RecordFailedSync(ctx context.Context, arg RecordFailedSyncPara
 	return err
 }
 
+const recordImageMetadata = `-- name: RecordImageMetadata :exec
+insert into tapes.image_metadata (
+    filename,
+    etag,
+    last_modified,
+    metadata,
+    analysis_version,
+    analysis,
+    updated_at
+) values (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    now()
+)
+on conflict (filename) do update set
+    etag = excluded.etag,
+    last_modified = excluded.last_modified,
+    metadata = excluded.metadata,
+    analysis_version = excluded.analysis_version,
+    analysis = excluded.analysis,
+    updated_at = excluded.updated_at
+`
+
+type RecordImageMetadataParams struct {
+	Filename        string
+	Etag            string
+	LastModified    time.Time
+	Metadata        json.RawMessage
+	AnalysisVersion int32
+	Analysis        json.RawMessage
+}
+
+func (q *Queries) RecordImageMetadata(ctx context.Context, arg RecordImageMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, recordImageMetadata,
+		arg.Filename,
+		arg.Etag,
+		arg.LastModified,
+		arg.Metadata,
+		arg.AnalysisVersion,
+		arg.Analysis,
+	)
+	return err
+}
+
 const recordSuccessfulSync = `-- name: RecordSuccessfulSync :exec
 update tapes.sync set
     finished_at = now(),
     num_tapes = $1::integer,
-    warnings = $2::text
+    warnings = $2::text,
+    source_fingerprint = $3
 where
-    sync.uuid = $3
+    sync.uuid = $4
     and finished_at is null
 `
 
 type RecordSuccessfulSyncParams struct {
-	NumTapes int32
-	Warnings string
-	Uuid     uuid.UUID
+	NumTapes          int32
+	Warnings          string
+	SourceFingerprint sql.NullString
+	Uuid              uuid.UUID
 }
 
 func (q *Queries) RecordSuccessfulSync(ctx context.Context, arg RecordSuccessfulSyncParams) error {
-	_, err := q.db.ExecContext(ctx, recordSuccessfulSync, arg.NumTapes, arg.Warnings, arg.Uuid)
+	_, err := q.db.ExecContext(ctx, recordSuccessfulSync,
+		arg.NumTapes,
+		arg.Warnings,
+		arg.SourceFingerprint,
+		arg.Uuid,
+	)
 	return err
 }
 
+const recordSyncWarning = `-- name: RecordSyncWarning :exec
+insert into tapes.sync_warning (
+    sync_uuid,
+    index,
+    source,
+    kind,
+    tape_id,
+    row_number,
+    filename,
+    message
+) values (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+`
+
+type RecordSyncWarningParams struct {
+	SyncUuid  uuid.UUID
+	Index     int32
+	Source    string
+	Kind      string
+	TapeID    sql.NullInt32
+	RowNumber sql.NullInt32
+	Filename  sql.NullString
+	Message   string
+}
+
+func (q *Queries) RecordSyncWarning(ctx context.Context, arg RecordSyncWarningParams) error {
+	_, err := q.db.ExecContext(ctx, recordSyncWarning,
+		arg.SyncUuid,
+		arg.Index,
+		arg.Source,
+		arg.Kind,
+		arg.TapeID,
+		arg.RowNumber,
+		arg.Filename,
+		arg.Message,
+	)
+	return err
+}
+
+const releaseSyncLock = `-- name: ReleaseSyncLock :exec
+select pg_advisory_unlock('tapes'::regnamespace::oid::bigint)
+`
+
+func (q *Queries) ReleaseSyncLock(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, releaseSyncLock)
+	return err
+}
+
+const retireTapes = `-- name: RetireTapes :execrows
+update tapes.tape set retired_at = now()
+where
+    tape.retired_at is null
+    and not (tape.id = any($1::integer[]))
+`
+
+func (q *Queries) RetireTapes(ctx context.Context, activeTapeIds []int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retireTapes, pq.Array(activeTapeIds))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const syncImage = `-- name: SyncImage :exec
 insert into tapes.image (
     tape_id,
@@ -75,29 +599,37 @@ insert into tapes.image (
     color,
     width,
     height,
-    rotated
+    rotated,
+    blurhash,
+    variants
 ) values (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    coalesce($8::jsonb, '[]'::jsonb)
 )
 on conflict (tape_id, index) do update set
     color = excluded.color,
     width = excluded.width,
     height = excluded.height,
-    rotated = excluded.rotated
+    rotated = excluded.rotated,
+    blurhash = excluded.blurhash,
+    variants = excluded.variants
 `
 
 type SyncImageParams struct {
-	TapeID  int32
-	Index   int32
-	Color   string
-	Width   int32
-	Height  int32
-	Rotated bool
+	TapeID   int32
+	Index    int32
+	Color    string
+	Width    int32
+	Height   int32
+	Rotated  bool
+	Blurhash string
+	Variants json.RawMessage
 }
 
 func (q *Queries) SyncImage(ctx context.Context, arg SyncImageParams) error {
@@ -108,6 +640,8 @@ func (q *Queries) SyncImage(ctx context.Context, arg SyncImageParams) error {
 		arg.Width,
 		arg.Height,
 		arg.Rotated,
+		arg.Blurhash,
+		arg.Variants,
 	)
 	return err
 }
@@ -132,7 +666,8 @@ on conflict (id) do update set
     title = excluded.title,
     year = excluded.year,
     runtime = excluded.runtime,
-    contributor_id = excluded.contributor_id
+    contributor_id = excluded.contributor_id,
+    retired_at = null
 `
 
 type SyncTapeParams struct {
@@ -176,3 +711,59 @@ func (q *Queries) SyncTapeTags(ctx context.Context, arg SyncTapeTagsParams) erro
 	_, err := q.db.ExecContext(ctx, syncTapeTags, arg.TapeID, pq.Array(arg.TagNames))
 	return err
 }
+
+const syncThumbnail = `-- name: SyncThumbnail :exec
+insert into tapes.thumbnail (
+    tape_id,
+    color,
+    width,
+    height,
+    blurhash,
+    content_hash
+) values (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+on conflict (tape_id) do update set
+    color = excluded.color,
+    width = excluded.width,
+    height = excluded.height,
+    blurhash = excluded.blurhash,
+    content_hash = excluded.content_hash
+`
+
+type SyncThumbnailParams struct {
+	TapeID      int32
+	Color       string
+	Width       int32
+	Height      int32
+	Blurhash    string
+	ContentHash string
+}
+
+func (q *Queries) SyncThumbnail(ctx context.Context, arg SyncThumbnailParams) error {
+	_, err := q.db.ExecContext(ctx, syncThumbnail,
+		arg.TapeID,
+		arg.Color,
+		arg.Width,
+		arg.Height,
+		arg.Blurhash,
+		arg.ContentHash,
+	)
+	return err
+}
+
+const tryAcquireSyncLock = `-- name: TryAcquireSyncLock :one
+select pg_try_advisory_lock('tapes'::regnamespace::oid::bigint)::boolean as acquired
+`
+
+func (q *Queries) TryAcquireSyncLock(ctx context.Context) (bool, error) {
+	row := q.db.QueryRowContext(ctx, tryAcquireSyncLock)
+	var acquired bool
+	err := row.Scan(&acquired)
+	return acquired, err
+}
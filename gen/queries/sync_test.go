@@ -3,7 +3,9 @@ package queries_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/golden-vcr/server-common/querytest"
 	"github.com/golden-vcr/tapes/gen/queries"
@@ -231,3 +233,437 @@ func Test_SyncImage(t *testing.T) {
 
 	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.image")
 }
+
+func Test_SyncThumbnail(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.thumbnail")
+
+	err := q.SyncTape(context.Background(), queries.SyncTapeParams{
+		ID:    99,
+		Title: "Tape 99",
+	})
+	assert.NoError(t, err)
+
+	err = q.SyncThumbnail(context.Background(), queries.SyncThumbnailParams{
+		TapeID:      99,
+		Color:       "#ffcc00",
+		Width:       120,
+		Height:      256,
+		Blurhash:    "TEHV6nWB2yk8pyo0adR*.7kCMdnj",
+		ContentHash: "aaaa",
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 1, `
+		SELECT COUNT(*) FROM tapes.thumbnail
+			WHERE tape_id = 99
+			AND color = '#ffcc00'
+			AND width = 120
+			AND height = 256
+			AND blurhash = 'TEHV6nWB2yk8pyo0adR*.7kCMdnj'
+			AND content_hash = 'aaaa'
+	`)
+
+	// Syncing a replaced thumbnail should update the existing record
+	err = q.SyncThumbnail(context.Background(), queries.SyncThumbnailParams{
+		TapeID:      99,
+		Color:       "#ffee99",
+		Width:       128,
+		Height:      256,
+		Blurhash:    "TEHV6nWB2yk8pyo0adR*.7kCMdnj",
+		ContentHash: "bbbb",
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 1, `
+		SELECT COUNT(*) FROM tapes.thumbnail
+			WHERE tape_id = 99
+			AND color = '#ffee99'
+			AND width = 128
+			AND content_hash = 'bbbb'
+	`)
+
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.thumbnail")
+}
+
+func Test_DeleteThumbnail(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title) VALUES
+			(1, now(), 'Tape 1'),
+			(2, now(), 'Tape 2')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.thumbnail (tape_id, color, width, height, blurhash, content_hash) VALUES
+			(1, '#ffffff', 128, 256, '', 'aaaa'),
+			(2, '#ffffff', 128, 256, '', 'bbbb')
+	`)
+	assert.NoError(t, err)
+
+	// Only the thumbnail for the given tape should be deleted, e.g. because it's gone
+	// missing from the bucket or can no longer be decoded
+	numDeleted, err := q.DeleteThumbnail(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), numDeleted)
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.thumbnail WHERE tape_id = 1")
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.thumbnail WHERE tape_id = 2")
+
+	// Deleting a thumbnail that's not recorded is a no-op
+	numDeleted, err = q.DeleteThumbnail(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), numDeleted)
+}
+
+func Test_RetireTapes(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title) VALUES
+			(1, now(), 'Tape 1'),
+			(2, now(), 'Tape 2'),
+			(3, now(), 'Tape 3')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO tapes.favorite (twitch_user_id, tape_id) VALUES ('1234', 2)")
+	assert.NoError(t, err)
+
+	// Tapes that aren't in the active set should be retired, and favorites should be
+	// left intact
+	numRetired, err := q.RetireTapes(context.Background(), []int32{1, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), numRetired)
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.tape WHERE retired_at IS NOT NULL")
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.tape WHERE id = 2 AND retired_at = now()")
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.favorite WHERE tape_id = 2")
+
+	// Retiring should be idempotent: already-retired tapes are not counted again
+	numRetired, err = q.RetireTapes(context.Background(), []int32{1, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), numRetired)
+
+	// Syncing a retired tape should restore it
+	err = q.SyncTape(context.Background(), queries.SyncTapeParams{
+		ID:    2,
+		Title: "Tape 2",
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.tape WHERE retired_at IS NOT NULL")
+}
+
+func Test_DeleteStaleImages(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title) VALUES
+			(1, now(), 'Tape 1'),
+			(2, now(), 'Tape 2')
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.image (tape_id, index, color, width, height, rotated) VALUES
+			(1, 0, '#ffffff', 100, 200, false),
+			(1, 1, '#ffffff', 100, 200, false),
+			(1, 2, '#ffffff', 100, 200, false),
+			(2, 0, '#ffffff', 100, 200, false),
+			(2, 1, '#ffffff', 100, 200, false)
+	`)
+	assert.NoError(t, err)
+
+	// Only images for the given tape whose indices aren't listed should be deleted
+	numDeleted, err := q.DeleteStaleImages(context.Background(), queries.DeleteStaleImagesParams{
+		TapeID:  1,
+		Indices: []int32{0, 2},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), numDeleted)
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.image WHERE tape_id = 1 AND index IN (0, 2)")
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.image WHERE tape_id = 2")
+
+	// An empty set of indices should delete all images for a tape that's been excluded
+	// from the sync, so that it no longer appears in the catalog
+	numDeleted, err = q.DeleteStaleImages(context.Background(), queries.DeleteStaleImagesParams{
+		TapeID:  2,
+		Indices: []int32{},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), numDeleted)
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.image WHERE tape_id = 2")
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.image WHERE tape_id = 1")
+
+	rows, err := q.GetTapes(context.Background(), queries.GetTapesParams{})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, int32(1), rows[0].ID)
+}
+
+func Test_GetSyncedTapes(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.tape (id, created_at, title, year, retired_at) VALUES
+			(1, now(), 'Tape 1', 1991, NULL),
+			(2, now(), 'Tape 2', NULL, now())
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec(`
+		INSERT INTO tapes.image (tape_id, index, color, width, height, rotated) VALUES
+			(1, 1, '#ffffff', 101, 201, true),
+			(1, 0, '#000000', 100, 200, false)
+	`)
+	assert.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO tapes.tape_to_tag (tape_id, tag_name) VALUES (1, 'fitness')")
+	assert.NoError(t, err)
+
+	// Tapes should be returned even if retired or lacking images
+	rows, err := q.GetSyncedTapes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	assert.Equal(t, int32(1), rows[0].ID)
+	assert.Equal(t, sql.NullInt32{Valid: true, Int32: 1991}, rows[0].Year)
+	assert.False(t, rows[0].IsRetired)
+	assert.Equal(t, []string{"fitness"}, rows[0].Tags)
+	assert.JSONEq(t, `[
+		{"index": 0, "color": "#000000", "width": 100, "height": 200, "rotated": false},
+		{"index": 1, "color": "#ffffff", "width": 101, "height": 201, "rotated": true}
+	]`, string(rows[0].Images))
+
+	assert.Equal(t, int32(2), rows[1].ID)
+	assert.True(t, rows[1].IsRetired)
+	assert.Equal(t, []string{}, rows[1].Tags)
+	assert.JSONEq(t, `[]`, string(rows[1].Images))
+}
+
+func Test_GetSyncs(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.sync (uuid, started_at, finished_at, error, num_tapes, warnings) VALUES
+			('1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d', now() - '2 hours'::interval, now() - '2 hours'::interval, NULL, 42, E'Spreadsheet row 3: bad year\nTape 7 has no gallery images; ignoring it.'),
+			('8e1f8d8e-0d0a-4b5d-8f5f-3a4b5e6f7a8b', now() - '1 hour'::interval, now() - '1 hour'::interval, 'spreadsheet is unavailable', NULL, NULL),
+			('c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d', now(), NULL, NULL, NULL, NULL)
+	`)
+	assert.NoError(t, err)
+
+	rows, err := q.GetSyncs(context.Background(), queries.GetSyncsParams{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uuid.MustParse("c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d"), rows[0].Uuid)
+	assert.False(t, rows[0].FinishedAt.Valid)
+	assert.Equal(t, int32(0), rows[0].NumWarnings)
+	assert.Equal(t, uuid.MustParse("8e1f8d8e-0d0a-4b5d-8f5f-3a4b5e6f7a8b"), rows[1].Uuid)
+	assert.Equal(t, sql.NullString{Valid: true, String: "spreadsheet is unavailable"}, rows[1].Error)
+
+	rows, err = q.GetSyncs(context.Background(), queries.GetSyncsParams{
+		AfterUuid:      uuid.NullUUID{Valid: true, UUID: rows[1].Uuid},
+		AfterStartedAt: rows[1].StartedAt,
+		PageSize:       2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"), rows[0].Uuid)
+	assert.Equal(t, sql.NullInt32{Valid: true, Int32: 42}, rows[0].NumTapes)
+	assert.Equal(t, int32(2), rows[0].NumWarnings)
+
+	// Warnings that were recorded individually are counted in preference to the legacy
+	// newline-delimited text
+	_, err = tx.Exec(`
+		INSERT INTO tapes.sync_warning (sync_uuid, index, source, kind, message) VALUES
+			('1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d', 0, 'spreadsheet', 'invalid_row', 'bad year'),
+			('1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d', 1, 'storage', 'missing_gallery', 'no gallery images'),
+			('1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d', 2, 'storage', 'missing_thumbnail', 'no thumbnail'),
+			('c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d', 0, 'spreadsheet', 'invalid_row', 'bad year')
+	`)
+	assert.NoError(t, err)
+	rows, err = q.GetSyncs(context.Background(), queries.GetSyncsParams{PageSize: 3})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, int32(1), rows[0].NumWarnings)
+	assert.Equal(t, int32(0), rows[1].NumWarnings)
+	assert.Equal(t, int32(3), rows[2].NumWarnings)
+}
+
+func Test_GetSync(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	syncUuid := uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+	_, err := q.GetSync(context.Background(), syncUuid)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = q.CreateSync(context.Background(), syncUuid)
+	assert.NoError(t, err)
+	err = q.RecordSuccessfulSync(context.Background(), queries.RecordSuccessfulSyncParams{
+		Uuid:     syncUuid,
+		NumTapes: 42,
+		Warnings: "Spreadsheet row 3: bad year",
+	})
+	assert.NoError(t, err)
+
+	row, err := q.GetSync(context.Background(), syncUuid)
+	assert.NoError(t, err)
+	assert.Equal(t, syncUuid, row.Uuid)
+	assert.True(t, row.FinishedAt.Valid)
+	assert.Equal(t, sql.NullInt32{Valid: true, Int32: 42}, row.NumTapes)
+	assert.Equal(t, sql.NullString{Valid: true, String: "Spreadsheet row 3: bad year"}, row.Warnings)
+}
+
+func Test_RecordSyncWarning(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	syncUuid := uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d")
+	err := q.CreateSync(context.Background(), syncUuid)
+	assert.NoError(t, err)
+
+	err = q.RecordSyncWarning(context.Background(), queries.RecordSyncWarningParams{
+		SyncUuid: syncUuid,
+		Index:    1,
+		Source:   "storage",
+		Kind:     "missing_thumbnail",
+		TapeID:   sql.NullInt32{Valid: true, Int32: 41},
+		Filename: sql.NullString{Valid: true, String: "0041_a.jpg"},
+		Message:  "tape 41 has gallery image(s) but no accompanying thumbnail image",
+	})
+	assert.NoError(t, err)
+	err = q.RecordSyncWarning(context.Background(), queries.RecordSyncWarningParams{
+		SyncUuid:  syncUuid,
+		Index:     0,
+		Source:    "spreadsheet",
+		Kind:      "invalid_row",
+		RowNumber: sql.NullInt32{Valid: true, Int32: 3},
+		Message:   "'id' value is required",
+	})
+	assert.NoError(t, err)
+
+	rows, err := q.GetSyncWarnings(context.Background(), syncUuid)
+	assert.NoError(t, err)
+	assert.Equal(t, []queries.GetSyncWarningsRow{
+		{
+			Source:    "spreadsheet",
+			Kind:      "invalid_row",
+			RowNumber: sql.NullInt32{Valid: true, Int32: 3},
+			Message:   "'id' value is required",
+		},
+		{
+			Source:   "storage",
+			Kind:     "missing_thumbnail",
+			TapeID:   sql.NullInt32{Valid: true, Int32: 41},
+			Filename: sql.NullString{Valid: true, String: "0041_a.jpg"},
+			Message:  "tape 41 has gallery image(s) but no accompanying thumbnail image",
+		},
+	}, rows)
+}
+
+func Test_GetRecentlyWarnedTapes(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := tx.Exec(`
+		INSERT INTO tapes.sync (uuid, started_at, finished_at, error, num_tapes, warnings) VALUES
+			('00000000-0000-4000-8000-000000000001', now() - '3 hours'::interval, now() - '3 hours'::interval, NULL, 40, ''),
+			('00000000-0000-4000-8000-000000000002', now() - '2 hours'::interval, now() - '2 hours'::interval, NULL, 40, ''),
+			('00000000-0000-4000-8000-000000000003', now() - '1 hour'::interval, now() - '1 hour'::interval, 'sync failed', NULL, NULL),
+			('00000000-0000-4000-8000-000000000004', now(), now(), NULL, 40, '');
+		INSERT INTO tapes.sync_warning (sync_uuid, index, source, kind, tape_id, message) VALUES
+			('00000000-0000-4000-8000-000000000001', 0, 'sync', 'no_gallery_images', 12, 'Tape 12 has no gallery images; ignoring it.'),
+			('00000000-0000-4000-8000-000000000002', 0, 'sync', 'no_gallery_images', 7, 'Tape 7 has no gallery images; ignoring it.'),
+			('00000000-0000-4000-8000-000000000004', 0, 'storage', 'missing_thumbnail', 7, 'tape 7 has gallery image(s) but no accompanying thumbnail image'),
+			('00000000-0000-4000-8000-000000000004', 1, 'sync', 'no_gallery_images', 7, 'Tape 7 has no gallery images; ignoring it.'),
+			('00000000-0000-4000-8000-000000000004', 2, 'sync', 'no_valid_tapes', NULL, 'Spreadsheet contains no valid tapes; not retiring any existing tapes.');
+	`)
+	assert.NoError(t, err)
+
+	// Only the 2 most recent successful syncs should be considered
+	rows, err := q.GetRecentlyWarnedTapes(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, int32(7), rows[0].TapeID)
+	assert.Equal(t, int32(2), rows[0].NumSyncs)
+	assert.Equal(t, []string{"missing_thumbnail", "no_gallery_images"}, rows[0].Kinds)
+
+	rows, err = q.GetRecentlyWarnedTapes(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, int32(7), rows[0].TapeID)
+	assert.Equal(t, int32(12), rows[1].TapeID)
+	assert.Equal(t, int32(1), rows[1].NumSyncs)
+}
+
+func Test_TryAcquireSyncLock(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	acquired, err := q.TryAcquireSyncLock(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	err = q.ReleaseSyncLock(context.Background())
+	assert.NoError(t, err)
+}
+
+func Test_GetLatestSyncFingerprint(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	_, err := q.GetLatestSyncFingerprint(context.Background())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = tx.Exec(`
+		INSERT INTO tapes.sync (uuid, started_at, finished_at, error, num_tapes, warnings, source_fingerprint) VALUES
+			('00000000-0000-4000-8000-000000000001', now() - '2 hours'::interval, now() - '2 hours'::interval, NULL, 40, '', 'aaaa'),
+			('00000000-0000-4000-8000-000000000002', now() - '1 hour'::interval, now() - '1 hour'::interval, 'sync failed', NULL, NULL, NULL),
+			('00000000-0000-4000-8000-000000000003', now(), NULL, NULL, NULL, NULL, NULL)
+	`)
+	assert.NoError(t, err)
+
+	// Only successful syncs should be considered
+	fingerprint, err := q.GetLatestSyncFingerprint(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, sql.NullString{Valid: true, String: "aaaa"}, fingerprint)
+}
+
+func Test_RecordImageMetadata(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	lastModified := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	err := q.RecordImageMetadata(context.Background(), queries.RecordImageMetadataParams{
+		Filename:        "0042_a.jpg",
+		Etag:            `"aaaa"`,
+		LastModified:    lastModified,
+		Metadata:        json.RawMessage(`{"Width":"700"}`),
+		AnalysisVersion: 0,
+		Analysis:        json.RawMessage(`null`),
+	})
+	assert.NoError(t, err)
+
+	// Recording metadata for the same file again should replace the cached values
+	err = q.RecordImageMetadata(context.Background(), queries.RecordImageMetadataParams{
+		Filename:        "0042_a.jpg",
+		Etag:            `"bbbb"`,
+		LastModified:    lastModified.Add(time.Hour),
+		Metadata:        json.RawMessage(`{"Width":"701"}`),
+		AnalysisVersion: 1,
+		Analysis:        json.RawMessage(`{"Width":701}`),
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 1, "SELECT COUNT(*) FROM tapes.image_metadata")
+
+	rows, err := q.GetImageMetadata(context.Background(), []string{"0042_a.jpg", "0042_b.jpg"})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "0042_a.jpg", rows[0].Filename)
+	assert.Equal(t, `"bbbb"`, rows[0].Etag)
+	assert.True(t, lastModified.Add(time.Hour).Equal(rows[0].LastModified))
+	assert.JSONEq(t, `{"Width":"701"}`, string(rows[0].Metadata))
+	assert.Equal(t, int32(1), rows[0].AnalysisVersion)
+	assert.JSONEq(t, `{"Width":701}`, string(rows[0].Analysis))
+}
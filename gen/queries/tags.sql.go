@@ -0,0 +1,67 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: tags.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const getTagCount = `-- name: GetTagCount :one
+select
+    count(*) as num_tapes
+from tapes.tape_to_tag
+join tapes.tape on tape.id = tape_to_tag.tape_id
+where tape_to_tag.tag_name = $1
+    and tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+`
+
+func (q *Queries) GetTagCount(ctx context.Context, tagName string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTagCount, tagName)
+	var num_tapes int64
+	err := row.Scan(&num_tapes)
+	return num_tapes, err
+}
+
+const getTagCounts = `-- name: GetTagCounts :many
+select
+    tape_to_tag.tag_name as name,
+    count(*) as num_tapes
+from tapes.tape_to_tag
+join tapes.tape on tape.id = tape_to_tag.tape_id
+where tape.retired_at is null
+    and exists (select 1 from tapes.image where image.tape_id = tape.id)
+group by tape_to_tag.tag_name
+order by tape_to_tag.tag_name
+`
+
+type GetTagCountsRow struct {
+	Name     string
+	NumTapes int64
+}
+
+func (q *Queries) GetTagCounts(ctx context.Context) ([]GetTagCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTagCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagCountsRow
+	for rows.Next() {
+		var i GetTagCountsRow
+		if err := rows.Scan(&i.Name, &i.NumTapes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
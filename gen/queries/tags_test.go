@@ -0,0 +1,74 @@
+package queries_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golden-vcr/server-common/querytest"
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetTagCounts(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	tapes := []struct {
+		id        int32
+		tags      []string
+		hasImages bool
+	}{
+		{1, []string{"fitness", "instructional"}, true},
+		{2, []string{"fitness"}, true},
+		{3, []string{"fitness", "christmas"}, true},
+		{4, []string{"fitness", "music"}, false},
+	}
+	for _, tape := range tapes {
+		err := q.SyncTape(context.Background(), queries.SyncTapeParams{
+			ID:    tape.id,
+			Title: "Tape",
+		})
+		assert.NoError(t, err)
+		err = q.SyncTapeTags(context.Background(), queries.SyncTapeTagsParams{
+			TapeID:   tape.id,
+			TagNames: tape.tags,
+		})
+		assert.NoError(t, err)
+		if tape.hasImages {
+			err = q.SyncImage(context.Background(), queries.SyncImageParams{
+				TapeID: tape.id,
+				Index:  0,
+				Width:  500,
+				Height: 1000,
+				Color:  "#ff0000",
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	// Tapes with no images are not listed in the catalog, so their tags aren't counted
+	rows, err := q.GetTagCounts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []queries.GetTagCountsRow{
+		{Name: "christmas", NumTapes: 1},
+		{Name: "fitness", NumTapes: 3},
+		{Name: "instructional", NumTapes: 1},
+	}, rows)
+
+	// Retired tapes are not counted
+	_, err = q.RetireTapes(context.Background(), []int32{1, 2, 4})
+	assert.NoError(t, err)
+	rows, err = q.GetTagCounts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []queries.GetTagCountsRow{
+		{Name: "fitness", NumTapes: 2},
+		{Name: "instructional", NumTapes: 1},
+	}, rows)
+
+	numTapes, err := q.GetTagCount(context.Background(), "fitness")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), numTapes)
+	numTapes, err = q.GetTagCount(context.Background(), "christmas")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), numTapes)
+}
@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.20.0
+// source: users.sql
+
+package queries
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getTwitchUsers = `-- name: GetTwitchUsers :many
+select
+    twitch_user.twitch_user_id,
+    twitch_user.display_name,
+    twitch_user.updated_at
+from tapes.twitch_user
+where twitch_user.twitch_user_id = any($1::text[])
+`
+
+func (q *Queries) GetTwitchUsers(ctx context.Context, twitchUserIds []string) ([]TapesTwitchUser, error) {
+	rows, err := q.db.QueryContext(ctx, getTwitchUsers, pq.Array(twitchUserIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TapesTwitchUser
+	for rows.Next() {
+		var i TapesTwitchUser
+		if err := rows.Scan(&i.TwitchUserID, &i.DisplayName, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordTwitchUser = `-- name: RecordTwitchUser :exec
+insert into tapes.twitch_user (
+    twitch_user_id,
+    display_name,
+    updated_at
+) values (
+    $1,
+    $2,
+    now()
+)
+on conflict (twitch_user_id) do update set
+    display_name = excluded.display_name,
+    updated_at = excluded.updated_at
+`
+
+type RecordTwitchUserParams struct {
+	TwitchUserID string
+	DisplayName  string
+}
+
+func (q *Queries) RecordTwitchUser(ctx context.Context, arg RecordTwitchUserParams) error {
+	_, err := q.db.ExecContext(ctx, recordTwitchUser, arg.TwitchUserID, arg.DisplayName)
+	return err
+}
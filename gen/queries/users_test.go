@@ -0,0 +1,87 @@
+package queries_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golden-vcr/server-common/querytest"
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RecordTwitchUser(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	querytest.AssertCount(t, tx, 0, "SELECT COUNT(*) FROM tapes.twitch_user")
+
+	err := q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "1234",
+		DisplayName:  "JoeBob",
+	})
+	assert.NoError(t, err)
+	err = q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "5678",
+		DisplayName:  "BigBilly",
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.twitch_user")
+
+	// Recording the same user again should update their display name
+	err = q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "1234",
+		DisplayName:  "JoeBobRenamed",
+	})
+	assert.NoError(t, err)
+	querytest.AssertCount(t, tx, 2, "SELECT COUNT(*) FROM tapes.twitch_user")
+
+	rows, err := q.GetTwitchUsers(context.Background(), []string{"1234", "9999"})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "1234", rows[0].TwitchUserID)
+	assert.Equal(t, "JoeBobRenamed", rows[0].DisplayName)
+}
+
+func Test_RecordTwitchUser_catalogRevision(t *testing.T) {
+	tx := querytest.PrepareTx(t)
+	q := queries.New(tx)
+
+	// Revision triggers are deferred until commit; fire them at the end of each
+	// statement instead so that we can observe each change within this transaction
+	_, err := tx.Exec("SET CONSTRAINTS ALL IMMEDIATE")
+	assert.NoError(t, err)
+
+	initial, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+
+	// Recording a new user bumps the catalog revision
+	err = q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "1234",
+		DisplayName:  "JoeBob",
+	})
+	assert.NoError(t, err)
+	afterInsert, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, afterInsert.Revision, initial.Revision)
+
+	// Refreshing a user whose display name hasn't changed only updates updated_at,
+	// which isn't exposed in the catalog
+	err = q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "1234",
+		DisplayName:  "JoeBob",
+	})
+	assert.NoError(t, err)
+	afterRefresh, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, afterInsert.Revision, afterRefresh.Revision)
+
+	// Renaming a user bumps the catalog revision
+	err = q.RecordTwitchUser(context.Background(), queries.RecordTwitchUserParams{
+		TwitchUserID: "1234",
+		DisplayName:  "JoeBobRenamed",
+	})
+	assert.NoError(t, err)
+	afterRename, err := q.GetCatalogRevision(context.Background())
+	assert.NoError(t, err)
+	assert.Greater(t, afterRename.Revision, afterRefresh.Revision)
+}
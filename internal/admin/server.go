@@ -3,26 +3,43 @@ package admin
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/golden-vcr/auth"
 	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/lib/pq"
 )
 
 type Queries interface {
-	ApplySeries(ctx context.Context, arg queries.ApplySeriesParams) (sql.Result, error)
+	ApplySeries(ctx context.Context, arg queries.ApplySeriesParams) (int32, error)
+	GetSyncs(ctx context.Context, arg queries.GetSyncsParams) ([]queries.GetSyncsRow, error)
+	GetSync(ctx context.Context, argUuid uuid.UUID) (queries.GetSyncRow, error)
+	GetSyncWarnings(ctx context.Context, syncUuid uuid.UUID) ([]queries.GetSyncWarningsRow, error)
+	GetRecentlyWarnedTapes(ctx context.Context, numSyncs int32) ([]queries.GetRecentlyWarnedTapesRow, error)
+}
+
+// Syncer runs syncs from the inventory spreadsheet and storage bucket in the background
+type Syncer interface {
+	Start(ctx context.Context) (uuid.UUID, error)
 }
 
 type Server struct {
-	q Queries
+	q      Queries
+	syncer Syncer
 }
 
-func NewServer(q *queries.Queries) *Server {
+// NewServer initializes an admin server: syncer may be nil if the server is not
+// configured with credentials for the spreadsheet and storage bucket, in which case
+// syncs may not be triggered via the API
+func NewServer(q *queries.Queries, syncer Syncer) *Server {
 	return &Server{
-		q: q,
+		q:      q,
+		syncer: syncer,
 	}
 }
 
@@ -33,6 +50,10 @@ func (s *Server) RegisterRoutes(c auth.Client, r *mux.Router) {
 	})
 
 	r.Path("/apply-series").Methods("POST").HandlerFunc(s.handleApplySeries)
+	r.Path("/sync").Methods("POST").HandlerFunc(s.handleStartSync)
+	r.Path("/syncs").Methods("GET").HandlerFunc(s.handleGetSyncs)
+	r.Path("/syncs/{uuid}").Methods("GET").HandlerFunc(s.handleGetSync)
+	r.Path("/warned-tapes").Methods("GET").HandlerFunc(s.handleGetWarnedTapes)
 }
 
 func (s *Server) handleApplySeries(res http.ResponseWriter, req *http.Request) {
@@ -48,9 +69,24 @@ func (s *Server) handleApplySeries(res http.ResponseWriter, req *http.Request) {
 		http.Error(res, err.Error(), http.StatusBadRequest)
 		return
 	}
-	seriesName := req.PostForm.Get("seriesName")
-	if seriesName == "" {
-		http.Error(res, "seriesName is required", http.StatusBadRequest)
+	// The target series may be identified by ID, in which case it must already exist
+	// (and will be renamed if seriesName is also supplied), or by name, in which case
+	// it's created if it doesn't already exist
+	seriesId := sql.NullInt32{}
+	if seriesIdStr := req.PostForm.Get("seriesId"); seriesIdStr != "" {
+		value, err := strconv.Atoi(seriesIdStr)
+		if err != nil || value <= 0 {
+			http.Error(res, "seriesId must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		seriesId = sql.NullInt32{Valid: true, Int32: int32(value)}
+	}
+	seriesName := sql.NullString{}
+	if name := req.PostForm.Get("seriesName"); name != "" {
+		seriesName = sql.NullString{Valid: true, String: name}
+	}
+	if !seriesId.Valid && !seriesName.Valid {
+		http.Error(res, "seriesId or seriesName is required", http.StatusBadRequest)
 		return
 	}
 	tapeIdsStr := req.PostForm.Get("tapeIds")
@@ -59,14 +95,34 @@ func (s *Server) handleApplySeries(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Parse list of target tape IDs
+	description := sql.NullString{}
+	if req.PostForm.Has("description") {
+		description = sql.NullString{Valid: true, String: req.PostForm.Get("description")}
+	}
+	ordering := sql.NullInt32{}
+	if orderingStr := req.PostForm.Get("ordering"); orderingStr != "" {
+		value, err := strconv.Atoi(orderingStr)
+		if err != nil {
+			http.Error(res, "ordering must be an integer", http.StatusBadRequest)
+			return
+		}
+		ordering = sql.NullInt32{Valid: true, Int32: int32(value)}
+	}
+
+	// Parse list of target tape IDs, in the order they should appear in the series
 	tapeIds := make([]int32, 0)
+	seen := make(map[int32]struct{})
 	for _, token := range strings.Split(tapeIdsStr, ",") {
 		tapeId, err := strconv.Atoi(token)
 		if err != nil {
 			http.Error(res, "tapeIds must be supplied as a comma-delimited list of integers", http.StatusBadRequest)
 			return
 		}
+		if _, ok := seen[int32(tapeId)]; ok {
+			http.Error(res, "tapeIds must not contain duplicates", http.StatusBadRequest)
+			return
+		}
+		seen[int32(tapeId)] = struct{}{}
 		tapeIds = append(tapeIds, int32(tapeId))
 	}
 	if len(tapeIds) == 0 {
@@ -74,12 +130,27 @@ func (s *Server) handleApplySeries(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Update the seriesName value for all target tapes
+	// Create or update the series, then make the target tapes its sole members,
+	// numbered in the order given: any tapes previously in the series that aren't
+	// listed are removed from it
 	_, err = s.q.ApplySeries(req.Context(), queries.ApplySeriesParams{
-		SeriesName: seriesName,
-		TapeIds:    tapeIds,
+		SeriesID:    seriesId,
+		SeriesName:  seriesName,
+		Description: description,
+		Ordering:    ordering,
+		TapeIds:     tapeIds,
 	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(res, "no such series", http.StatusNotFound)
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code.Name() == "unique_violation" {
+				http.Error(res, "another series already has that name", http.StatusConflict)
+				return
+			}
+		}
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/pagination"
+	"github.com/golden-vcr/tapes/internal/syncer"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// defaultSyncPageSize is the number of syncs returned per page if no limit is given
+const defaultSyncPageSize = 20
+
+// maxSyncPageSize is the largest value that may be supplied for the 'limit' parameter
+const maxSyncPageSize = 100
+
+// defaultWarnedTapesNumSyncs is the number of recent syncs that are considered when
+// listing tapes with warnings, if not otherwise specified
+const defaultWarnedTapesNumSyncs = 5
+
+// syncCursor identifies the last sync on a page of results from GET /syncs, which are
+// ordered by start time (most recent first) and then by UUID
+type syncCursor struct {
+	StartedAt time.Time `json:"startedAt"`
+	Uuid      uuid.UUID `json:"uuid"`
+}
+
+func (s *Server) handleStartSync(res http.ResponseWriter, req *http.Request) {
+	if s.syncer == nil {
+		http.Error(res, "syncing is not configured on this server", http.StatusServiceUnavailable)
+		return
+	}
+
+	// The sync continues running after we respond, so it shouldn't be canceled when
+	// the request is finished
+	syncUuid, err := s.syncer.Start(context.WithoutCancel(req.Context()))
+	if errors.Is(err, syncer.ErrSyncInProgress) {
+		http.Error(res, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Respond immediately with the UUID of the new sync, which the caller can use to
+	// poll GET /syncs/{uuid} until the sync is finished
+	res.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(res).Encode(SyncStarted{
+		Uuid: syncUuid.String(),
+	}); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetSyncs(res http.ResponseWriter, req *http.Request) {
+	limit, err := pagination.ParseLimit(req.URL.Query(), defaultSyncPageSize, maxSyncPageSize)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Request one additional row so we can tell whether there's another page
+	params := queries.GetSyncsParams{
+		PageSize: int32(limit + 1),
+	}
+	if cursor := req.URL.Query().Get("cursor"); cursor != "" {
+		var after syncCursor
+		if err := pagination.DecodeCursor(cursor, &after); err != nil || after.Uuid == uuid.Nil {
+			http.Error(res, "'cursor' is not valid", http.StatusBadRequest)
+			return
+		}
+		params.AfterUuid = uuid.NullUUID{Valid: true, UUID: after.Uuid}
+		params.AfterStartedAt = after.StartedAt
+	}
+	rows, err := s.q.GetSyncs(req.Context(), params)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nextCursor := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = pagination.EncodeCursor(syncCursor{
+			StartedAt: last.StartedAt,
+			Uuid:      last.Uuid,
+		})
+	}
+
+	syncs := make([]SyncSummary, 0, len(rows))
+	for _, row := range rows {
+		syncs = append(syncs, newSyncSummary(row.Uuid, row.StartedAt, row.FinishedAt, row.Error, row.NumTapes, int(row.NumWarnings)))
+	}
+	if err := json.NewEncoder(res).Encode(SyncHistory{
+		Syncs:      syncs,
+		NextCursor: nextCursor,
+	}); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetSync(res http.ResponseWriter, req *http.Request) {
+	syncUuid, err := uuid.Parse(mux.Vars(req)["uuid"])
+	if err != nil {
+		http.Error(res, "invalid sync UUID", http.StatusBadRequest)
+		return
+	}
+
+	row, err := s.q.GetSync(req.Context(), syncUuid)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(res, "no such sync", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Syncs record each warning individually, but older syncs only recorded warnings
+	// as newline-delimited text, which we need to parse
+	warningRows, err := s.q.GetSyncWarnings(req.Context(), syncUuid)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var warnings []SyncWarning
+	if len(warningRows) > 0 {
+		warnings = make([]SyncWarning, 0, len(warningRows))
+		for _, warningRow := range warningRows {
+			warnings = append(warnings, SyncWarning{
+				Source:    SyncWarningSource(warningRow.Source),
+				Kind:      warningRow.Kind,
+				RowNumber: int(warningRow.RowNumber.Int32),
+				Filename:  warningRow.Filename.String,
+				TapeId:    int(warningRow.TapeID.Int32),
+				Message:   warningRow.Message,
+			})
+		}
+	} else {
+		warnings = parseWarnings(row.Warnings.String)
+	}
+
+	if err := json.NewEncoder(res).Encode(SyncDetails{
+		SyncSummary: newSyncSummary(row.Uuid, row.StartedAt, row.FinishedAt, row.Error, row.NumTapes, len(warnings)),
+		Warnings:    warnings,
+	}); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetWarnedTapes(res http.ResponseWriter, req *http.Request) {
+	numSyncs := defaultWarnedTapesNumSyncs
+	if numSyncsStr := req.URL.Query().Get("syncs"); numSyncsStr != "" {
+		value, err := strconv.Atoi(numSyncsStr)
+		if err != nil || value <= 0 || value > maxSyncPageSize {
+			http.Error(res, fmt.Sprintf("'syncs' must be an integer between 1 and %d", maxSyncPageSize), http.StatusBadRequest)
+			return
+		}
+		numSyncs = value
+	}
+
+	rows, err := s.q.GetRecentlyWarnedTapes(req.Context(), int32(numSyncs))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tapes := make([]WarnedTape, 0, len(rows))
+	for _, row := range rows {
+		tapes = append(tapes, WarnedTape{
+			TapeId:       int(row.TapeID),
+			NumSyncs:     int(row.NumSyncs),
+			LastWarnedAt: row.LastWarnedAt,
+			Kinds:        row.Kinds,
+		})
+	}
+	if err := json.NewEncoder(res).Encode(WarnedTapeListing{
+		NumSyncs: numSyncs,
+		Tapes:    tapes,
+	}); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newSyncSummary initializes a SyncSummary from the columns of a tapes.sync row
+func newSyncSummary(syncUuid uuid.UUID, startedAt time.Time, finishedAt sql.NullTime, syncErr sql.NullString, numTapes sql.NullInt32, numWarnings int) SyncSummary {
+	summary := SyncSummary{
+		Uuid:        syncUuid.String(),
+		Status:      SyncStatusIncomplete,
+		StartedAt:   startedAt,
+		NumTapes:    int(numTapes.Int32),
+		NumWarnings: numWarnings,
+	}
+	if finishedAt.Valid {
+		summary.FinishedAt = &finishedAt.Time
+		if syncErr.Valid {
+			summary.Status = SyncStatusFailed
+			summary.Error = syncErr.String
+		} else {
+			summary.Status = SyncStatusSucceeded
+		}
+	}
+	return summary
+}
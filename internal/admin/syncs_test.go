@@ -0,0 +1,371 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/pagination"
+	"github.com/golden-vcr/tapes/internal/syncer"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleStartSync(t *testing.T) {
+	syncUuid := uuid.MustParse("c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d")
+	tests := []struct {
+		name       string
+		syncer     Syncer
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			&mockSyncer{syncUuid: syncUuid},
+			http.StatusAccepted,
+			`{"uuid":"c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d"}`,
+		},
+		{
+			"sync already in progress",
+			&mockSyncer{err: syncer.ErrSyncInProgress},
+			http.StatusConflict,
+			"a sync is already in progress",
+		},
+		{
+			"sync failed to start",
+			&mockSyncer{err: fmt.Errorf("mock error")},
+			http.StatusInternalServerError,
+			"mock error",
+		},
+		{
+			"syncing not configured",
+			nil,
+			http.StatusServiceUnavailable,
+			"syncing is not configured on this server",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{q: &mockQueries{}, syncer: tt.syncer}
+			req := httptest.NewRequest(http.MethodPost, "/sync", nil)
+			res := httptest.NewRecorder()
+			s.handleStartSync(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetSyncs(t *testing.T) {
+	startedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Minute)
+	q := &mockQueries{
+		syncs: []queries.TapesSync{
+			{
+				Uuid:      uuid.MustParse("c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d"),
+				StartedAt: startedAt.Add(2 * time.Hour),
+			},
+			{
+				Uuid:       uuid.MustParse("8e1f8d8e-0d0a-4b5d-8f5f-3a4b5e6f7a8b"),
+				StartedAt:  startedAt.Add(time.Hour),
+				FinishedAt: sql.NullTime{Valid: true, Time: finishedAt.Add(time.Hour)},
+				Error:      sql.NullString{Valid: true, String: "spreadsheet is unavailable"},
+			},
+			{
+				Uuid:       uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"),
+				StartedAt:  startedAt,
+				FinishedAt: sql.NullTime{Valid: true, Time: finishedAt},
+				NumTapes:   sql.NullInt32{Valid: true, Int32: 42},
+				Warnings:   sql.NullString{Valid: true, String: "Spreadsheet row 3: bad year\nTape 7 has no gallery images; ignoring it."},
+			},
+		},
+		warnings: map[uuid.UUID][]queries.GetSyncWarningsRow{
+			uuid.MustParse("c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d"): {
+				{Source: "spreadsheet", Kind: "invalid_row", RowNumber: sql.NullInt32{Valid: true, Int32: 3}, Message: "bad year"},
+			},
+		},
+	}
+	secondPageCursor := pagination.EncodeCursor(syncCursor{
+		StartedAt: startedAt.Add(time.Hour),
+		Uuid:      uuid.MustParse("8e1f8d8e-0d0a-4b5d-8f5f-3a4b5e6f7a8b"),
+	})
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"first page",
+			"?limit=2",
+			http.StatusOK,
+			`{"syncs":[{"uuid":"c0b6ff0e-f3e4-4f2e-a4a4-2f9ac1d54b8d","status":"incomplete","startedAt":"2023-10-01T14:00:00Z","numTapes":0,"numWarnings":1},{"uuid":"8e1f8d8e-0d0a-4b5d-8f5f-3a4b5e6f7a8b","status":"failed","startedAt":"2023-10-01T13:00:00Z","finishedAt":"2023-10-01T13:01:00Z","error":"spreadsheet is unavailable","numTapes":0,"numWarnings":0}],"nextCursor":"` + secondPageCursor + `"}`,
+		},
+		{
+			"last page",
+			"?limit=2&cursor=" + secondPageCursor,
+			http.StatusOK,
+			`{"syncs":[{"uuid":"1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d","status":"succeeded","startedAt":"2023-10-01T12:00:00Z","finishedAt":"2023-10-01T12:01:00Z","numTapes":42,"numWarnings":2}]}`,
+		},
+		{
+			"invalid limit",
+			"?limit=1000",
+			http.StatusBadRequest,
+			"'limit' must be an integer between 1 and 100",
+		},
+		{
+			"invalid cursor",
+			"?cursor=bogus",
+			http.StatusBadRequest,
+			"'cursor' is not valid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{q: q}
+			req := httptest.NewRequest(http.MethodGet, "/syncs"+tt.query, nil)
+			res := httptest.NewRecorder()
+			s.handleGetSyncs(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetSync(t *testing.T) {
+	startedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	q := &mockQueries{
+		syncs: []queries.TapesSync{
+			{
+				Uuid:       uuid.MustParse("1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"),
+				StartedAt:  startedAt,
+				FinishedAt: sql.NullTime{Valid: true, Time: startedAt.Add(time.Minute)},
+				NumTapes:   sql.NullInt32{Valid: true, Int32: 42},
+				Warnings:   sql.NullString{Valid: true, String: "Spreadsheet row 3: bad year\nImage file 0007_a.jpg: unrecognized filename format"},
+			},
+			{
+				Uuid:       uuid.MustParse("9d8c7b6a-5f4e-4d3c-8b2a-1f0e9d8c7b6a"),
+				StartedAt:  startedAt.Add(time.Hour),
+				FinishedAt: sql.NullTime{Valid: true, Time: startedAt.Add(time.Hour + time.Minute)},
+				NumTapes:   sql.NullInt32{Valid: true, Int32: 41},
+				Warnings:   sql.NullString{Valid: true, String: "Spreadsheet row 3: bad year\nTape 7 has no gallery images; ignoring it."},
+			},
+		},
+		warnings: map[uuid.UUID][]queries.GetSyncWarningsRow{
+			uuid.MustParse("9d8c7b6a-5f4e-4d3c-8b2a-1f0e9d8c7b6a"): {
+				{
+					Source:    "spreadsheet",
+					Kind:      "invalid_row",
+					TapeID:    sql.NullInt32{Valid: true, Int32: 3},
+					RowNumber: sql.NullInt32{Valid: true, Int32: 3},
+					Message:   "bad year",
+				},
+				{
+					Source:  "sync",
+					Kind:    "no_gallery_images",
+					TapeID:  sql.NullInt32{Valid: true, Int32: 7},
+					Message: "Tape 7 has no gallery images; ignoring it.",
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		uuid       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			"1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+			http.StatusOK,
+			`{"uuid":"1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d","status":"succeeded","startedAt":"2023-10-01T12:00:00Z","finishedAt":"2023-10-01T12:01:00Z","numTapes":42,"numWarnings":2,"warnings":[{"source":"spreadsheet","rowNumber":3,"message":"bad year"},{"source":"storage","filename":"0007_a.jpg","message":"unrecognized filename format"}]}`,
+		},
+		{
+			"structured warnings",
+			"9d8c7b6a-5f4e-4d3c-8b2a-1f0e9d8c7b6a",
+			http.StatusOK,
+			`{"uuid":"9d8c7b6a-5f4e-4d3c-8b2a-1f0e9d8c7b6a","status":"succeeded","startedAt":"2023-10-01T13:00:00Z","finishedAt":"2023-10-01T13:01:00Z","numTapes":41,"numWarnings":2,"warnings":[{"source":"spreadsheet","kind":"invalid_row","rowNumber":3,"tapeId":3,"message":"bad year"},{"source":"sync","kind":"no_gallery_images","tapeId":7,"message":"Tape 7 has no gallery images; ignoring it."}]}`,
+		},
+		{
+			"no such sync",
+			"6f0d1c8e-9b3a-4c2d-8e1f-7a6b5c4d3e2f",
+			http.StatusNotFound,
+			"no such sync",
+		},
+		{
+			"invalid UUID",
+			"latest",
+			http.StatusBadRequest,
+			"invalid sync UUID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{q: q}
+			req := httptest.NewRequest(http.MethodGet, "/syncs/"+tt.uuid, nil)
+			req = mux.SetURLVars(req, map[string]string{"uuid": tt.uuid})
+			res := httptest.NewRecorder()
+			s.handleGetSync(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetWarnedTapes(t *testing.T) {
+	lastWarnedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		query        string
+		wantStatus   int
+		wantBody     string
+		wantNumSyncs int32
+	}{
+		{
+			"default number of syncs",
+			"",
+			http.StatusOK,
+			`{"numSyncs":5,"tapes":[{"tapeId":7,"numSyncs":2,"lastWarnedAt":"2023-10-01T12:00:00Z","kinds":["missing_thumbnail","no_gallery_images"]}]}`,
+			5,
+		},
+		{
+			"explicit number of syncs",
+			"?syncs=10",
+			http.StatusOK,
+			`{"numSyncs":10,"tapes":[{"tapeId":7,"numSyncs":2,"lastWarnedAt":"2023-10-01T12:00:00Z","kinds":["missing_thumbnail","no_gallery_images"]}]}`,
+			10,
+		},
+		{
+			"invalid number of syncs",
+			"?syncs=0",
+			http.StatusBadRequest,
+			"'syncs' must be an integer between 1 and 100",
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &mockQueries{
+				warnedTapes: []queries.GetRecentlyWarnedTapesRow{
+					{TapeID: 7, NumSyncs: 2, LastWarnedAt: lastWarnedAt, Kinds: []string{"missing_thumbnail", "no_gallery_images"}},
+				},
+			}
+			s := &Server{q: q}
+			req := httptest.NewRequest(http.MethodGet, "/warned-tapes"+tt.query, nil)
+			res := httptest.NewRecorder()
+			s.handleGetWarnedTapes(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+			assert.Equal(t, tt.wantNumSyncs, q.numSyncs)
+		})
+	}
+}
+
+type mockQueries struct {
+	// syncs is the contents of tapes.sync, ordered by most recently started first
+	syncs []queries.TapesSync
+	// warnings is the contents of tapes.sync_warning, keyed by sync UUID
+	warnings map[uuid.UUID][]queries.GetSyncWarningsRow
+	// warnedTapes is the result of GetRecentlyWarnedTapes, regardless of numSyncs
+	warnedTapes []queries.GetRecentlyWarnedTapesRow
+	// numSyncs records the last value passed to GetRecentlyWarnedTapes
+	numSyncs int32
+}
+
+func (m *mockQueries) ApplySeries(ctx context.Context, arg queries.ApplySeriesParams) (int32, error) {
+	return 0, nil
+}
+
+func (m *mockQueries) GetSyncs(ctx context.Context, arg queries.GetSyncsParams) ([]queries.GetSyncsRow, error) {
+	start := 0
+	if arg.AfterUuid.Valid {
+		for i, sync := range m.syncs {
+			if sync.Uuid == arg.AfterUuid.UUID {
+				start = i + 1
+			}
+		}
+	}
+	rows := make([]queries.GetSyncsRow, 0)
+	for i := start; i < len(m.syncs) && len(rows) < int(arg.PageSize); i++ {
+		sync := m.syncs[i]
+		numWarnings := len(m.warnings[sync.Uuid])
+		if numWarnings == 0 {
+			numWarnings = len(parseWarnings(sync.Warnings.String))
+		}
+		rows = append(rows, queries.GetSyncsRow{
+			Uuid:        sync.Uuid,
+			StartedAt:   sync.StartedAt,
+			FinishedAt:  sync.FinishedAt,
+			Error:       sync.Error,
+			NumTapes:    sync.NumTapes,
+			NumWarnings: int32(numWarnings),
+		})
+	}
+	return rows, nil
+}
+
+func (m *mockQueries) GetSync(ctx context.Context, argUuid uuid.UUID) (queries.GetSyncRow, error) {
+	for _, sync := range m.syncs {
+		if sync.Uuid == argUuid {
+			return queries.GetSyncRow{
+				Uuid:       sync.Uuid,
+				StartedAt:  sync.StartedAt,
+				FinishedAt: sync.FinishedAt,
+				Error:      sync.Error,
+				NumTapes:   sync.NumTapes,
+				Warnings:   sync.Warnings,
+			}, nil
+		}
+	}
+	return queries.GetSyncRow{}, sql.ErrNoRows
+}
+
+func (m *mockQueries) GetSyncWarnings(ctx context.Context, syncUuid uuid.UUID) ([]queries.GetSyncWarningsRow, error) {
+	return m.warnings[syncUuid], nil
+}
+
+func (m *mockQueries) GetRecentlyWarnedTapes(ctx context.Context, numSyncs int32) ([]queries.GetRecentlyWarnedTapesRow, error) {
+	m.numSyncs = numSyncs
+	return m.warnedTapes, nil
+}
+
+var _ Queries = (*mockQueries)(nil)
+
+type mockSyncer struct {
+	syncUuid uuid.UUID
+	err      error
+}
+
+func (m *mockSyncer) Start(ctx context.Context) (uuid.UUID, error) {
+	if m.err != nil {
+		return uuid.Nil, m.err
+	}
+	return m.syncUuid, nil
+}
+
+var _ Syncer = (*mockSyncer)(nil)
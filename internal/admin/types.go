@@ -0,0 +1,77 @@
+package admin
+
+import "time"
+
+type SyncStarted struct {
+	Uuid string `json:"uuid"`
+}
+
+type SyncHistory struct {
+	Syncs      []SyncSummary `json:"syncs"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// SyncSummary describes a single run of cmd/sync, as recorded in tapes.sync
+type SyncSummary struct {
+	Uuid        string     `json:"uuid"`
+	Status      SyncStatus `json:"status"`
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	NumTapes    int        `json:"numTapes"`
+	NumWarnings int        `json:"numWarnings"`
+}
+
+type SyncStatus string
+
+const (
+	// SyncStatusIncomplete indicates that a sync has not finished: it's either still in
+	// progress or it was abandoned without recording its results
+	SyncStatusIncomplete SyncStatus = "incomplete"
+	SyncStatusSucceeded  SyncStatus = "succeeded"
+	SyncStatusFailed     SyncStatus = "failed"
+)
+
+type SyncDetails struct {
+	SyncSummary
+	Warnings []SyncWarning `json:"warnings"`
+}
+
+// SyncWarning is a single non-fatal problem that was encountered during a sync,
+// typically resulting in a tape or image being excluded from the catalog
+type SyncWarning struct {
+	Source    SyncWarningSource `json:"source"`
+	Kind      string            `json:"kind,omitempty"`
+	RowNumber int               `json:"rowNumber,omitempty"`
+	Filename  string            `json:"filename,omitempty"`
+	TapeId    int               `json:"tapeId,omitempty"`
+	Message   string            `json:"message"`
+}
+
+type SyncWarningSource string
+
+const (
+	// SyncWarningSourceSpreadsheet indicates a problem with a row in the inventory
+	// spreadsheet, identified by RowNumber
+	SyncWarningSourceSpreadsheet SyncWarningSource = "spreadsheet"
+	// SyncWarningSourceStorage indicates a problem with an image file in the storage
+	// bucket, identified by Filename
+	SyncWarningSourceStorage SyncWarningSource = "storage"
+	// SyncWarningSourceSync indicates a problem that was detected while reconciling the
+	// spreadsheet with the storage bucket, or any other warning
+	SyncWarningSourceSync SyncWarningSource = "sync"
+)
+
+// WarnedTapeListing identifies the tapes that have been warned about in the most
+// recent successful syncs
+type WarnedTapeListing struct {
+	NumSyncs int          `json:"numSyncs"`
+	Tapes    []WarnedTape `json:"tapes"`
+}
+
+type WarnedTape struct {
+	TapeId       int       `json:"tapeId"`
+	NumSyncs     int       `json:"numSyncs"`
+	LastWarnedAt time.Time `json:"lastWarnedAt"`
+	Kinds        []string  `json:"kinds"`
+}
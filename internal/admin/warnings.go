@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Each warning line recorded in tapes.sync.warnings is formatted by cmd/sync in one of
+// these formats, identifying the source of the problem
+var (
+	spreadsheetWarningRegex = regexp.MustCompile(`^Spreadsheet row (\d+): (.*)$`)
+	storageWarningRegex     = regexp.MustCompile(`^Image file (.+?): (.*)$`)
+	tapeWarningRegex        = regexp.MustCompile(`^Tape (\d+) (.*)$`)
+)
+
+// parseWarnings splits the newline-delimited warnings string recorded for a sync into
+// structured warnings: lines that aren't in a recognized format are reported verbatim
+func parseWarnings(s string) []SyncWarning {
+	warnings := make([]SyncWarning, 0)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			warnings = append(warnings, parseWarning(line))
+		}
+	}
+	return warnings
+}
+
+// parseWarning parses a single warning line as formatted by cmd/sync
+func parseWarning(line string) SyncWarning {
+	if m := spreadsheetWarningRegex.FindStringSubmatch(line); m != nil {
+		if rowNumber, err := strconv.Atoi(m[1]); err == nil {
+			return SyncWarning{
+				Source:    SyncWarningSourceSpreadsheet,
+				RowNumber: rowNumber,
+				Message:   m[2],
+			}
+		}
+	}
+	if m := storageWarningRegex.FindStringSubmatch(line); m != nil {
+		return SyncWarning{
+			Source:   SyncWarningSourceStorage,
+			Filename: m[1],
+			Message:  m[2],
+		}
+	}
+	if m := tapeWarningRegex.FindStringSubmatch(line); m != nil {
+		if tapeId, err := strconv.Atoi(m[1]); err == nil {
+			return SyncWarning{
+				Source:  SyncWarningSourceSync,
+				TapeId:  tapeId,
+				Message: line,
+			}
+		}
+	}
+	return SyncWarning{
+		Source:  SyncWarningSourceSync,
+		Message: line,
+	}
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []SyncWarning
+	}{
+		{
+			"no warnings",
+			"",
+			[]SyncWarning{},
+		},
+		{
+			"all recognized formats",
+			"Spreadsheet row 12: tape ID is not a valid integer\n" +
+				"Image file 0044_a.jpg: tape 44 has gallery image(s) but no accompanying thumbnail image\n" +
+				"Tape 51 has no gallery images; ignoring it.",
+			[]SyncWarning{
+				{Source: SyncWarningSourceSpreadsheet, RowNumber: 12, Message: "tape ID is not a valid integer"},
+				{Source: SyncWarningSourceStorage, Filename: "0044_a.jpg", Message: "tape 44 has gallery image(s) but no accompanying thumbnail image"},
+				{Source: SyncWarningSourceSync, TapeId: 51, Message: "Tape 51 has no gallery images; ignoring it."},
+			},
+		},
+		{
+			"unrecognized lines are reported verbatim",
+			"Spreadsheet contains no valid tapes; not retiring any existing tapes.\n\n",
+			[]SyncWarning{
+				{Source: SyncWarningSourceSync, Message: "Spreadsheet contains no valid tapes; not retiring any existing tapes."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseWarnings(tt.s)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleGetContributors(res http.ResponseWriter, req *http.Request) {
+	rows, err := s.q.GetContributors(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	userIds := make([]string, 0, len(rows))
+	for _, row := range rows {
+		userIds = append(userIds, row.ContributorID)
+	}
+	if len(userIds) > 0 {
+		if err := s.lookup.Resolve(req.Context(), userIds); err != nil {
+			fmt.Printf("Error resolving contributor usernames: %v\n", err)
+		}
+	}
+
+	contributors := make([]Contributor, 0, len(rows))
+	for _, row := range rows {
+		contributors = append(contributors, Contributor{
+			TwitchUserId:       row.ContributorID,
+			Name:               s.lookup.GetDisplayName(row.ContributorID),
+			NumTapes:           int(row.NumTapes),
+			FirstContributedAt: row.FirstContributedAt,
+			LastContributedAt:  row.LastContributedAt,
+		})
+	}
+
+	result := ContributorListing{
+		Contributors: contributors,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetContributor(res http.ResponseWriter, req *http.Request) {
+	twitchUserId, ok := mux.Vars(req)["twitchUserId"]
+	if !ok || twitchUserId == "" {
+		http.Error(res, "failed to parse 'twitchUserId' from URL", http.StatusInternalServerError)
+		return
+	}
+
+	row, err := s.q.GetContributor(req.Context(), twitchUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(res, "no such contributor", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get all tapes sent in by this contributor, in the order they were added
+	tapeRows, err := s.q.GetTapes(req.Context(), queries.GetTapesParams{
+		ContributorID: sql.NullString{Valid: true, String: twitchUserId},
+		SortBy:        "added",
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.lookup.Resolve(req.Context(), []string{twitchUserId}); err != nil {
+		fmt.Printf("Error resolving contributor username: %v\n", err)
+	}
+
+	items := make([]Item, 0, len(tapeRows))
+	for _, tapeRow := range tapeRows {
+		item, err := s.newItem(queries.GetTapeRow(tapeRow))
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items = append(items, item)
+	}
+
+	result := ContributorDetails{
+		Contributor: Contributor{
+			TwitchUserId:       row.ContributorID,
+			Name:               s.lookup.GetDisplayName(row.ContributorID),
+			NumTapes:           int(row.NumTapes),
+			FirstContributedAt: row.FirstContributedAt,
+			LastContributedAt:  row.LastContributedAt,
+		},
+		ImageHostUrl: s.imageHostUrl,
+		Items:        items,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
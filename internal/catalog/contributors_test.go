@@ -0,0 +1,136 @@
+package catalog
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleGetContributors(t *testing.T) {
+	lookup := mockLookup{
+		"1234": "JoeBob",
+		"5678": "BigBilly",
+	}
+	tests := []struct {
+		name       string
+		q          *mockQueries
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			&mockQueries{
+				contributors: []queries.GetContributorsRow{
+					{
+						ContributorID:      "1234",
+						NumTapes:           2,
+						FirstContributedAt: time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC),
+						LastContributedAt:  time.Date(2023, 10, 15, 12, 0, 0, 0, time.UTC),
+					},
+					{
+						ContributorID:      "5678",
+						NumTapes:           1,
+						FirstContributedAt: time.Date(2023, 9, 20, 12, 0, 0, 0, time.UTC),
+						LastContributedAt:  time.Date(2023, 9, 20, 12, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+			http.StatusOK,
+			`{"contributors":[{"twitchUserId":"1234","name":"JoeBob","numTapes":2,"firstContributedAt":"2023-09-01T12:00:00Z","lastContributedAt":"2023-10-15T12:00:00Z"},{"twitchUserId":"5678","name":"BigBilly","numTapes":1,"firstContributedAt":"2023-09-20T12:00:00Z","lastContributedAt":"2023-09-20T12:00:00Z"}]}`,
+		},
+		{
+			"no contributors",
+			&mockQueries{},
+			http.StatusOK,
+			`{"contributors":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            tt.q,
+				lookup:       lookup,
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/contributors", nil)
+			res := httptest.NewRecorder()
+			s.handleGetContributors(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetContributor(t *testing.T) {
+	lookup := mockLookup{
+		"1234": "JoeBob",
+	}
+	images := encodeTapeImages(t, []db.TapeImage{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}})
+	q := &mockQueries{
+		rows: []queries.GetTapesRow{
+			{ID: 1, Title: "Tape one", ContributorID: sql.NullString{Valid: true, String: "1234"}, Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{}},
+			{ID: 2, Title: "Tape two", Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{}},
+		},
+		contributors: []queries.GetContributorsRow{
+			{
+				ContributorID:      "1234",
+				NumTapes:           1,
+				FirstContributedAt: time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC),
+				LastContributedAt:  time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	tests := []struct {
+		name         string
+		twitchUserId string
+		wantStatus   int
+		wantBody     string
+	}{
+		{
+			"normal usage",
+			"1234",
+			http.StatusOK,
+			`{"twitchUserId":"1234","name":"JoeBob","numTapes":1,"firstContributedAt":"2023-09-01T12:00:00Z","lastContributedAt":"2023-09-01T12:00:00Z","imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":0,"runtime":0,"contributor":"JoeBob","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":[]}]}`,
+		},
+		{
+			"user with no tapes is a 404 error",
+			"5678",
+			http.StatusNotFound,
+			"no such contributor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            q,
+				lookup:       lookup,
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/contributors/"+tt.twitchUserId, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"twitchUserId": tt.twitchUserId,
+			})
+			res := httptest.NewRecorder()
+			s.handleGetContributor(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
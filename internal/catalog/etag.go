@@ -0,0 +1,78 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// handleConditionalGet is middleware that tags every catalog response with an ETag
+// that changes whenever the underlying catalog data changes, responding with a 304 if
+// the client already has the current version of the requested resource
+func (s *Server) handleConditionalGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// If we can't determine the current revision, just serve the request uncached
+		row, err := s.q.GetCatalogRevision(req.Context())
+		if err != nil {
+			fmt.Printf("Error getting catalog revision: %v\n", err)
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		// Clients may cache responses, but they must revalidate them on every use
+		etag := computeETag(row.Revision, row.LatestSyncUuid, req.URL.RequestURI())
+		res.Header().Set("ETag", etag)
+		res.Header().Set("Cache-Control", "public, no-cache")
+		if etagMatches(req.Header.Get("If-None-Match"), etag) {
+			res.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		// Record the revision for which we've issued an ETag, so that the response can
+		// be served from a snapshot only if it matches that revision
+		ctx := context.WithValue(req.Context(), requestRevisionKey, row.Revision)
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+type contextKey string
+
+// requestRevisionKey is the context key under which handleConditionalGet stores the
+// catalog revision that was current at the start of the request
+const requestRevisionKey contextKey = "revision"
+
+// getRequestRevision returns the catalog revision that was recorded for the current
+// request by handleConditionalGet, if any
+func getRequestRevision(ctx context.Context) (int64, bool) {
+	revision, ok := ctx.Value(requestRevisionKey).(int64)
+	return revision, ok
+}
+
+// computeETag returns a strong ETag value that uniquely identifies the response to a
+// request for the given URI, given the current revision of the catalog data and the
+// most recent successful sync
+func computeETag(revision int64, latestSyncUuid uuid.UUID, requestUri string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s", revision, latestSyncUuid, requestUri)
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// etagMatches returns true if the value of an If-None-Match header matches the given
+// ETag, using the weak comparison required for If-None-Match
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
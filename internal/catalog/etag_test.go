@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleConditionalGet(t *testing.T) {
+	syncUuid := uuid.MustParse("5c3c7a36-2cb8-4f5a-8a0e-5e3dcd7b0d1e")
+	q := &mockQueries{
+		revision: queries.GetCatalogRevisionRow{Revision: 10, LatestSyncUuid: syncUuid},
+	}
+	s := &Server{q: q}
+	numCalls := 0
+	handler := s.handleConditionalGet(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		numCalls++
+		res.Write([]byte("ok"))
+	}))
+	get := func(uri string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	// An initial request should be served in full, with caching headers
+	res := get("/catalog", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "ok", res.Body.String())
+	assert.Equal(t, "public, no-cache", res.Header().Get("Cache-Control"))
+	etag := res.Header().Get("ETag")
+	assert.Equal(t, computeETag(10, syncUuid, "/catalog"), etag)
+	assert.Equal(t, 1, numCalls)
+
+	// Revalidating with the same ETag should result in a 304
+	res = get("/catalog", etag)
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+	assert.Equal(t, etag, res.Header().Get("ETag"))
+	assert.Equal(t, 1, numCalls)
+	res = get("/catalog", `"something-else", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Equal(t, 1, numCalls)
+
+	// Different URLs should have different ETags
+	res = get("/catalog/13", etag)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotEqual(t, etag, res.Header().Get("ETag"))
+	assert.Equal(t, 2, numCalls)
+
+	// Once the catalog changes, the old ETag should no longer match
+	q.revision.Revision = 11
+	res = get("/catalog", etag)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.NotEqual(t, etag, res.Header().Get("ETag"))
+	assert.Equal(t, 3, numCalls)
+
+	// If we can't get the current revision, the request should be served uncached
+	q.err = fmt.Errorf("mock error")
+	res = get("/catalog", etag)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Empty(t, res.Header().Get("ETag"))
+	assert.Equal(t, 4, numCalls)
+}
+
+func Test_etagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{``, false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"def"`, false},
+		{`"def", "abc"`, true},
+		{`*`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ifNoneMatch, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatches(tt.ifNoneMatch, `"abc"`))
+		})
+	}
+}
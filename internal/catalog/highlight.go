@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenizeQuery splits a search query into a list of lowercase terms, ignoring any
+// punctuation
+func tokenizeQuery(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// highlight splits the given text into fragments, flagging each word that approximately
+// matches one of the given search terms: returns nil if no words match
+func highlight(text string, terms []string) []Fragment {
+	fragments := make([]Fragment, 0)
+	numMatches := 0
+
+	// Walk through the text, alternating between runs of word characters and runs of
+	// non-word characters, and check each word against our search terms
+	runes := []rune(text)
+	start := 0
+	for start < len(runes) {
+		end := start
+		inWord := isWordRune(runes[start])
+		for end < len(runes) && isWordRune(runes[end]) == inWord {
+			end++
+		}
+		s := string(runes[start:end])
+		matched := inWord && matchesAnyTerm(strings.ToLower(s), terms)
+		if matched {
+			numMatches++
+		}
+
+		// Merge adjacent fragments that share the same match state
+		if len(fragments) > 0 && fragments[len(fragments)-1].Matched == matched {
+			fragments[len(fragments)-1].Text += s
+		} else {
+			fragments = append(fragments, Fragment{Text: s, Matched: matched})
+		}
+		start = end
+	}
+
+	if numMatches == 0 {
+		return nil
+	}
+	return fragments
+}
+
+// matchesAnyTerm returns true if the given lowercase word is an exact match, a prefix
+// match, or a near-miss (i.e. a likely typo) for any of the given search terms
+func matchesAnyTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if word == term {
+			return true
+		}
+		if len(term) >= 3 && strings.HasPrefix(word, term) {
+			return true
+		}
+		if maxDistance := maxTypoDistance(term); maxDistance > 0 && levenshtein(word, term) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// maxTypoDistance returns the number of single-character edits that we'll tolerate
+// when matching a word against a search term of the given length
+func maxTypoDistance(term string) int {
+	n := len([]rune(term))
+	if n < 4 {
+		return 0
+	}
+	if n < 8 {
+		return 1
+	}
+	return 2
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
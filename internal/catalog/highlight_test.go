@@ -0,0 +1,113 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tokenizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{}},
+		{"Jazzercise", []string{"jazzercise"}},
+		{"  Arts & Crafts!  ", []string{"arts", "crafts"}},
+		{"sesame-street 1991", []string{"sesame", "street", "1991"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := tokenizeQuery(tt.query)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_highlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  []Fragment
+	}{
+		{
+			"no match returns nil",
+			"Richard Simmons: Sweatin' to the Oldies",
+			[]string{"jazzercise"},
+			nil,
+		},
+		{
+			"exact match is highlighted, case-insensitively",
+			"Richard Simmons: Sweatin' to the Oldies",
+			[]string{"oldies"},
+			[]Fragment{
+				{Text: "Richard Simmons: Sweatin' to the "},
+				{Text: "Oldies", Matched: true},
+			},
+		},
+		{
+			"prefix match is highlighted",
+			"Jazzercise Workout",
+			[]string{"jazz"},
+			[]Fragment{
+				{Text: "Jazzercise", Matched: true},
+				{Text: " Workout"},
+			},
+		},
+		{
+			"near-miss typo is highlighted",
+			"Richard Simmons",
+			[]string{"simmonds"},
+			[]Fragment{
+				{Text: "Richard "},
+				{Text: "Simmons", Matched: true},
+			},
+		},
+		{
+			"short terms must match exactly",
+			"The Cat in the Hat",
+			[]string{"hot"},
+			nil,
+		},
+		{
+			"multiple terms are highlighted",
+			"Sweatin' to the Oldies",
+			[]string{"sweatin", "oldies"},
+			[]Fragment{
+				{Text: "Sweatin", Matched: true},
+				{Text: "' to the "},
+				{Text: "Oldies", Matched: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlight(tt.text, tt.terms)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"simmons", "simmonds", 1},
+		{"jazzercise", "jazzercise", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, levenshtein(tt.a, tt.b))
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package catalog
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/pagination"
+)
+
+// maxPageSize is the largest value that may be supplied for the 'limit' parameter
+const maxPageSize = 500
+
+// validSortValues lists the values that may be supplied for the 'sort' parameter
+var validSortValues = []string{"id", "title", "year", "runtime", "favorites", "added"}
+
+// listingParams describes how a listing of tapes should be filtered, sorted, and
+// paginated, as requested via the query string of a GET /catalog request
+type listingParams struct {
+	// filter holds the query parameters that should be passed to GetTapes, excluding
+	// pagination
+	filter queries.GetTapesParams
+	// limit is the maximum number of items to return in a single page of results, or 0
+	// to return all results
+	limit int
+	// after identifies the last item on the previous page of results, as decoded from
+	// an opaque cursor value, or nil to start from the first page
+	after *listingCursor
+}
+
+// listingCursor identifies a position within a paginated listing by the value of the
+// sort key and the ID of the last tape on a page: the next page begins with whichever
+// tape follows that position, so inserting or removing tapes between requests doesn't
+// cause subsequent pages to skip or repeat any results
+type listingCursor struct {
+	// SortBy and Descending record the sort order of the listing, since the cursor is
+	// meaningless if used to resume a listing sorted by a different key
+	SortBy     string `json:"sort"`
+	Descending bool   `json:"desc,omitempty"`
+	// Text holds the tape's title, if sorting by title
+	Text *string `json:"text,omitempty"`
+	// Int holds the value of the tape's year, runtime, favorite count, or series index
+	// if sorting by one of those, or nil if that value is NULL
+	Int *int64 `json:"int,omitempty"`
+	// Time holds the time at which the tape was added, if sorting by that
+	Time *time.Time `json:"time,omitempty"`
+	// TapeId is the ID of the tape, which breaks ties between tapes with equal sort keys
+	TapeId int `json:"id"`
+}
+
+// parseListingParams parses a listingParams struct from the query string of a request,
+// returning an error if any parameter is invalid
+func parseListingParams(values url.Values) (*listingParams, error) {
+	p := &listingParams{
+		filter: queries.GetTapesParams{
+			SortBy: "id",
+		},
+	}
+
+	// String filters are applied only if non-empty
+	p.filter.Tag = parseOptionalString(values, "tag")
+	p.filter.SeriesName = parseOptionalString(values, "series")
+	p.filter.ContributorID = parseOptionalString(values, "contributor")
+
+	// Integer filters must be positive if supplied
+	var err error
+	if p.filter.YearMin, err = parseOptionalInt(values, "yearMin"); err != nil {
+		return nil, err
+	}
+	if p.filter.YearMax, err = parseOptionalInt(values, "yearMax"); err != nil {
+		return nil, err
+	}
+	if p.filter.RuntimeMin, err = parseOptionalInt(values, "runtimeMin"); err != nil {
+		return nil, err
+	}
+	if p.filter.RuntimeMax, err = parseOptionalInt(values, "runtimeMax"); err != nil {
+		return nil, err
+	}
+
+	// Sort order defaults to ascending by ID
+	if sortBy := values.Get("sort"); sortBy != "" {
+		if !isValidSortValue(sortBy) {
+			return nil, fmt.Errorf("'sort' must be one of: %s", strings.Join(validSortValues, ", "))
+		}
+		p.filter.SortBy = sortBy
+	}
+	switch values.Get("order") {
+	case "", "asc":
+		p.filter.Descending = false
+	case "desc":
+		p.filter.Descending = true
+	default:
+		return nil, fmt.Errorf("'order' must be one of: asc, desc")
+	}
+
+	// Pagination is opt-in: if no limit is specified, all results are returned
+	if p.limit, err = pagination.ParseLimit(values, 0, maxPageSize); err != nil {
+		return nil, err
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		var after listingCursor
+		if err := pagination.DecodeCursor(cursor, &after); err != nil || !after.isValidFor(p.filter) {
+			return nil, fmt.Errorf("'cursor' is not valid")
+		}
+		p.after = &after
+	}
+	return p, nil
+}
+
+// toGetTapesParams returns the full set of params required to request the desired
+// page of results from the database: if a limit is set, we request one additional row
+// so we can tell whether there are more results beyond the current page
+func (p *listingParams) toGetTapesParams() queries.GetTapesParams {
+	params := p.filter
+	if p.after != nil {
+		params.AfterID = sql.NullInt32{Valid: true, Int32: int32(p.after.TapeId)}
+		if p.after.Text != nil {
+			params.AfterText = sql.NullString{Valid: true, String: *p.after.Text}
+		}
+		if p.after.Int != nil {
+			params.AfterInt = sql.NullInt64{Valid: true, Int64: *p.after.Int}
+		}
+		if p.after.Time != nil {
+			params.AfterTime = sql.NullTime{Valid: true, Time: *p.after.Time}
+		}
+	}
+	if p.limit > 0 {
+		params.PageSize = sql.NullInt32{Valid: true, Int32: int32(p.limit + 1)}
+	}
+	return params
+}
+
+// newListingCursor returns a cursor that identifies the position of the given tape
+// within a listing that's sorted according to filter
+func newListingCursor(filter queries.GetTapesParams, row queries.GetTapesRow) *listingCursor {
+	c := &listingCursor{
+		SortBy:     filter.SortBy,
+		Descending: filter.Descending,
+		TapeId:     int(row.ID),
+	}
+	switch filter.SortBy {
+	case "title":
+		c.Text = &row.Title
+	case "year":
+		c.Int = nullInt32ToInt64Ptr(row.Year)
+	case "runtime":
+		c.Int = nullInt32ToInt64Ptr(row.Runtime)
+	case "favorites":
+		c.Int = &row.NumFavorites
+	case "series":
+		c.Int = nullInt32ToInt64Ptr(row.SeriesIndex)
+	case "added":
+		c.Time = &row.CreatedAt
+	}
+	return c
+}
+
+// isValidFor returns true if c identifies a tape within a listing that's sorted
+// according to filter
+func (c *listingCursor) isValidFor(filter queries.GetTapesParams) bool {
+	return c.SortBy == filter.SortBy && c.Descending == filter.Descending && c.TapeId > 0
+}
+
+func parseOptionalString(values url.Values, name string) sql.NullString {
+	value := values.Get(name)
+	if value == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{Valid: true, String: value}
+}
+
+func parseOptionalInt(values url.Values, name string) (sql.NullInt32, error) {
+	strValue := values.Get(name)
+	if strValue == "" {
+		return sql.NullInt32{}, nil
+	}
+	value, err := strconv.Atoi(strValue)
+	if err != nil || value <= 0 {
+		return sql.NullInt32{}, fmt.Errorf("'%s' must be a positive integer", name)
+	}
+	return sql.NullInt32{Valid: true, Int32: int32(value)}, nil
+}
+
+func isValidSortValue(s string) bool {
+	for _, value := range validSortValues {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+func nullInt32ToInt64Ptr(value sql.NullInt32) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	v := int64(value.Int32)
+	return &v
+}
@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseListingParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+		want    *listingParams
+	}{
+		{
+			"empty query string lists all tapes by ID",
+			"",
+			"",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "id"},
+			},
+		},
+		{
+			"filters are parsed",
+			"tag=fitness&series=Jazzercise&contributor=1234&yearMin=1980&yearMax=1989&runtimeMin=30&runtimeMax=90",
+			"",
+			&listingParams{
+				filter: queries.GetTapesParams{
+					Tag:           sql.NullString{Valid: true, String: "fitness"},
+					SeriesName:    sql.NullString{Valid: true, String: "Jazzercise"},
+					ContributorID: sql.NullString{Valid: true, String: "1234"},
+					YearMin:       sql.NullInt32{Valid: true, Int32: 1980},
+					YearMax:       sql.NullInt32{Valid: true, Int32: 1989},
+					RuntimeMin:    sql.NullInt32{Valid: true, Int32: 30},
+					RuntimeMax:    sql.NullInt32{Valid: true, Int32: 90},
+					SortBy:        "id",
+				},
+			},
+		},
+		{
+			"sort and order are parsed",
+			"sort=favorites&order=desc",
+			"",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "favorites", Descending: true},
+			},
+		},
+		{
+			"limit and cursor are parsed",
+			"limit=20&cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 40}),
+			"",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "id"},
+				limit:  20,
+				after:  &listingCursor{SortBy: "id", TapeId: 40},
+			},
+		},
+		{
+			"non-integer year is rejected",
+			"yearMin=eighties",
+			"'yearMin' must be a positive integer",
+			nil,
+		},
+		{
+			"negative runtime is rejected",
+			"runtimeMax=-5",
+			"'runtimeMax' must be a positive integer",
+			nil,
+		},
+		{
+			"invalid sort is rejected",
+			"sort=color",
+			"'sort' must be one of: id, title, year, runtime, favorites, added",
+			nil,
+		},
+		{
+			"invalid order is rejected",
+			"order=sideways",
+			"'order' must be one of: asc, desc",
+			nil,
+		},
+		{
+			"limit must be positive",
+			"limit=0",
+			"'limit' must be an integer between 1 and 500",
+			nil,
+		},
+		{
+			"limit is capped",
+			"limit=501",
+			"'limit' must be an integer between 1 and 500",
+			nil,
+		},
+		{
+			"cursor must be well-formed",
+			"cursor=not-a-cursor",
+			"'cursor' is not valid",
+			nil,
+		},
+		{
+			"cursor must identify a tape",
+			"cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "id"}),
+			"'cursor' is not valid",
+			nil,
+		},
+		{
+			"cursor must match sort order",
+			"sort=title&cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "year", Int: int64Ptr(1991), TapeId: 40}),
+			"'cursor' is not valid",
+			nil,
+		},
+		{
+			"cursor must match sort direction",
+			"sort=title&order=desc&cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "title", Text: stringPtr("Tape 40"), TapeId: 40}),
+			"'cursor' is not valid",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+			got, err := parseListingParams(values)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_listingParams_toGetTapesParams(t *testing.T) {
+	addedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		p    *listingParams
+		want queries.GetTapesParams
+	}{
+		{
+			"first page",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "title"},
+				limit:  10,
+			},
+			queries.GetTapesParams{
+				SortBy:   "title",
+				PageSize: sql.NullInt32{Valid: true, Int32: 11},
+			},
+		},
+		{
+			"subsequent page sorted by title",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "title"},
+				limit:  10,
+				after:  &listingCursor{SortBy: "title", Text: stringPtr("Jazzercise"), TapeId: 30},
+			},
+			queries.GetTapesParams{
+				SortBy:    "title",
+				AfterID:   sql.NullInt32{Valid: true, Int32: 30},
+				AfterText: sql.NullString{Valid: true, String: "Jazzercise"},
+				PageSize:  sql.NullInt32{Valid: true, Int32: 11},
+			},
+		},
+		{
+			"subsequent page sorted by year, after a tape with no year",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "year", Descending: true},
+				limit:  10,
+				after:  &listingCursor{SortBy: "year", Descending: true, TapeId: 30},
+			},
+			queries.GetTapesParams{
+				SortBy:     "year",
+				Descending: true,
+				AfterID:    sql.NullInt32{Valid: true, Int32: 30},
+				PageSize:   sql.NullInt32{Valid: true, Int32: 11},
+			},
+		},
+		{
+			"subsequent page sorted by time added",
+			&listingParams{
+				filter: queries.GetTapesParams{SortBy: "added"},
+				limit:  10,
+				after:  &listingCursor{SortBy: "added", Time: &addedAt, TapeId: 30},
+			},
+			queries.GetTapesParams{
+				SortBy:    "added",
+				AfterID:   sql.NullInt32{Valid: true, Int32: 30},
+				AfterTime: sql.NullTime{Valid: true, Time: addedAt},
+				PageSize:  sql.NullInt32{Valid: true, Int32: 11},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.p.toGetTapesParams())
+		})
+	}
+}
+
+func Test_newListingCursor(t *testing.T) {
+	addedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	row := queries.GetTapesRow{
+		ID:           30,
+		Title:        "Jazzercise",
+		Year:         sql.NullInt32{Valid: true, Int32: 1991},
+		CreatedAt:    addedAt,
+		NumFavorites: 4,
+	}
+	assert.Equal(t, &listingCursor{SortBy: "id", TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "id"}, row))
+	assert.Equal(t, &listingCursor{SortBy: "title", Descending: true, Text: stringPtr("Jazzercise"), TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "title", Descending: true}, row))
+	assert.Equal(t, &listingCursor{SortBy: "year", Int: int64Ptr(1991), TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "year"}, row))
+	assert.Equal(t, &listingCursor{SortBy: "runtime", TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "runtime"}, row))
+	assert.Equal(t, &listingCursor{SortBy: "favorites", Int: int64Ptr(4), TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "favorites"}, row))
+	assert.Equal(t, &listingCursor{SortBy: "added", Time: &addedAt, TapeId: 30}, newListingCursor(queries.GetTapesParams{SortBy: "added"}, row))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/pagination"
+)
+
+// defaultSearchLimit is the number of search results returned if no limit is specified
+const defaultSearchLimit = 20
+
+// maxSearchLimit is the largest value that may be supplied for the 'limit' parameter
+// in a search request
+const maxSearchLimit = 100
+
+func (s *Server) handleSearch(res http.ResponseWriter, req *http.Request) {
+	// Parse the search query, which is required, and an optional result limit
+	query := strings.TrimSpace(req.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(res, "'q' is required", http.StatusBadRequest)
+		return
+	}
+	limit, err := pagination.ParseLimit(req.URL.Query(), defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get the best-matching tapes from the database, ranked by similarity
+	rows, err := s.q.SearchTapes(req.Context(), queries.SearchTapesParams{
+		Query:      query,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Resolve display names for any contributors to matching tapes
+	userIds := make([]string, 0)
+	for _, row := range rows {
+		if row.ContributorID.Valid {
+			userIds = append(userIds, row.ContributorID.String)
+		}
+	}
+	if len(userIds) > 0 {
+		if err := s.lookup.Resolve(req.Context(), userIds); err != nil {
+			fmt.Printf("Error resolving contributor usernames: %v\n", err)
+		}
+	}
+
+	// Build a result for each tape, noting which fields matched the query
+	terms := tokenizeQuery(query)
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		item, err := s.newItem(queries.GetTapeRow{
+			ID:            row.ID,
+			Title:         row.Title,
+			Year:          row.Year,
+			Runtime:       row.Runtime,
+			SeriesName:    row.SeriesName,
+			SeriesID:      row.SeriesID,
+			SeriesIndex:   row.SeriesIndex,
+			ContributorID: row.ContributorID,
+			NumFavorites:  row.NumFavorites,
+			Thumbnail:     row.Thumbnail,
+			Images:        row.Images,
+			Tags:          row.Tags,
+		})
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, SearchResult{
+			Item:       item,
+			Rank:       float64(row.Rank),
+			Highlights: getHighlights(&item, terms),
+		})
+	}
+
+	result := SearchResults{
+		ImageHostUrl: s.imageHostUrl,
+		Query:        query,
+		Results:      results,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getHighlights returns a Highlight for each searchable field of the given item that
+// contains at least one word matching the given search terms
+func getHighlights(item *Item, terms []string) []Highlight {
+	highlights := make([]Highlight, 0)
+	if fragments := highlight(item.Title, terms); fragments != nil {
+		highlights = append(highlights, Highlight{Field: "title", Fragments: fragments})
+	}
+	if fragments := highlight(item.SeriesName, terms); fragments != nil {
+		highlights = append(highlights, Highlight{Field: "series", Fragments: fragments})
+	}
+	for _, tag := range item.Tags {
+		if fragments := highlight(tag, terms); fragments != nil {
+			highlights = append(highlights, Highlight{Field: "tags", Fragments: fragments})
+		}
+	}
+	return highlights
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleSearch(t *testing.T) {
+	lookup := mockLookup{
+		"1234": "JoeBob",
+	}
+	rows := []queries.SearchTapesRow{
+		{
+			ID:            2,
+			Title:         "Sweatin' to the Oldies",
+			SeriesName:    "Richard Simmons",
+			ContributorID: sql.NullString{Valid: true, String: "1234"},
+			NumFavorites:  1,
+			Thumbnail:     encodeTapeThumbnail(t, nil),
+			Images:        encodeTapeImages(t, []db.TapeImage{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}}),
+			Tags:          []string{"fitness", "oldies"},
+			Rank:          0.75,
+		},
+	}
+	tests := []struct {
+		name       string
+		query      string
+		q          *mockQueries
+		wantStatus int
+		wantParams *queries.SearchTapesParams
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			"?q=oldies",
+			&mockQueries{searchRows: rows},
+			http.StatusOK,
+			&queries.SearchTapesParams{Query: "oldies", MaxResults: defaultSearchLimit},
+			`{"imageHost":"https://my-images.biz","query":"oldies","results":[{"id":2,"title":"Sweatin' to the Oldies","year":0,"runtime":0,"series":"Richard Simmons","contributor":"JoeBob","numFavorites":1,"images":[{"filename":"0002_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":["fitness","oldies"],"rank":0.75,"highlights":[{"field":"title","fragments":[{"text":"Sweatin' to the ","matched":false},{"text":"Oldies","matched":true}]},{"field":"tags","fragments":[{"text":"oldies","matched":true}]}]}]}`,
+		},
+		{
+			"no matches returns an empty list",
+			"?q=jazzercise&limit=5",
+			&mockQueries{},
+			http.StatusOK,
+			&queries.SearchTapesParams{Query: "jazzercise", MaxResults: 5},
+			`{"imageHost":"https://my-images.biz","query":"jazzercise","results":[]}`,
+		},
+		{
+			"missing query is a 400 error",
+			"?q=%20",
+			&mockQueries{},
+			http.StatusBadRequest,
+			nil,
+			"'q' is required",
+		},
+		{
+			"invalid limit is a 400 error",
+			"?q=oldies&limit=1000",
+			&mockQueries{},
+			http.StatusBadRequest,
+			nil,
+			"'limit' must be an integer between 1 and 100",
+		},
+		{
+			"non-integer limit is a 400 error",
+			"?q=oldies&limit=some",
+			&mockQueries{},
+			http.StatusBadRequest,
+			nil,
+			"'limit' must be an integer between 1 and 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            tt.q,
+				lookup:       lookup,
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/search"+tt.query, nil)
+			res := httptest.NewRecorder()
+			s.handleSearch(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantParams, tt.q.searchTapesParams)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleGetSeriesListing(res http.ResponseWriter, req *http.Request) {
+	rows, err := s.q.GetSeriesList(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	series := make([]Series, 0, len(rows))
+	for _, row := range rows {
+		series = append(series, Series{
+			Id:          int(row.ID),
+			Name:        row.Name,
+			Description: row.Description,
+			NumTapes:    int(row.NumTapes),
+		})
+	}
+
+	result := SeriesListing{
+		Series: series,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetSeriesDetails(res http.ResponseWriter, req *http.Request) {
+	seriesIdStr, ok := mux.Vars(req)["id"]
+	if !ok || seriesIdStr == "" {
+		http.Error(res, "failed to parse 'id' from URL", http.StatusInternalServerError)
+		return
+	}
+	seriesId, err := strconv.Atoi(seriesIdStr)
+	if err != nil {
+		http.Error(res, "series ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	row, err := s.q.GetSeries(req.Context(), int32(seriesId))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(res, "no such series", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get all tapes in the series, in order
+	tapeRows, err := s.q.GetTapes(req.Context(), queries.GetTapesParams{
+		SeriesID: sql.NullInt32{Valid: true, Int32: row.ID},
+		SortBy:   "series",
+	})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	userIds := make([]string, 0)
+	for _, tapeRow := range tapeRows {
+		if tapeRow.ContributorID.Valid {
+			userIds = append(userIds, tapeRow.ContributorID.String)
+		}
+	}
+	if len(userIds) > 0 {
+		if err := s.lookup.Resolve(req.Context(), userIds); err != nil {
+			fmt.Printf("Error resolving contributor usernames: %v\n", err)
+		}
+	}
+
+	items := make([]Item, 0, len(tapeRows))
+	for _, tapeRow := range tapeRows {
+		item, err := s.newItem(queries.GetTapeRow(tapeRow))
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items = append(items, item)
+	}
+
+	result := SeriesDetails{
+		Series: Series{
+			Id:          int(row.ID),
+			Name:        row.Name,
+			Description: row.Description,
+			NumTapes:    len(items),
+		},
+		ImageHostUrl: s.imageHostUrl,
+		Items:        items,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
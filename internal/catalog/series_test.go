@@ -0,0 +1,126 @@
+package catalog
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleGetSeriesListing(t *testing.T) {
+	tests := []struct {
+		name       string
+		q          *mockQueries
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			&mockQueries{
+				rows: []queries.GetTapesRow{
+					{ID: 1, SeriesID: sql.NullInt32{Valid: true, Int32: 1}, SeriesIndex: sql.NullInt32{Valid: true, Int32: 1}},
+					{ID: 2, SeriesID: sql.NullInt32{Valid: true, Int32: 1}, SeriesIndex: sql.NullInt32{Valid: true, Int32: 2}},
+					{ID: 3, SeriesID: sql.NullInt32{Valid: true, Int32: 2}, SeriesIndex: sql.NullInt32{Valid: true, Int32: 1}},
+				},
+				series: []queries.GetSeriesRow{
+					{ID: 1, Name: "Jazzercise", Description: "Dance your way to fitness"},
+					{ID: 2, Name: "Richard Simmons"},
+					{ID: 3, Name: "Empty series"},
+				},
+			},
+			http.StatusOK,
+			`{"series":[{"id":1,"name":"Jazzercise","description":"Dance your way to fitness","numTapes":2},{"id":2,"name":"Richard Simmons","numTapes":1}]}`,
+		},
+		{
+			"no series",
+			&mockQueries{},
+			http.StatusOK,
+			`{"series":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            tt.q,
+				lookup:       mockLookup{},
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/series", nil)
+			res := httptest.NewRecorder()
+			s.handleGetSeriesListing(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetSeriesDetails(t *testing.T) {
+	images := encodeTapeImages(t, []db.TapeImage{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}})
+	q := &mockQueries{
+		rows: []queries.GetTapesRow{
+			{ID: 1, Title: "Part two", SeriesName: "Jazzercise", SeriesID: sql.NullInt32{Valid: true, Int32: 1}, SeriesIndex: sql.NullInt32{Valid: true, Int32: 2}, Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{}},
+			{ID: 2, Title: "Unrelated", Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{}},
+			{ID: 3, Title: "Part one", SeriesName: "Jazzercise", SeriesID: sql.NullInt32{Valid: true, Int32: 1}, SeriesIndex: sql.NullInt32{Valid: true, Int32: 1}, Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{}},
+		},
+		series: []queries.GetSeriesRow{
+			{ID: 1, Name: "Jazzercise", Description: "Dance your way to fitness"},
+		},
+	}
+	tests := []struct {
+		name       string
+		seriesId   string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			"1",
+			http.StatusOK,
+			`{"id":1,"name":"Jazzercise","description":"Dance your way to fitness","numTapes":2,"imageHost":"https://my-images.biz","items":[{"id":3,"title":"Part one","year":0,"runtime":0,"series":"Jazzercise","seriesId":1,"seriesIndex":1,"numFavorites":0,"images":[{"filename":"0003_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":[]},{"id":1,"title":"Part two","year":0,"runtime":0,"series":"Jazzercise","seriesId":1,"seriesIndex":2,"numFavorites":0,"images":[{"filename":"0001_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":[]}]}`,
+		},
+		{
+			"unknown series is a 404 error",
+			"2",
+			http.StatusNotFound,
+			"no such series",
+		},
+		{
+			"non-integer ID is a 400 error",
+			"jazzercise",
+			http.StatusBadRequest,
+			"series ID must be an integer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            q,
+				lookup:       mockLookup{},
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/series/"+tt.seriesId, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"id": tt.seriesId,
+			})
+			res := httptest.NewRecorder()
+			s.handleGetSeriesDetails(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
@@ -8,24 +8,37 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
 
 	"github.com/golden-vcr/tapes/gen/queries"
 	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/golden-vcr/tapes/internal/pagination"
 	"github.com/golden-vcr/tapes/internal/storage"
 	"github.com/golden-vcr/tapes/internal/users"
 	"github.com/gorilla/mux"
 )
 
 type Queries interface {
-	GetTapes(ctx context.Context) ([]queries.GetTapesRow, error)
+	GetTapes(ctx context.Context, arg queries.GetTapesParams) ([]queries.GetTapesRow, error)
 	GetTape(ctx context.Context, tapeID int32) (queries.GetTapeRow, error)
 	GetTapeContributorIds(ctx context.Context) ([]string, error)
+	SearchTapes(ctx context.Context, arg queries.SearchTapesParams) ([]queries.SearchTapesRow, error)
+	GetTagCounts(ctx context.Context) ([]queries.GetTagCountsRow, error)
+	GetTagCount(ctx context.Context, tagName string) (int64, error)
+	GetSeriesList(ctx context.Context) ([]queries.GetSeriesListRow, error)
+	GetSeries(ctx context.Context, seriesID int32) (queries.GetSeriesRow, error)
+	GetContributors(ctx context.Context) ([]queries.GetContributorsRow, error)
+	GetContributor(ctx context.Context, contributorID string) (queries.GetContributorRow, error)
+	GetCatalogRevision(ctx context.Context) (queries.GetCatalogRevisionRow, error)
 }
 
 type Server struct {
 	q            Queries
 	lookup       users.Lookup
 	imageHostUrl string
+
+	snapshotMu sync.RWMutex
+	snapshot   *snapshot
 }
 
 func NewServer(q Queries, lookup users.Lookup, imageHostUrl string) *Server {
@@ -37,79 +50,90 @@ func NewServer(q Queries, lookup users.Lookup, imageHostUrl string) *Server {
 }
 
 func (s *Server) RegisterRoutes(r *mux.Router) {
+	// Catalog data only changes when tapes are synced or users make changes that are
+	// reflected in the catalog, so clients can use conditional requests to avoid
+	// fetching the same data repeatedly
+	r.Use(s.handleConditionalGet)
+
 	for _, root := range []string{"", "/"} {
 		r.Path(root).Methods("GET").HandlerFunc(s.handleGetListing)
 	}
+	r.Path("/search").Methods("GET").HandlerFunc(s.handleSearch)
+	r.Path("/tags").Methods("GET").HandlerFunc(s.handleGetTags)
+	r.Path("/tags/{name}").Methods("GET").HandlerFunc(s.handleGetTag)
+	r.Path("/series").Methods("GET").HandlerFunc(s.handleGetSeriesListing)
+	r.Path("/series/{id}").Methods("GET").HandlerFunc(s.handleGetSeriesDetails)
+	r.Path("/contributors").Methods("GET").HandlerFunc(s.handleGetContributors)
+	r.Path("/contributors/{twitchUserId}").Methods("GET").HandlerFunc(s.handleGetContributor)
 	r.Path("/{id}").Methods("GET").HandlerFunc(s.handleGetDetails)
 }
 
 func (s *Server) handleGetListing(res http.ResponseWriter, req *http.Request) {
-	userIds, err := s.q.GetTapeContributorIds(req.Context())
+	// The default, unfiltered listing is requested far more often than anything else,
+	// so we serve it from a prebuilt snapshot if we have one that's up-to-date
+	if len(req.URL.Query()) == 0 {
+		if body, ok := s.getSnapshot(req.Context()); ok {
+			res.Write(body)
+			return
+		}
+	}
+
+	params, err := parseListingParams(req.URL.Query())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeListing(res, req, params)
+}
+
+// writeListing responds with a Listing of all tapes that match the given params
+func (s *Server) writeListing(res http.ResponseWriter, req *http.Request, params *listingParams) {
+	result, err := s.getListing(req.Context(), params)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	if err := s.lookup.Resolve(req.Context(), userIds); err != nil {
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getListing queries the database for all tapes that match the given params
+func (s *Server) getListing(ctx context.Context, params *listingParams) (*Listing, error) {
+	userIds, err := s.q.GetTapeContributorIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lookup.Resolve(ctx, userIds); err != nil {
 		fmt.Printf("Error resolving contributor usernames: %v\n", err)
 	}
 
-	rows, err := s.q.GetTapes(req.Context())
+	rows, err := s.q.GetTapes(ctx, params.toGetTapesParams())
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+
+	// If we got more rows than requested, there's at least one more page of results
+	nextCursor := ""
+	if params.limit > 0 && len(rows) > params.limit {
+		rows = rows[:params.limit]
+		nextCursor = pagination.EncodeCursor(newListingCursor(params.filter, rows[len(rows)-1]))
 	}
 
 	items := make([]Item, 0, len(rows))
 	for _, row := range rows {
-		images, err := db.ParseTapeImageArray(row.Images)
+		item, err := s.newItem(queries.GetTapeRow(row))
 		if err != nil {
-			http.Error(res, err.Error(), http.StatusInternalServerError)
-			return
+			return nil, err
 		}
-
-		galleryImages := make([]GalleryImage, 0, len(images))
-		for _, image := range images {
-			galleryImages = append(galleryImages, GalleryImage{
-				Filename: storage.GetImageFilename(int(row.ID), storage.ImageTypeGallery, int(image.Index)),
-				Width:    int(image.Width),
-				Height:   int(image.Height),
-				Color:    image.Color,
-				Rotated:  image.Rotated,
-			})
-		}
-		year := 0
-		if row.Year.Valid {
-			year = int(row.Year.Int32)
-		}
-		runtime := 0
-		if row.Runtime.Valid {
-			runtime = int(row.Runtime.Int32)
-		}
-		contributorName := ""
-		if row.ContributorID.Valid {
-			contributorName = s.lookup.GetDisplayName(row.ContributorID.String)
-		}
-		items = append(items, Item{
-			Id:                     int(row.ID),
-			Title:                  row.Title,
-			Year:                   year,
-			RuntimeInMinutes:       runtime,
-			ThumbnailImageFilename: storage.GetImageFilename(int(row.ID), storage.ImageTypeThumbnail, -1),
-			SeriesName:             row.SeriesName,
-			ContributorName:        contributorName,
-			NumFavorites:           int(row.NumFavorites),
-			Images:                 galleryImages,
-			Tags:                   row.Tags,
-		})
+		items = append(items, item)
 	}
 
-	result := Listing{
+	return &Listing{
 		ImageHostUrl: s.imageHostUrl,
 		Items:        items,
-	}
-	if err := json.NewEncoder(res).Encode(result); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
-	}
+		NextCursor:   nextCursor,
+	}, nil
 }
 
 func (s *Server) handleGetDetails(res http.ResponseWriter, req *http.Request) {
@@ -134,30 +158,82 @@ func (s *Server) handleGetDetails(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	contributorName := ""
 	if row.ContributorID.Valid {
 		if err := s.lookup.Resolve(req.Context(), []string{row.ContributorID.String}); err != nil {
 			fmt.Printf("Error resolving contributor username: %v\n", err)
 		}
-		contributorName = s.lookup.GetDisplayName(row.ContributorID.String)
 	}
 
-	images, err := db.ParseTapeImageArray(row.Images)
+	item, err := s.newItem(row)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := json.NewEncoder(res).Encode(item); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newItem converts a row of tape data from the database into an Item, assuming that
+// the tape's contributor (if any) has already been resolved via the user lookup
+func (s *Server) newItem(row queries.GetTapeRow) (Item, error) {
+	images, err := db.ParseTapeImageArray(row.Images)
+	if err != nil {
+		return Item{}, err
+	}
 
 	galleryImages := make([]GalleryImage, 0, len(images))
 	for _, image := range images {
+		variants := make([]GalleryImageVariant, 0, len(image.Variants))
+		for _, variant := range image.Variants {
+			variantFilename, err := storage.GetImageVariantFilename(int(row.ID), int(image.Index), storage.ImageVariant{
+				Format: storage.ImageFormat(variant.Format),
+				Width:  int(variant.Width),
+			})
+			if err != nil {
+				return Item{}, err
+			}
+			variants = append(variants, GalleryImageVariant{
+				Filename: variantFilename,
+				Format:   variant.Format,
+				Width:    int(variant.Width),
+			})
+		}
+		filename, err := storage.GetImageFilename(int(row.ID), storage.ImageTypeGallery, int(image.Index))
+		if err != nil {
+			return Item{}, err
+		}
 		galleryImages = append(galleryImages, GalleryImage{
-			Filename: storage.GetImageFilename(int(row.ID), storage.ImageTypeGallery, int(image.Index)),
+			Filename: filename,
 			Width:    int(image.Width),
 			Height:   int(image.Height),
 			Color:    image.Color,
 			Rotated:  image.Rotated,
+			Blurhash: image.Blurhash,
+			Variants: variants,
 		})
 	}
+
+	// Thumbnails are only reported once they've been recorded by a sync, so that
+	// clients can tell a missing thumbnail apart from one with known details
+	thumbnailDetails, err := db.ParseTapeThumbnail(row.Thumbnail)
+	if err != nil {
+		return Item{}, err
+	}
+	var thumbnail *ThumbnailImage
+	if thumbnailDetails != nil {
+		thumbnailFilename, err := storage.GetImageFilename(int(row.ID), storage.ImageTypeThumbnail, -1)
+		if err != nil {
+			return Item{}, err
+		}
+		thumbnail = &ThumbnailImage{
+			Filename: thumbnailFilename,
+			Width:    int(thumbnailDetails.Width),
+			Height:   int(thumbnailDetails.Height),
+			Color:    thumbnailDetails.Color,
+			Blurhash: thumbnailDetails.Blurhash,
+		}
+	}
 	year := 0
 	if row.Year.Valid {
 		year = int(row.Year.Int32)
@@ -166,19 +242,28 @@ func (s *Server) handleGetDetails(res http.ResponseWriter, req *http.Request) {
 	if row.Runtime.Valid {
 		runtime = int(row.Runtime.Int32)
 	}
-	item := Item{
-		Id:                     int(row.ID),
-		Title:                  row.Title,
-		Year:                   year,
-		RuntimeInMinutes:       runtime,
-		ThumbnailImageFilename: storage.GetImageFilename(int(row.ID), storage.ImageTypeThumbnail, -1),
-		SeriesName:             row.SeriesName,
-		ContributorName:        contributorName,
-		NumFavorites:           int(row.NumFavorites),
-		Images:                 galleryImages,
-		Tags:                   row.Tags,
+	seriesId := 0
+	seriesIndex := 0
+	if row.SeriesID.Valid && row.SeriesIndex.Valid {
+		seriesId = int(row.SeriesID.Int32)
+		seriesIndex = int(row.SeriesIndex.Int32)
 	}
-	if err := json.NewEncoder(res).Encode(item); err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+	contributorName := ""
+	if row.ContributorID.Valid {
+		contributorName = s.lookup.GetDisplayName(row.ContributorID.String)
 	}
+	return Item{
+		Id:               int(row.ID),
+		Title:            row.Title,
+		Year:             year,
+		RuntimeInMinutes: runtime,
+		Thumbnail:        thumbnail,
+		SeriesName:       row.SeriesName,
+		SeriesId:         seriesId,
+		SeriesIndex:      seriesIndex,
+		ContributorName:  contributorName,
+		NumFavorites:     int(row.NumFavorites),
+		Images:           galleryImages,
+		Tags:             row.Tags,
+	}, nil
 }
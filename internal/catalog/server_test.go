@@ -8,11 +8,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/golden-vcr/tapes/gen/queries"
 	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/golden-vcr/tapes/internal/pagination"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,20 +40,32 @@ func Test_Server_handleGetListing(t *testing.T) {
 						Year:         sql.NullInt32{Valid: true, Int32: 1991},
 						Runtime:      sql.NullInt32{Valid: true, Int32: 120},
 						NumFavorites: 2,
+						Thumbnail: encodeTapeThumbnail(t, &db.TapeThumbnail{
+							Color:    "#ffccee",
+							Width:    87,
+							Height:   256,
+							Blurhash: "TEHV6nWB2yk8pyo0adR*.7kCMdnj",
+						}),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
-								Index:   0,
-								Color:   "#ffccee",
-								Width:   440,
-								Height:  1301,
-								Rotated: false,
+								Index:    0,
+								Color:    "#ffccee",
+								Width:    440,
+								Height:   1301,
+								Rotated:  false,
+								Blurhash: "TEHV6nWB2yk8pyo0adR*.7kCMdnj",
 							},
 							{
-								Index:   1,
-								Color:   "#eebbee",
-								Width:   441,
-								Height:  1300,
-								Rotated: true,
+								Index:    1,
+								Color:    "#eebbee",
+								Width:    441,
+								Height:   1300,
+								Rotated:  true,
+								Blurhash: "TKO2?U%2Tw=w]~RBVZRi};RPxuwH",
+								Variants: []db.TapeImageVariant{
+									{Format: "jpeg", Width: 400},
+									{Format: "webp", Width: 400},
+								},
 							},
 						}),
 						Tags: []string{"fitness", "instructional"},
@@ -59,7 +73,7 @@ func Test_Server_handleGetListing(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":1991,"runtime":120,"thumbnail":"0001_thumb.jpg","numFavorites":2,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true}],"tags":["fitness","instructional"]}]}`,
+			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":1991,"runtime":120,"thumbnail":{"filename":"0001_thumb.jpg","width":87,"height":256,"color":"#ffccee","blurhash":"TEHV6nWB2yk8pyo0adR*.7kCMdnj"},"numFavorites":2,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"blurhash":"TEHV6nWB2yk8pyo0adR*.7kCMdnj","variants":[]},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true,"blurhash":"TKO2?U%2Tw=w]~RBVZRi};RPxuwH","variants":[{"filename":"0001_b@400w.jpg","format":"jpeg","width":400},{"filename":"0001_b@400w.webp","format":"webp","width":400}]}],"tags":["fitness","instructional"]}]}`,
 		},
 		{
 			"null year and runtime are represented as 0",
@@ -71,6 +85,7 @@ func Test_Server_handleGetListing(t *testing.T) {
 						Year:         sql.NullInt32{},
 						Runtime:      sql.NullInt32{},
 						NumFavorites: 2,
+						Thumbnail:    encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -85,18 +100,19 @@ func Test_Server_handleGetListing(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":0,"runtime":0,"thumbnail":"0001_thumb.jpg","numFavorites":2,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false}],"tags":["fitness","instructional"]}]}`,
+			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":0,"runtime":0,"numFavorites":2,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"variants":[]}],"tags":["fitness","instructional"]}]}`,
 		},
 		{
 			"unexpected JSON format for image data is a 500 error",
 			&mockQueries{
 				rows: []queries.GetTapesRow{
 					{
-						ID:      1,
-						Title:   "Tape one",
-						Year:    sql.NullInt32{},
-						Runtime: sql.NullInt32{},
-						Images:  []byte(`[{"index":"not-a-valid-int","color":"#ffccee","width": 440,"height": 1301,"rotated":false}]`),
+						ID:        1,
+						Title:     "Tape one",
+						Year:      sql.NullInt32{},
+						Runtime:   sql.NullInt32{},
+						Thumbnail: encodeTapeThumbnail(t, nil),
+						Images:    []byte(`[{"index":"not-a-valid-int","color":"#ffccee","width": 440,"height": 1301,"rotated":false,"variants":[]}]`),
 					},
 				},
 			},
@@ -121,6 +137,7 @@ func Test_Server_handleGetListing(t *testing.T) {
 						Year:          sql.NullInt32{Valid: true, Int32: 1991},
 						Runtime:       sql.NullInt32{Valid: true, Int32: 120},
 						ContributorID: sql.NullString{Valid: true, String: "1234"},
+						Thumbnail:     encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -142,7 +159,7 @@ func Test_Server_handleGetListing(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":1991,"runtime":120,"thumbnail":"0001_thumb.jpg","contributor":"JoeBob","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true}],"tags":["fitness","instructional"]}]}`,
+			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":1991,"runtime":120,"contributor":"JoeBob","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"variants":[]},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true,"variants":[]}],"tags":["fitness","instructional"]}]}`,
 		},
 	}
 	for _, tt := range tests {
@@ -165,6 +182,180 @@ func Test_Server_handleGetListing(t *testing.T) {
 	}
 }
 
+func Test_Server_handleGetListing_invalidThumbnail(t *testing.T) {
+	s := &Server{
+		q: &mockQueries{
+			rows: []queries.GetTapesRow{
+				{
+					ID:        1,
+					Title:     "Tape one",
+					Thumbnail: []byte(`{"color":"#ffccee","width":"wide","height":256,"blurhash":""}`),
+					Images:    encodeTapeImages(t, nil),
+				},
+			},
+		},
+		lookup:       mockLookup{},
+		imageHostUrl: "https://my-images.biz",
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	s.handleGetListing(res, req)
+
+	// The details of the underlying JSON error vary between Go versions, so only the
+	// stable prefix of the message is checked
+	b, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Contains(t, string(b), "failed to parse TapeThumbnail from JSON data")
+}
+
+func Test_Server_newItem_thumbnail(t *testing.T) {
+	s := &Server{lookup: mockLookup{}}
+
+	// A tape with no thumbnail recorded in the database should have no thumbnail at
+	// all, so that it can't be mistaken for a thumbnail with real details
+	item, err := s.newItem(queries.GetTapeRow{
+		ID:        1,
+		Title:     "Tape one",
+		Thumbnail: encodeTapeThumbnail(t, nil),
+		Images:    encodeTapeImages(t, nil),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, item.Thumbnail)
+	b, err := json.Marshal(item)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), `"thumbnail"`)
+
+	item, err = s.newItem(queries.GetTapeRow{
+		ID:    1,
+		Title: "Tape one",
+		Thumbnail: encodeTapeThumbnail(t, &db.TapeThumbnail{
+			Color:  "#ffccee",
+			Width:  87,
+			Height: 256,
+		}),
+		Images: encodeTapeImages(t, nil),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &ThumbnailImage{
+		Filename: "0001_thumb.jpg",
+		Width:    87,
+		Height:   256,
+		Color:    "#ffccee",
+	}, item.Thumbnail)
+}
+
+func Test_Server_handleGetListing_params(t *testing.T) {
+	rows := make([]queries.GetTapesRow, 0, 5)
+	for i := 1; i <= 5; i++ {
+		rows = append(rows, queries.GetTapesRow{
+			ID:            int32(i),
+			Title:         fmt.Sprintf("Tape %d", i),
+			ContributorID: sql.NullString{Valid: true, String: "1234"},
+			Thumbnail:     encodeTapeThumbnail(t, nil),
+			Images:        encodeTapeImages(t, []db.TapeImage{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}}),
+			Tags:          []string{"fitness"},
+		})
+	}
+	tests := []struct {
+		name           string
+		query          string
+		wantStatus     int
+		wantParams     *queries.GetTapesParams
+		wantIds        []int
+		wantNextCursor string
+	}{
+		{
+			"filter and sort parameters are passed to the database",
+			"?tag=fitness&series=Jazzercise&contributor=1234&yearMin=1980&yearMax=1989&runtimeMin=30&runtimeMax=90&sort=year&order=desc",
+			http.StatusOK,
+			&queries.GetTapesParams{
+				Tag:           sql.NullString{Valid: true, String: "fitness"},
+				SeriesName:    sql.NullString{Valid: true, String: "Jazzercise"},
+				ContributorID: sql.NullString{Valid: true, String: "1234"},
+				YearMin:       sql.NullInt32{Valid: true, Int32: 1980},
+				YearMax:       sql.NullInt32{Valid: true, Int32: 1989},
+				RuntimeMin:    sql.NullInt32{Valid: true, Int32: 30},
+				RuntimeMax:    sql.NullInt32{Valid: true, Int32: 90},
+				SortBy:        "year",
+				Descending:    true,
+			},
+			[]int{1, 2, 3, 4, 5},
+			"",
+		},
+		{
+			"limit returns first page with cursor",
+			"?limit=2",
+			http.StatusOK,
+			&queries.GetTapesParams{
+				SortBy:   "id",
+				PageSize: sql.NullInt32{Valid: true, Int32: 3},
+			},
+			[]int{1, 2},
+			pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 2}),
+		},
+		{
+			"cursor resumes listing at next page",
+			"?limit=2&cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 2}),
+			http.StatusOK,
+			&queries.GetTapesParams{
+				SortBy:   "id",
+				AfterID:  sql.NullInt32{Valid: true, Int32: 2},
+				PageSize: sql.NullInt32{Valid: true, Int32: 3},
+			},
+			[]int{3, 4},
+			pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 4}),
+		},
+		{
+			"final page has no cursor",
+			"?limit=2&cursor=" + pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 4}),
+			http.StatusOK,
+			&queries.GetTapesParams{
+				SortBy:   "id",
+				AfterID:  sql.NullInt32{Valid: true, Int32: 4},
+				PageSize: sql.NullInt32{Valid: true, Int32: 3},
+			},
+			[]int{5},
+			"",
+		},
+		{
+			"invalid parameter is a 400 error",
+			"?sort=color",
+			http.StatusBadRequest,
+			nil,
+			nil,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &mockQueries{rows: rows}
+			s := &Server{
+				q:            q,
+				lookup:       mockLookup{},
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			res := httptest.NewRecorder()
+			s.handleGetListing(res, req)
+
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantParams, q.getTapesParams)
+			if tt.wantStatus == http.StatusOK {
+				var listing Listing
+				err := json.NewDecoder(res.Body).Decode(&listing)
+				assert.NoError(t, err)
+				ids := make([]int, 0, len(listing.Items))
+				for _, item := range listing.Items {
+					ids = append(ids, item.Id)
+				}
+				assert.Equal(t, tt.wantIds, ids)
+				assert.Equal(t, tt.wantNextCursor, listing.NextCursor)
+			}
+		})
+	}
+}
+
 func Test_Server_handleGetDetails(t *testing.T) {
 	lookup := mockLookup{
 		"1234": "JoeBob",
@@ -183,10 +374,11 @@ func Test_Server_handleGetDetails(t *testing.T) {
 			&mockQueries{
 				rows: []queries.GetTapesRow{
 					{
-						ID:      1,
-						Title:   "Tape one",
-						Year:    sql.NullInt32{Valid: true, Int32: 1991},
-						Runtime: sql.NullInt32{Valid: true, Int32: 120},
+						ID:        1,
+						Title:     "Tape one",
+						Year:      sql.NullInt32{Valid: true, Int32: 1991},
+						Runtime:   sql.NullInt32{Valid: true, Int32: 120},
+						Thumbnail: encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -208,7 +400,7 @@ func Test_Server_handleGetDetails(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"id":1,"title":"Tape one","year":1991,"runtime":120,"thumbnail":"0001_thumb.jpg","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true}],"tags":["fitness","instructional"]}`,
+			`{"id":1,"title":"Tape one","year":1991,"runtime":120,"numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"variants":[]},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true,"variants":[]}],"tags":["fitness","instructional"]}`,
 		},
 		{
 			"null year and runtime are represented as 0",
@@ -216,10 +408,11 @@ func Test_Server_handleGetDetails(t *testing.T) {
 			&mockQueries{
 				rows: []queries.GetTapesRow{
 					{
-						ID:      1,
-						Title:   "Tape one",
-						Year:    sql.NullInt32{},
-						Runtime: sql.NullInt32{},
+						ID:        1,
+						Title:     "Tape one",
+						Year:      sql.NullInt32{},
+						Runtime:   sql.NullInt32{},
+						Thumbnail: encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -234,7 +427,7 @@ func Test_Server_handleGetDetails(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"id":1,"title":"Tape one","year":0,"runtime":0,"thumbnail":"0001_thumb.jpg","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false}],"tags":["fitness","instructional"]}`,
+			`{"id":1,"title":"Tape one","year":0,"runtime":0,"numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"variants":[]}],"tags":["fitness","instructional"]}`,
 		},
 		{
 			"unexpected JSON format for image data is a 500 error",
@@ -242,11 +435,12 @@ func Test_Server_handleGetDetails(t *testing.T) {
 			&mockQueries{
 				rows: []queries.GetTapesRow{
 					{
-						ID:      1,
-						Title:   "Tape one",
-						Year:    sql.NullInt32{},
-						Runtime: sql.NullInt32{},
-						Images:  []byte(`[{"index":"not-a-valid-int","color":"#ffccee","width": 440,"height": 1301,"rotated":false}]`),
+						ID:        1,
+						Title:     "Tape one",
+						Year:      sql.NullInt32{},
+						Runtime:   sql.NullInt32{},
+						Thumbnail: encodeTapeThumbnail(t, nil),
+						Images:    []byte(`[{"index":"not-a-valid-int","color":"#ffccee","width": 440,"height": 1301,"rotated":false,"variants":[]}]`),
 					},
 				},
 			},
@@ -260,10 +454,11 @@ func Test_Server_handleGetDetails(t *testing.T) {
 				err: fmt.Errorf("mock error"),
 				rows: []queries.GetTapesRow{
 					{
-						ID:      1,
-						Title:   "Tape one",
-						Year:    sql.NullInt32{},
-						Runtime: sql.NullInt32{},
+						ID:        1,
+						Title:     "Tape one",
+						Year:      sql.NullInt32{},
+						Runtime:   sql.NullInt32{},
+						Thumbnail: encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -298,6 +493,7 @@ func Test_Server_handleGetDetails(t *testing.T) {
 						Year:          sql.NullInt32{Valid: true, Int32: 1991},
 						Runtime:       sql.NullInt32{Valid: true, Int32: 120},
 						ContributorID: sql.NullString{Valid: true, String: "1234"},
+						Thumbnail:     encodeTapeThumbnail(t, nil),
 						Images: encodeTapeImages(t, []db.TapeImage{
 							{
 								Index:   0,
@@ -319,7 +515,7 @@ func Test_Server_handleGetDetails(t *testing.T) {
 				},
 			},
 			http.StatusOK,
-			`{"id":1,"title":"Tape one","year":1991,"runtime":120,"thumbnail":"0001_thumb.jpg","contributor":"JoeBob","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true}],"tags":["fitness","instructional"]}`,
+			`{"id":1,"title":"Tape one","year":1991,"runtime":120,"contributor":"JoeBob","numFavorites":0,"images":[{"filename":"0001_a.jpg","width":440,"height":1301,"color":"#ffccee","rotated":false,"variants":[]},{"filename":"0001_b.jpg","width":441,"height":1300,"color":"#eebbee","rotated":true,"variants":[]}],"tags":["fitness","instructional"]}`,
 		},
 	}
 	for _, tt := range tests {
@@ -348,13 +544,68 @@ func Test_Server_handleGetDetails(t *testing.T) {
 type mockQueries struct {
 	err  error
 	rows []queries.GetTapesRow
+
+	searchRows []queries.SearchTapesRow
+	series     []queries.GetSeriesRow
+
+	contributors []queries.GetContributorsRow
+
+	revision queries.GetCatalogRevisionRow
+
+	getTapesParams    *queries.GetTapesParams
+	searchTapesParams *queries.SearchTapesParams
 }
 
-func (m *mockQueries) GetTapes(ctx context.Context) ([]queries.GetTapesRow, error) {
+func (m *mockQueries) GetTapes(ctx context.Context, arg queries.GetTapesParams) ([]queries.GetTapesRow, error) {
+	m.getTapesParams = &arg
 	if m.err != nil {
 		return nil, m.err
 	}
-	return m.rows, nil
+	rows := m.rows
+	if arg.Tag.Valid {
+		rows = make([]queries.GetTapesRow, 0, len(m.rows))
+		for _, row := range m.rows {
+			if hasTag(row.Tags, arg.Tag.String) {
+				rows = append(rows, row)
+			}
+		}
+	}
+	if arg.SeriesID.Valid {
+		inSeries := make([]queries.GetTapesRow, 0, len(rows))
+		for _, row := range rows {
+			if row.SeriesID == arg.SeriesID {
+				inSeries = append(inSeries, row)
+			}
+		}
+		rows = inSeries
+	}
+	if arg.ContributorID.Valid {
+		contributed := make([]queries.GetTapesRow, 0, len(rows))
+		for _, row := range rows {
+			if row.ContributorID == arg.ContributorID {
+				contributed = append(contributed, row)
+			}
+		}
+		rows = contributed
+	}
+	if arg.SortBy == "series" {
+		rows = append([]queries.GetTapesRow(nil), rows...)
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].SeriesIndex.Int32 < rows[j].SeriesIndex.Int32
+		})
+	}
+	if arg.AfterID.Valid {
+		for i, row := range rows {
+			if row.ID == arg.AfterID.Int32 {
+				rows = rows[i+1:]
+				break
+			}
+		}
+	}
+	if arg.PageSize.Valid && len(rows) > int(arg.PageSize.Int32) {
+		rows = rows[:arg.PageSize.Int32]
+	}
+	return rows, nil
 }
 
 func (m *mockQueries) GetTape(ctx context.Context, tapeID int32) (queries.GetTapeRow, error) {
@@ -369,6 +620,7 @@ func (m *mockQueries) GetTape(ctx context.Context, tapeID int32) (queries.GetTap
 				Year:          row.Year,
 				Runtime:       row.Runtime,
 				ContributorID: row.ContributorID,
+				Thumbnail:     row.Thumbnail,
 				Images:        row.Images,
 				Tags:          row.Tags,
 			}, nil
@@ -391,8 +643,135 @@ func (m *mockQueries) GetTapeContributorIds(ctx context.Context) ([]string, erro
 	return contributorIds, nil
 }
 
+func (m *mockQueries) SearchTapes(ctx context.Context, arg queries.SearchTapesParams) ([]queries.SearchTapesRow, error) {
+	m.searchTapesParams = &arg
+	if m.err != nil {
+		return nil, m.err
+	}
+	rows := m.searchRows
+	if len(rows) > int(arg.MaxResults) {
+		rows = rows[:arg.MaxResults]
+	}
+	return rows, nil
+}
+
+func (m *mockQueries) GetTagCounts(ctx context.Context) ([]queries.GetTagCountsRow, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	numTapesByTag := make(map[string]int64)
+	for _, row := range m.rows {
+		for _, tag := range row.Tags {
+			numTapesByTag[tag]++
+		}
+	}
+	names := make([]string, 0, len(numTapesByTag))
+	for name := range numTapesByTag {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]queries.GetTagCountsRow, 0, len(names))
+	for _, name := range names {
+		result = append(result, queries.GetTagCountsRow{
+			Name:     name,
+			NumTapes: numTapesByTag[name],
+		})
+	}
+	return result, nil
+}
+
+func (m *mockQueries) GetTagCount(ctx context.Context, tagName string) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	numTapes := int64(0)
+	for _, row := range m.rows {
+		if hasTag(row.Tags, tagName) {
+			numTapes++
+		}
+	}
+	return numTapes, nil
+}
+
+func (m *mockQueries) GetSeriesList(ctx context.Context) ([]queries.GetSeriesListRow, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	result := make([]queries.GetSeriesListRow, 0, len(m.series))
+	for _, series := range m.series {
+		numTapes := int64(0)
+		for _, row := range m.rows {
+			if row.SeriesID.Valid && row.SeriesID.Int32 == series.ID {
+				numTapes++
+			}
+		}
+		if numTapes > 0 {
+			result = append(result, queries.GetSeriesListRow{
+				ID:          series.ID,
+				Name:        series.Name,
+				Description: series.Description,
+				NumTapes:    numTapes,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (m *mockQueries) GetSeries(ctx context.Context, seriesID int32) (queries.GetSeriesRow, error) {
+	if m.err != nil {
+		return queries.GetSeriesRow{}, m.err
+	}
+	for _, series := range m.series {
+		if series.ID == seriesID {
+			return series, nil
+		}
+	}
+	return queries.GetSeriesRow{}, sql.ErrNoRows
+}
+
+func (m *mockQueries) GetContributors(ctx context.Context) ([]queries.GetContributorsRow, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.contributors, nil
+}
+
+func (m *mockQueries) GetContributor(ctx context.Context, contributorID string) (queries.GetContributorRow, error) {
+	if m.err != nil {
+		return queries.GetContributorRow{}, m.err
+	}
+	for _, contributor := range m.contributors {
+		if contributor.ContributorID == contributorID {
+			return queries.GetContributorRow(contributor), nil
+		}
+	}
+	return queries.GetContributorRow{}, sql.ErrNoRows
+}
+
+func (m *mockQueries) GetCatalogRevision(ctx context.Context) (queries.GetCatalogRevisionRow, error) {
+	if m.err != nil {
+		return queries.GetCatalogRevisionRow{}, m.err
+	}
+	return m.revision, nil
+}
+
 var _ Queries = (*mockQueries)(nil)
 
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeTapeThumbnail(t *testing.T, thumbnail *db.TapeThumbnail) json.RawMessage {
+	data, err := json.Marshal(thumbnail)
+	assert.NoError(t, err)
+	return data
+}
+
 func encodeTapeImages(t *testing.T, images []db.TapeImage) json.RawMessage {
 	data, err := json.Marshal(images)
 	assert.NoError(t, err)
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// snapshot is a pre-serialized copy of the default catalog listing, i.e. the response
+// to a GET /catalog request with no query params
+type snapshot struct {
+	// revision is the catalog revision as of when the snapshot was built: the snapshot
+	// reflects all changes up to and including that revision
+	revision int64
+	// body is the JSON-encoded Listing
+	body []byte
+}
+
+// RunSnapshotUpdates builds an initial snapshot of the default catalog listing, then
+// rebuilds it every time a value is received from changes, until the given context is
+// canceled
+func (s *Server) RunSnapshotUpdates(ctx context.Context, changes <-chan struct{}) {
+	for {
+		if err := s.rebuildSnapshot(ctx); err != nil {
+			fmt.Printf("Error rebuilding catalog snapshot: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+		}
+	}
+}
+
+// rebuildSnapshot queries the database for the current state of the default catalog
+// listing and replaces our snapshot with the result
+func (s *Server) rebuildSnapshot(ctx context.Context) error {
+	// Get the current revision before querying for catalog data, so that our snapshot
+	// is guaranteed to be at least as new as the revision we record
+	row, err := s.q.GetCatalogRevision(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog revision: %w", err)
+	}
+
+	params, err := parseListingParams(nil)
+	if err != nil {
+		return err
+	}
+	listing, err := s.getListing(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog listing: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(listing); err != nil {
+		return fmt.Errorf("failed to encode catalog listing: %w", err)
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+	if s.snapshot == nil || s.snapshot.revision <= row.Revision {
+		s.snapshot = &snapshot{
+			revision: row.Revision,
+			body:     buf.Bytes(),
+		}
+	}
+	return nil
+}
+
+// getSnapshot returns the pre-serialized default catalog listing, provided that it's
+// up-to-date with the catalog revision that was current at the start of the request
+func (s *Server) getSnapshot(ctx context.Context) ([]byte, bool) {
+	revision, ok := getRequestRevision(ctx)
+	if !ok {
+		return nil, false
+	}
+	s.snapshotMu.RLock()
+	defer s.snapshotMu.RUnlock()
+	if s.snapshot == nil || s.snapshot.revision != revision {
+		return nil, false
+	}
+	return s.snapshot.body, true
+}
@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_snapshot(t *testing.T) {
+	q := &mockQueries{
+		revision: queries.GetCatalogRevisionRow{Revision: 10},
+		rows: []queries.GetTapesRow{
+			{ID: 1, Title: "Tape one", Thumbnail: encodeTapeThumbnail(t, nil), Images: encodeTapeImages(t, nil), Tags: []string{}},
+		},
+	}
+	s := &Server{q: q, lookup: mockLookup{}}
+	handler := s.handleConditionalGet(http.HandlerFunc(s.handleGetListing))
+	get := func(uri string) string {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+		return res.Body.String()
+	}
+
+	// Once a snapshot has been built, the default listing should be served from memory
+	err := s.rebuildSnapshot(context.Background())
+	assert.NoError(t, err)
+	q.rows[0].Title = "Tape one (modified)"
+	assert.Contains(t, get("/catalog"), `"title":"Tape one"`)
+
+	// Requests with query params should never be served from the snapshot
+	assert.Contains(t, get("/catalog?sort=id"), `"title":"Tape one (modified)"`)
+
+	// If the catalog has changed since the snapshot was built, we should fall back to
+	// querying the database until the snapshot has been rebuilt
+	q.revision.Revision = 11
+	assert.Contains(t, get("/catalog"), `"title":"Tape one (modified)"`)
+	q.rows[0].Title = "Tape one (modified again)"
+	assert.Contains(t, get("/catalog"), `"title":"Tape one (modified again)"`)
+	err = s.rebuildSnapshot(context.Background())
+	assert.NoError(t, err)
+	q.rows[0].Title = "Tape one (stale)"
+	assert.Contains(t, get("/catalog"), `"title":"Tape one (modified again)"`)
+}
+
+func Test_Server_RunSnapshotUpdates(t *testing.T) {
+	q := &mockQueries{
+		revision: queries.GetCatalogRevisionRow{Revision: 1},
+	}
+	s := &Server{q: q, lookup: mockLookup{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.RunSnapshotUpdates(ctx, changes)
+		close(done)
+	}()
+
+	getRevision := func() int64 {
+		s.snapshotMu.RLock()
+		defer s.snapshotMu.RUnlock()
+		if s.snapshot == nil {
+			return 0
+		}
+		return s.snapshot.revision
+	}
+
+	// An initial snapshot should be built immediately, then rebuilt on each change
+	assert.Eventually(t, func() bool { return getRevision() == 1 }, time.Second, 10*time.Millisecond)
+	q.revision.Revision = 2
+	changes <- struct{}{}
+	assert.Eventually(t, func() bool { return getRevision() == 2 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunSnapshotUpdates did not return after context was canceled")
+	}
+}
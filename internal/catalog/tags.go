@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) handleGetTags(res http.ResponseWriter, req *http.Request) {
+	rows, err := s.q.GetTagCounts(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tags := make([]Tag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, Tag{
+			Name:     row.Name,
+			NumTapes: int(row.NumTapes),
+		})
+	}
+
+	result := TagIndex{
+		Tags: tags,
+	}
+	if err := json.NewEncoder(res).Encode(result); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleGetTag(res http.ResponseWriter, req *http.Request) {
+	tagName, ok := mux.Vars(req)["name"]
+	if !ok || tagName == "" {
+		http.Error(res, "failed to parse 'name' from URL", http.StatusInternalServerError)
+		return
+	}
+
+	// The tag listing accepts the same parameters as GET /catalog, aside from 'tag',
+	// which is taken from the URL
+	params, err := parseListingParams(req.URL.Query())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params.filter.Tag = sql.NullString{Valid: true, String: tagName}
+
+	// Return a 404 if no tapes in the catalog carry this tag
+	numTapes, err := s.q.GetTagCount(req.Context(), tagName)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if numTapes == 0 {
+		http.Error(res, "no such tag", http.StatusNotFound)
+		return
+	}
+
+	s.writeListing(res, req, params)
+}
@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/golden-vcr/tapes/internal/pagination"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_handleGetTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		q          *mockQueries
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			&mockQueries{
+				rows: []queries.GetTapesRow{
+					{ID: 1, Tags: []string{"fitness", "instructional"}},
+					{ID: 2, Tags: []string{"fitness"}},
+					{ID: 3, Tags: []string{}},
+				},
+			},
+			http.StatusOK,
+			`{"tags":[{"name":"fitness","numTapes":2},{"name":"instructional","numTapes":1}]}`,
+		},
+		{
+			"no tags",
+			&mockQueries{},
+			http.StatusOK,
+			`{"tags":[]}`,
+		},
+		{
+			"database error is a 500",
+			&mockQueries{err: fmt.Errorf("mock error")},
+			http.StatusInternalServerError,
+			"mock error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            tt.q,
+				lookup:       mockLookup{},
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+			res := httptest.NewRecorder()
+			s.handleGetTags(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func Test_Server_handleGetTag(t *testing.T) {
+	images := encodeTapeImages(t, []db.TapeImage{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}})
+	rows := []queries.GetTapesRow{
+		{ID: 1, Title: "Tape one", Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{"fitness", "instructional"}},
+		{ID: 2, Title: "Tape two", Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{"instructional"}},
+		{ID: 3, Title: "Tape three", Thumbnail: encodeTapeThumbnail(t, nil), Images: images, Tags: []string{"fitness"}},
+	}
+	tests := []struct {
+		name       string
+		tagName    string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"normal usage",
+			"fitness",
+			"",
+			http.StatusOK,
+			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":0,"runtime":0,"numFavorites":0,"images":[{"filename":"0001_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":["fitness","instructional"]},{"id":3,"title":"Tape three","year":0,"runtime":0,"numFavorites":0,"images":[{"filename":"0003_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":["fitness"]}]}`,
+		},
+		{
+			"listing params are supported",
+			"fitness",
+			"?limit=1",
+			http.StatusOK,
+			`{"imageHost":"https://my-images.biz","items":[{"id":1,"title":"Tape one","year":0,"runtime":0,"numFavorites":0,"images":[{"filename":"0001_a.jpg","width":100,"height":200,"color":"#ffffff","rotated":false,"variants":[]}],"tags":["fitness","instructional"]}],"nextCursor":"` + pagination.EncodeCursor(&listingCursor{SortBy: "id", TapeId: 1}) + `"}`,
+		},
+		{
+			"invalid listing params are a 400 error",
+			"fitness",
+			"?order=sideways",
+			http.StatusBadRequest,
+			"'order' must be one of: asc, desc",
+		},
+		{
+			"unknown tag is a 404 error",
+			"christmas",
+			"",
+			http.StatusNotFound,
+			"no such tag",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				q:            &mockQueries{rows: rows},
+				lookup:       mockLookup{},
+				imageHostUrl: "https://my-images.biz",
+			}
+			req := httptest.NewRequest(http.MethodGet, "/tags/"+tt.tagName+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{
+				"name": tt.tagName,
+			})
+			res := httptest.NewRecorder()
+			s.handleGetTag(res, req)
+
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			body := strings.TrimSuffix(string(b), "\n")
+			assert.Equal(t, tt.wantStatus, res.Code)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
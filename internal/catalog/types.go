@@ -1,27 +1,116 @@
 package catalog
 
+import "time"
+
 type Listing struct {
 	ImageHostUrl string `json:"imageHost"`
 	Items        []Item `json:"items"`
+	NextCursor   string `json:"nextCursor,omitempty"`
 }
 
 type Item struct {
-	Id                     int            `json:"id"`
-	Title                  string         `json:"title"`
-	Year                   int            `json:"year"`
-	RuntimeInMinutes       int            `json:"runtime"`
-	ThumbnailImageFilename string         `json:"thumbnail"`
-	SeriesName             string         `json:"series,omitempty"`
-	ContributorName        string         `json:"contributor,omitempty"`
-	NumFavorites           int            `json:"numFavorites"`
-	Images                 []GalleryImage `json:"images"`
-	Tags                   []string       `json:"tags"`
+	Id               int             `json:"id"`
+	Title            string          `json:"title"`
+	Year             int             `json:"year"`
+	RuntimeInMinutes int             `json:"runtime"`
+	Thumbnail        *ThumbnailImage `json:"thumbnail,omitempty"`
+	SeriesName       string          `json:"series,omitempty"`
+	SeriesId         int             `json:"seriesId,omitempty"`
+	SeriesIndex      int             `json:"seriesIndex,omitempty"`
+	ContributorName  string          `json:"contributor,omitempty"`
+	NumFavorites     int             `json:"numFavorites"`
+	Images           []GalleryImage  `json:"images"`
+	Tags             []string        `json:"tags"`
 }
 
-type GalleryImage struct {
+type ThumbnailImage struct {
 	Filename string `json:"filename"`
 	Width    int    `json:"width"`
 	Height   int    `json:"height"`
 	Color    string `json:"color"`
-	Rotated  bool   `json:"rotated"`
+	Blurhash string `json:"blurhash,omitempty"`
+}
+
+type GalleryImage struct {
+	Filename string                `json:"filename"`
+	Width    int                   `json:"width"`
+	Height   int                   `json:"height"`
+	Color    string                `json:"color"`
+	Rotated  bool                  `json:"rotated"`
+	Blurhash string                `json:"blurhash,omitempty"`
+	Variants []GalleryImageVariant `json:"variants"`
+}
+
+type GalleryImageVariant struct {
+	Filename string `json:"filename"`
+	Format   string `json:"format"`
+	Width    int    `json:"width"`
+}
+
+type SearchResults struct {
+	ImageHostUrl string         `json:"imageHost"`
+	Query        string         `json:"query"`
+	Results      []SearchResult `json:"results"`
+}
+
+type SearchResult struct {
+	Item
+	Rank       float64     `json:"rank"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Highlight identifies a field of a tape that matched a search query, with the
+// field's value split into fragments so that the matching words can be emphasized
+type Highlight struct {
+	Field     string     `json:"field"`
+	Fragments []Fragment `json:"fragments"`
+}
+
+type Fragment struct {
+	Text    string `json:"text"`
+	Matched bool   `json:"matched"`
+}
+
+type TagIndex struct {
+	Tags []Tag `json:"tags"`
+}
+
+type Tag struct {
+	Name     string `json:"name"`
+	NumTapes int    `json:"numTapes"`
+}
+
+type SeriesListing struct {
+	Series []Series `json:"series"`
+}
+
+type Series struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	NumTapes    int    `json:"numTapes"`
+}
+
+type SeriesDetails struct {
+	Series
+	ImageHostUrl string `json:"imageHost"`
+	Items        []Item `json:"items"`
+}
+
+type ContributorListing struct {
+	Contributors []Contributor `json:"contributors"`
+}
+
+type Contributor struct {
+	TwitchUserId       string    `json:"twitchUserId"`
+	Name               string    `json:"name"`
+	NumTapes           int       `json:"numTapes"`
+	FirstContributedAt time.Time `json:"firstContributedAt"`
+	LastContributedAt  time.Time `json:"lastContributedAt"`
+}
+
+type ContributorDetails struct {
+	Contributor
+	ImageHostUrl string `json:"imageHost"`
+	Items        []Item `json:"items"`
 }
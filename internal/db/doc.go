@@ -1,3 +1,4 @@
 // Package db implements additional utility code on top of gen/queries, mainly for
-// parsing JSON-formatted result values that sqlc simply interprets as json.RawMessage
+// parsing JSON-formatted result values that sqlc simply interprets as json.RawMessage,
+// along with listening for notifications sent by Postgres when catalog data changes
 package db
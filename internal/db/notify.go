@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CatalogChangedChannel is the name of the channel on which Postgres sends a
+// notification whenever a transaction that modifies catalog data is committed
+const CatalogChangedChannel = "tapes_catalog_changed"
+
+// listenerPingInterval is how often we'll ping the database to verify that our
+// listener connection is still alive, if no notifications have been received
+const listenerPingInterval = 90 * time.Second
+
+// ListenForCatalogChanges opens a dedicated connection to the database and listens for
+// notifications indicating that catalog data has changed, signaling the returned
+// channel each time. Signals are coalesced: if the receiver falls behind, a burst of
+// notifications results in a single signal. The connection is closed once the given
+// context is canceled.
+func ListenForCatalogChanges(ctx context.Context, connectionString string) (<-chan struct{}, error) {
+	l := pq.NewListener(connectionString, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Printf("Error in %s listener: %v\n", CatalogChangedChannel, err)
+		}
+	})
+	if err := l.Listen(CatalogChangedChannel); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", CatalogChangedChannel, err)
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer l.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-l.Notify:
+				// A nil notification indicates that the connection was re-established,
+				// in which case we may have missed notifications in the meantime: either
+				// way, signal that the catalog may have changed
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			case <-time.After(listenerPingInterval):
+				go l.Ping()
+			}
+		}
+	}()
+	return changes, nil
+}
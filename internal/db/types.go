@@ -8,11 +8,41 @@ import (
 // TapeImage is the JSON format used by the GetTapes query when returning data about the
 // images for a tape
 type TapeImage struct {
-	Index   int32  `json:"index"`
-	Color   string `json:"color"`
-	Width   int32  `json:"width"`
-	Height  int32  `json:"height"`
-	Rotated bool   `json:"rotated"`
+	Index    int32              `json:"index"`
+	Color    string             `json:"color"`
+	Width    int32              `json:"width"`
+	Height   int32              `json:"height"`
+	Rotated  bool               `json:"rotated"`
+	Blurhash string             `json:"blurhash"`
+	Variants []TapeImageVariant `json:"variants"`
+}
+
+// TapeImageVariant is the JSON format used to describe an alternate version of a tape
+// image, i.e. one that's been converted to a different format and/or scaled down
+type TapeImageVariant struct {
+	Format string `json:"format"`
+	Width  int32  `json:"width"`
+}
+
+// TapeThumbnail is the JSON format used by the GetTapes query when returning data about
+// the thumbnail image for a tape
+type TapeThumbnail struct {
+	Color       string `json:"color"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	Blurhash    string `json:"blurhash"`
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// ParseTapeThumbnail accepts a JSON-formatted object representing a tape's thumbnail
+// image, as returned by the GetTapes query, or JSON null if no thumbnail has been
+// recorded for the tape, in which case the result is nil
+func ParseTapeThumbnail(data json.RawMessage) (*TapeThumbnail, error) {
+	var thumbnail *TapeThumbnail
+	if err := json.Unmarshal(data, &thumbnail); err != nil {
+		return nil, fmt.Errorf("failed to parse TapeThumbnail from JSON data: %v", err)
+	}
+	return thumbnail, nil
 }
 
 // ParseTapeImageArray accepts a JSON-formatted array of objects represented tape
@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"sort"
+)
+
+// Compute determines what changes a sync would make to the tapes database, given the
+// current state of every tape in the database, the new state of every tape that the
+// sync would write to the database, and the IDs of all tapes that are listed in the
+// inventory spreadsheet (including those that won't be synced due to warnings)
+func Compute(current []Tape, synced []Tape, listedTapeIds []int) Diff {
+	currentById := make(map[int]*Tape, len(current))
+	for i := range current {
+		currentById[current[i].Id] = &current[i]
+	}
+	listed := make(map[int]struct{}, len(listedTapeIds))
+	for _, tapeId := range listedTapeIds {
+		listed[tapeId] = struct{}{}
+	}
+
+	// Compare every tape that would be synced against its existing state, if any
+	tapes := make([]TapeDiff, 0)
+	for i := range synced {
+		d := diffTape(currentById[synced[i].Id], &synced[i])
+		if d.Change != ChangeTypeChanged || !d.isEmpty() {
+			tapes = append(tapes, d)
+		}
+	}
+
+	// Any active tape that's not listed in the spreadsheet would be retired, unless the
+	// spreadsheet listed no tapes at all
+	if len(listed) > 0 {
+		for i := range current {
+			if _, ok := listed[current[i].Id]; !ok && !current[i].Retired {
+				tapes = append(tapes, TapeDiff{
+					TapeId: current[i].Id,
+					Title:  current[i].Title,
+					Change: ChangeTypeRetired,
+				})
+			}
+		}
+	}
+
+	sort.Slice(tapes, func(i, j int) bool {
+		return tapes[i].TapeId < tapes[j].TapeId
+	})
+	return Diff{
+		Tapes:    tapes,
+		Warnings: []string{},
+	}
+}
+
+// diffTape compares the existing state of a tape (nil if the tape is not yet in the
+// database) against its new state
+func diffTape(existing *Tape, synced *Tape) TapeDiff {
+	change := ChangeTypeChanged
+	if existing == nil {
+		change = ChangeTypeAdded
+		existing = &Tape{}
+	} else if existing.Retired {
+		change = ChangeTypeRestored
+	}
+	return TapeDiff{
+		TapeId:      synced.Id,
+		Title:       synced.Title,
+		Change:      change,
+		Fields:      diffFields(existing, synced),
+		TagsAdded:   difference(synced.Tags, existing.Tags),
+		TagsRemoved: difference(existing.Tags, synced.Tags),
+		Images:      diffImages(existing.Images, synced.Images),
+	}
+}
+
+// diffFields returns a FieldChange for every scalar value that differs between two
+// states of the same tape
+func diffFields(existing *Tape, synced *Tape) []FieldChange {
+	fields := make([]FieldChange, 0)
+	if existing.Title != synced.Title {
+		fields = append(fields, FieldChange{"title", existing.Title, synced.Title})
+	}
+	if existing.Year != synced.Year {
+		fields = append(fields, FieldChange{"year", existing.Year, synced.Year})
+	}
+	if existing.Runtime != synced.Runtime {
+		fields = append(fields, FieldChange{"runtime", existing.Runtime, synced.Runtime})
+	}
+	if existing.Contributor != synced.Contributor {
+		fields = append(fields, FieldChange{"contributor", existing.Contributor, synced.Contributor})
+	}
+	if !thumbnailsEqual(existing.Thumbnail, synced.Thumbnail) {
+		fields = append(fields, FieldChange{"thumbnail", formatThumbnail(existing.Thumbnail), formatThumbnail(synced.Thumbnail)})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// diffImages returns an ImageDiff for every gallery image that would be added, changed,
+// or removed, sorted by index
+func diffImages(existing []Image, synced []Image) []ImageDiff {
+	existingByIndex := make(map[int]Image, len(existing))
+	for _, image := range existing {
+		existingByIndex[image.Index] = image
+	}
+	syncedByIndex := make(map[int]Image, len(synced))
+	for _, image := range synced {
+		syncedByIndex[image.Index] = image
+	}
+
+	images := make([]ImageDiff, 0)
+	for i := range synced {
+		newImage := synced[i]
+		oldImage, ok := existingByIndex[newImage.Index]
+		if !ok {
+			images = append(images, ImageDiff{
+				Index:  newImage.Index,
+				Change: ChangeTypeAdded,
+				New:    &newImage,
+			})
+		} else if !oldImage.equals(&newImage) {
+			images = append(images, ImageDiff{
+				Index:  newImage.Index,
+				Change: ChangeTypeChanged,
+				Old:    &oldImage,
+				New:    &newImage,
+			})
+		}
+	}
+	for i := range existing {
+		oldImage := existing[i]
+		if _, ok := syncedByIndex[oldImage.Index]; !ok {
+			images = append(images, ImageDiff{
+				Index:  oldImage.Index,
+				Change: ChangeTypeRemoved,
+				Old:    &oldImage,
+			})
+		}
+	}
+
+	if len(images) == 0 {
+		return nil
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Index < images[j].Index
+	})
+	return images
+}
+
+// equals returns true if both images have the same details and the same variants
+func (i *Image) equals(other *Image) bool {
+	return i.Index == other.Index &&
+		i.Color == other.Color &&
+		i.Width == other.Width &&
+		i.Height == other.Height &&
+		i.Rotated == other.Rotated &&
+		i.Blurhash == other.Blurhash &&
+		len(difference(i.Variants, other.Variants)) == 0 &&
+		len(difference(other.Variants, i.Variants)) == 0
+}
+
+// thumbnailsEqual returns true if both thumbnails are absent, or if both are present
+// with the same details and contents
+func thumbnailsEqual(a *Thumbnail, b *Thumbnail) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// difference returns the sorted set of strings that are in a but not in b
+func difference(a []string, b []string) []string {
+	inB := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		inB[s] = struct{}{}
+	}
+	result := make([]string, 0)
+	for _, s := range a {
+		if _, ok := inB[s]; !ok {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isEmpty returns true if a TapeDiff does not describe any actual changes
+func (d *TapeDiff) isEmpty() bool {
+	return len(d.Fields) == 0 && len(d.TagsAdded) == 0 && len(d.TagsRemoved) == 0 && len(d.Images) == 0
+}
@@ -0,0 +1,256 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compute(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       []Tape
+		synced        []Tape
+		listedTapeIds []int
+		want          []TapeDiff
+	}{
+		{
+			"empty database and empty spreadsheet results in no changes",
+			nil,
+			nil,
+			nil,
+			[]TapeDiff{},
+		},
+		{
+			"unchanged tapes are omitted",
+			[]Tape{
+				{Id: 1, Title: "Tape one", Year: 1991, Tags: []string{"b", "a"}, Images: []Image{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}}},
+			},
+			[]Tape{
+				{Id: 1, Title: "Tape one", Year: 1991, Tags: []string{"a", "b"}, Images: []Image{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}}},
+			},
+			[]int{1},
+			[]TapeDiff{},
+		},
+		{
+			"new tapes are added",
+			nil,
+			[]Tape{
+				{Id: 1, Title: "Tape one", Year: 1991, Tags: []string{"fitness"}, Images: []Image{{Index: 0, Color: "#ffffff", Width: 100, Height: 200}}},
+			},
+			[]int{1},
+			[]TapeDiff{
+				{
+					TapeId: 1,
+					Title:  "Tape one",
+					Change: ChangeTypeAdded,
+					Fields: []FieldChange{
+						{"title", "", "Tape one"},
+						{"year", 0, 1991},
+					},
+					TagsAdded: []string{"fitness"},
+					Images: []ImageDiff{
+						{Index: 0, Change: ChangeTypeAdded, New: &Image{Index: 0, Color: "#ffffff", Width: 100, Height: 200}},
+					},
+				},
+			},
+		},
+		{
+			"replaced or missing thumbnails are reported",
+			[]Tape{
+				{Id: 1, Title: "Tape one", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, ContentHash: "aaaaaaaaaaaaaaaa"}},
+				{Id: 2, Title: "Tape two", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, ContentHash: "aaaaaaaaaaaaaaaa"}},
+				{Id: 3, Title: "Tape three"},
+				{Id: 4, Title: "Tape four", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, ContentHash: "dddddddddddddddd"}},
+			},
+			[]Tape{
+				{Id: 1, Title: "Tape one", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, ContentHash: "aaaaaaaaaaaaaaaa"}},
+				{Id: 2, Title: "Tape two", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, ContentHash: "bbbbbbbbbbbbbbbb"}},
+				{Id: 3, Title: "Tape three", Thumbnail: &Thumbnail{Color: "#000000", Width: 256, Height: 128, ContentHash: "cccccccccccccccc"}},
+				{Id: 4, Title: "Tape four", Thumbnail: &Thumbnail{Color: "#ffffff", Width: 128, Height: 256, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ", ContentHash: "dddddddddddddddd"}},
+			},
+			[]int{1, 2, 3, 4},
+			[]TapeDiff{
+				{
+					TapeId: 2,
+					Title:  "Tape two",
+					Change: ChangeTypeChanged,
+					Fields: []FieldChange{
+						{"thumbnail", "128 x 256 #ffffff (aaaaaaaaaaaa)", "128 x 256 #ffffff (bbbbbbbbbbbb)"},
+					},
+				},
+				{
+					TapeId: 3,
+					Title:  "Tape three",
+					Change: ChangeTypeChanged,
+					Fields: []FieldChange{
+						{"thumbnail", "(none)", "256 x 128 #000000 (cccccccccccc)"},
+					},
+				},
+				{
+					TapeId: 4,
+					Title:  "Tape four",
+					Change: ChangeTypeChanged,
+					Fields: []FieldChange{
+						{"thumbnail", "128 x 256 #ffffff (dddddddddddd)", "128 x 256 #ffffff (dddddddddddd) (blurhash: T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ)"},
+					},
+				},
+			},
+		},
+		{
+			"changes to fields, tags, and images are reported",
+			[]Tape{
+				{
+					Id:          1,
+					Title:       "Tape on",
+					Year:        1991,
+					Runtime:     60,
+					Contributor: "1234",
+					Tags:        []string{"fitness", "christmas"},
+					Images: []Image{
+						{Index: 0, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"webp@400w", "avif@400w"}},
+						{Index: 1, Color: "#ffffff", Width: 100, Height: 200},
+						{Index: 2, Color: "#ffffff", Width: 100, Height: 200},
+						{Index: 4, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"webp@400w"}},
+						{Index: 5, Color: "#ffffff", Width: 100, Height: 200, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"},
+					},
+				},
+			},
+			[]Tape{
+				{
+					Id:          1,
+					Title:       "Tape one",
+					Year:        1991,
+					Runtime:     90,
+					Contributor: "",
+					Tags:        []string{"fitness", "instructional"},
+					Images: []Image{
+						{Index: 0, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"avif@400w", "webp@400w"}},
+						{Index: 1, Color: "#000000", Width: 100, Height: 200, Rotated: true},
+						{Index: 3, Color: "#ffffff", Width: 100, Height: 200},
+						{Index: 4, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"webp@400w", "webp@800w"}},
+						{Index: 5, Color: "#ffffff", Width: 100, Height: 200, Blurhash: "T00000fQfQfQfQfQfQfQfQfQfQfQ"},
+					},
+				},
+			},
+			[]int{1},
+			[]TapeDiff{
+				{
+					TapeId: 1,
+					Title:  "Tape one",
+					Change: ChangeTypeChanged,
+					Fields: []FieldChange{
+						{"title", "Tape on", "Tape one"},
+						{"runtime", 60, 90},
+						{"contributor", "1234", ""},
+					},
+					TagsAdded:   []string{"instructional"},
+					TagsRemoved: []string{"christmas"},
+					Images: []ImageDiff{
+						{
+							Index:  1,
+							Change: ChangeTypeChanged,
+							Old:    &Image{Index: 1, Color: "#ffffff", Width: 100, Height: 200},
+							New:    &Image{Index: 1, Color: "#000000", Width: 100, Height: 200, Rotated: true},
+						},
+						{Index: 2, Change: ChangeTypeRemoved, Old: &Image{Index: 2, Color: "#ffffff", Width: 100, Height: 200}},
+						{Index: 3, Change: ChangeTypeAdded, New: &Image{Index: 3, Color: "#ffffff", Width: 100, Height: 200}},
+						{
+							Index:  4,
+							Change: ChangeTypeChanged,
+							Old:    &Image{Index: 4, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"webp@400w"}},
+							New:    &Image{Index: 4, Color: "#ffffff", Width: 100, Height: 200, Variants: []string{"webp@400w", "webp@800w"}},
+						},
+						{
+							Index:  5,
+							Change: ChangeTypeChanged,
+							Old:    &Image{Index: 5, Color: "#ffffff", Width: 100, Height: 200, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"},
+							New:    &Image{Index: 5, Color: "#ffffff", Width: 100, Height: 200, Blurhash: "T00000fQfQfQfQfQfQfQfQfQfQfQ"},
+						},
+					},
+				},
+			},
+		},
+		{
+			"tapes missing from the spreadsheet are retired",
+			[]Tape{
+				{Id: 1, Title: "Tape one"},
+				{Id: 2, Title: "Tape two"},
+				{Id: 3, Title: "Tape three", Retired: true},
+				{Id: 4, Title: "Tape four"},
+			},
+			[]Tape{
+				{Id: 1, Title: "Tape one"},
+			},
+			[]int{1, 4},
+			[]TapeDiff{
+				{TapeId: 2, Title: "Tape two", Change: ChangeTypeRetired},
+			},
+		},
+		{
+			"nothing is retired if the spreadsheet lists no tapes",
+			[]Tape{
+				{Id: 1, Title: "Tape one"},
+			},
+			nil,
+			nil,
+			[]TapeDiff{},
+		},
+		{
+			"retired tapes that reappear are restored",
+			[]Tape{
+				{Id: 1, Title: "Tape one", Retired: true},
+			},
+			[]Tape{
+				{Id: 1, Title: "Tape one"},
+			},
+			[]int{1},
+			[]TapeDiff{
+				{TapeId: 1, Title: "Tape one", Change: ChangeTypeRestored},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.current, tt.synced, tt.listedTapeIds)
+			assert.Equal(t, tt.want, got.Tapes)
+		})
+	}
+}
+
+func Test_Diff_WriteText(t *testing.T) {
+	d := Diff{
+		Tapes: []TapeDiff{
+			{
+				TapeId: 1,
+				Title:  "Tape one",
+				Change: ChangeTypeChanged,
+				Fields: []FieldChange{
+					{"title", "Tape on", "Tape one"},
+					{"year", 0, 1991},
+					{"thumbnail", "(none)", "128 x 256 #ffffff (e3b0c44298fc)"},
+				},
+				TagsAdded: []string{"fitness", "instructional"},
+				Images: []ImageDiff{
+					{Index: 0, Change: ChangeTypeAdded, New: &Image{Index: 0, Color: "#ffffff", Width: 100, Height: 200, Rotated: true, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ", Variants: []string{"webp@800w", "avif@400w"}}},
+				},
+			},
+			{TapeId: 2, Title: "Tape two", Change: ChangeTypeRetired},
+		},
+		Warnings: []string{"Tape 3 has no gallery images; ignoring it."},
+	}
+	var buf bytes.Buffer
+	err := d.WriteText(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `Tape 1 (Tape one): changed
+  title: "Tape on" -> "Tape one"
+  year: 0 -> 1991
+  thumbnail: "(none)" -> "128 x 256 #ffffff (e3b0c44298fc)"
+  tags added: fitness, instructional
+  image 0 added: (none) -> 100 x 200 #ffffff rotated (blurhash: T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ) (variants: avif@400w, webp@800w)
+Tape 2 (Tape two): retired
+Encountered 1 warning(s):
+- Tape 3 has no gallery images; ignoring it.
+`, buf.String())
+}
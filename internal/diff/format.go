@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteText writes a human-readable summary of the diff to the given writer
+func (d *Diff) WriteText(w io.Writer) error {
+	lines := make([]string, 0, len(d.Tapes)*2)
+	if d.IsEmpty() {
+		lines = append(lines, "No changes.")
+	}
+	for _, tape := range d.Tapes {
+		lines = append(lines, fmt.Sprintf("Tape %d (%s): %s", tape.TapeId, tape.Title, tape.Change))
+		for _, field := range tape.Fields {
+			lines = append(lines, fmt.Sprintf("  %s: %v -> %v", field.Name, formatValue(field.Old), formatValue(field.New)))
+		}
+		if len(tape.TagsAdded) > 0 {
+			lines = append(lines, fmt.Sprintf("  tags added: %s", strings.Join(tape.TagsAdded, ", ")))
+		}
+		if len(tape.TagsRemoved) > 0 {
+			lines = append(lines, fmt.Sprintf("  tags removed: %s", strings.Join(tape.TagsRemoved, ", ")))
+		}
+		for _, image := range tape.Images {
+			lines = append(lines, fmt.Sprintf("  image %d %s: %s -> %s", image.Index, image.Change, formatImage(image.Old), formatImage(image.New)))
+		}
+	}
+	if len(d.Warnings) > 0 {
+		lines = append(lines, fmt.Sprintf("Encountered %d warning(s):", len(d.Warnings)))
+		for _, warning := range d.Warnings {
+			lines = append(lines, fmt.Sprintf("- %s", warning))
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatValue renders an old or new field value for display, quoting strings so that
+// empty values are still legible
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// thumbnailHashLength is the number of hex digits of a thumbnail's content hash that
+// are displayed, which is plenty to tell two versions of a thumbnail apart
+const thumbnailHashLength = 12
+
+// formatThumbnail renders the details of a thumbnail on a single line, or a placeholder
+// if the thumbnail does not exist
+func formatThumbnail(thumbnail *Thumbnail) string {
+	if thumbnail == nil {
+		return "(none)"
+	}
+	hash := thumbnail.ContentHash
+	if len(hash) > thumbnailHashLength {
+		hash = hash[:thumbnailHashLength]
+	}
+	flag := ""
+	if thumbnail.Blurhash != "" {
+		flag = fmt.Sprintf(" (blurhash: %s)", thumbnail.Blurhash)
+	}
+	return fmt.Sprintf("%d x %d %s (%s)%s", thumbnail.Width, thumbnail.Height, thumbnail.Color, hash, flag)
+}
+
+// formatImage renders the metadata for an image on a single line, or a placeholder if
+// the image does not exist
+func formatImage(image *Image) string {
+	if image == nil {
+		return "(none)"
+	}
+	flag := ""
+	if image.Rotated {
+		flag = " rotated"
+	}
+	if image.Blurhash != "" {
+		flag += fmt.Sprintf(" (blurhash: %s)", image.Blurhash)
+	}
+	if len(image.Variants) > 0 {
+		variants := append([]string{}, image.Variants...)
+		sort.Strings(variants)
+		flag += fmt.Sprintf(" (variants: %s)", strings.Join(variants, ", "))
+	}
+	return fmt.Sprintf("%d x %d %s%s", image.Width, image.Height, image.Color, flag)
+}
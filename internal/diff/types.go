@@ -0,0 +1,110 @@
+package diff
+
+// Tape is the state of a single tape, either as it's currently recorded in the tapes
+// database or as it would be recorded after a sync
+type Tape struct {
+	// Id is the unique integer ID of the tape
+	Id int
+	// Title is the title of the tape
+	Title string
+	// Year is the publication year of the tape, or 0 if unknown
+	Year int
+	// Runtime is the approximate runtime of the tape in minutes, or 0 if unknown
+	Runtime int
+	// Contributor is the Twitch User ID of the viewer who sent in the tape, if any
+	Contributor string
+	// Retired is true if the tape has been retired from the library; only meaningful
+	// for tapes in the current state of the database
+	Retired bool
+	// Tags is the set of tags that have been applied to the tape, in any order
+	Tags []string
+	// Thumbnail is the tape's thumbnail image, or nil if none has been recorded
+	Thumbnail *Thumbnail
+	// Images is the set of gallery images associated with the tape, in any order
+	Images []Image
+}
+
+// Thumbnail is the state of the thumbnail image associated with a tape
+type Thumbnail struct {
+	Color  string
+	Width  int
+	Height int
+	// Blurhash is the placeholder representation of the thumbnail, if any
+	Blurhash string
+	// ContentHash is the hex-encoded SHA-256 digest of the thumbnail file
+	ContentHash string
+}
+
+// Image is the state of a single gallery image associated with a tape
+type Image struct {
+	Index   int    `json:"index"`
+	Color   string `json:"color"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Rotated bool   `json:"rotated"`
+	// Blurhash is the placeholder representation of the image, if any
+	Blurhash string `json:"blurhash,omitempty"`
+	// Variants lists the alternate versions of the image, formatted as e.g.
+	// 'webp@800w', in any order
+	Variants []string `json:"variants,omitempty"`
+}
+
+// ChangeType describes how a tape or image would be affected by a sync
+type ChangeType string
+
+const (
+	// ChangeTypeAdded indicates a tape or image that does not yet exist in the database
+	ChangeTypeAdded ChangeType = "added"
+	// ChangeTypeChanged indicates a tape or image whose details would be updated
+	ChangeTypeChanged ChangeType = "changed"
+	// ChangeTypeRemoved indicates an image that would be deleted from the database
+	ChangeTypeRemoved ChangeType = "removed"
+	// ChangeTypeRetired indicates a tape that is no longer listed in the spreadsheet,
+	// which would be retired from the catalog
+	ChangeTypeRetired ChangeType = "retired"
+	// ChangeTypeRestored indicates a previously-retired tape that has reappeared in the
+	// spreadsheet, which would be restored to the catalog
+	ChangeTypeRestored ChangeType = "restored"
+)
+
+// Diff describes all the changes that a sync would make to the tapes database
+type Diff struct {
+	// Tapes lists every tape that would be affected by the sync, sorted by ID
+	Tapes []TapeDiff `json:"tapes"`
+	// Warnings lists any warnings that were encountered while gathering data for the
+	// sync, as human-readable strings
+	Warnings []string `json:"warnings"`
+}
+
+// TapeDiff describes the changes that a sync would make to a single tape
+type TapeDiff struct {
+	TapeId      int           `json:"tapeId"`
+	Title       string        `json:"title"`
+	Change      ChangeType    `json:"change"`
+	Fields      []FieldChange `json:"fields,omitempty"`
+	TagsAdded   []string      `json:"tagsAdded,omitempty"`
+	TagsRemoved []string      `json:"tagsRemoved,omitempty"`
+	Images      []ImageDiff   `json:"images,omitempty"`
+}
+
+// FieldChange describes a change to a single scalar value associated with a tape, e.g.
+// its title or release year
+type FieldChange struct {
+	Name string      `json:"name"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// ImageDiff describes a change to a single gallery image, where Old is nil for an
+// added image and New is nil for a removed image
+type ImageDiff struct {
+	Index  int        `json:"index"`
+	Change ChangeType `json:"change"`
+	Old    *Image     `json:"old,omitempty"`
+	New    *Image     `json:"new,omitempty"`
+}
+
+// IsEmpty returns true if the sync would not change anything
+func (d *Diff) IsEmpty() bool {
+	return len(d.Tapes) == 0
+}
@@ -0,0 +1,138 @@
+// Package imaging derives the details required to render a tape image (its dimensions,
+// dominant color, orientation, and blurhash) from the image's pixel data, so that we
+// don't need to rely on metadata supplied by whoever uploaded the image. It also
+// implements the transformations (rotating and scaling) that are applied to scans
+// before upload.
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io"
+)
+
+// maxSamplesPerAxis limits the number of pixels we'll examine along each axis when
+// computing the dominant color, so that analyzing a large scan stays cheap
+const maxSamplesPerAxis = 200
+
+// fallbackColor is reported as the dominant color of an image with no pixels
+const fallbackColor = "#cccccc"
+
+// Orientation describes the aspect ratio of an image
+type Orientation string
+
+const (
+	// OrientationPortrait indicates an image that's taller than it is wide
+	OrientationPortrait Orientation = "portrait"
+	// OrientationLandscape indicates an image that's wider than it is tall
+	OrientationLandscape Orientation = "landscape"
+	// OrientationSquare indicates an image whose width and height are equal
+	OrientationSquare Orientation = "square"
+)
+
+// AnalysisVersion identifies the set of details included in an Analysis: it must be
+// incremented whenever new details are added, so that cached analyses which lack them
+// can be recomputed
+const AnalysisVersion = 1
+
+// Analysis is the set of details derived from an image's pixel data
+type Analysis struct {
+	// Width is the width of the image in pixels
+	Width int
+	// Height is the height of the image in pixels
+	Height int
+	// Color is the dominant color in the image, as a 6-digit hex string with a hash
+	// prepended, e.g. "#febe99"
+	Color string
+	// Orientation indicates whether the image is portrait, landscape, or square
+	Orientation Orientation
+	// Blurhash is a compact encoding of the image from which a blurred placeholder can
+	// be rendered, as returned by ComputeBlurhash
+	Blurhash string
+	// ContentHash is the hex-encoded SHA-256 digest of the encoded image file, or empty
+	// if the image was analyzed after decoding
+	ContentHash string
+}
+
+// Analyze decodes the JPEG image read from r and returns the details derived from its
+// pixel data, along with a hash of its contents, or an error if the data is not a valid
+// image
+func Analyze(r io.Reader) (*Analysis, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	analysis := AnalyzeImage(img)
+	digest := sha256.Sum256(data)
+	analysis.ContentHash = hex.EncodeToString(digest[:])
+	return analysis, nil
+}
+
+// AnalyzeImage returns the details derived from an already-decoded image
+func AnalyzeImage(img image.Image) *Analysis {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	orientation := OrientationSquare
+	if width > height {
+		orientation = OrientationLandscape
+	} else if height > width {
+		orientation = OrientationPortrait
+	}
+	return &Analysis{
+		Width:       width,
+		Height:      height,
+		Color:       computeDominantColor(img),
+		Orientation: orientation,
+		Blurhash:    ComputeBlurhash(img),
+	}
+}
+
+// computeDominantColor buckets a sampling of the image's pixels into coarse color
+// ranges, then returns the average color of the pixels in the most populous bucket.
+// Unlike a plain average over the whole image, this yields a color that actually
+// appears in the image, rather than a muddy blend of e.g. a bright label on a dark
+// case.
+func computeDominantColor(img image.Image) string {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return fallbackColor
+	}
+	stepX := max(1, (bounds.Dx()+maxSamplesPerAxis-1)/maxSamplesPerAxis)
+	stepY := max(1, (bounds.Dy()+maxSamplesPerAxis-1)/maxSamplesPerAxis)
+
+	// Quantize each sampled pixel to 4 bits per channel, keeping a running total of
+	// the full-precision values in each bucket
+	type bucket struct {
+		r, g, b, n uint64
+	}
+	buckets := make(map[uint16]*bucket)
+	var best *bucket
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			pr, pg, pb, _ := img.At(x, y).RGBA()
+			r, g, b := pr>>8, pg>>8, pb>>8
+			key := uint16(r>>4)<<8 | uint16(g>>4)<<4 | uint16(b>>4)
+			bk, ok := buckets[key]
+			if !ok {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += uint64(r)
+			bk.g += uint64(g)
+			bk.b += uint64(b)
+			bk.n++
+			if best == nil || bk.n > best.n {
+				best = bk
+			}
+		}
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
@@ -0,0 +1,83 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Analyze(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want *Analysis
+	}{
+		{
+			"portrait image",
+			newImage(60, 120, color.RGBA{255, 255, 255, 255}),
+			&Analysis{Width: 60, Height: 120, Color: "#ffffff", Orientation: OrientationPortrait, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"},
+		},
+		{
+			"landscape image",
+			newImage(120, 60, color.RGBA{0, 0, 0, 255}),
+			&Analysis{Width: 120, Height: 60, Color: "#000000", Orientation: OrientationLandscape, Blurhash: "L00000fQfQfQfQfQfQfQfQfQfQfQ"},
+		},
+		{
+			"square image",
+			newImage(80, 80, color.RGBA{0, 0, 0, 255}),
+			&Analysis{Width: 80, Height: 80, Color: "#000000", Orientation: OrientationSquare, Blurhash: "U00000fQfQfQfQfQfQfQfQfQfQfQfQfQfQfQ"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := jpeg.Encode(&buf, tt.img, &jpeg.Options{Quality: 100})
+			assert.NoError(t, err)
+
+			digest := sha256.Sum256(buf.Bytes())
+			want := *tt.want
+			want.ContentHash = hex.EncodeToString(digest[:])
+
+			got, err := Analyze(&buf)
+			assert.NoError(t, err)
+			assert.Equal(t, &want, got)
+			assert.Empty(t, AnalyzeImage(tt.img).ContentHash)
+		})
+	}
+}
+
+func Test_Analyze_invalid(t *testing.T) {
+	got, err := Analyze(bytes.NewReader([]byte("not a jpeg")))
+	assert.ErrorContains(t, err, "failed to decode image")
+	assert.Nil(t, got)
+}
+
+func Test_computeDominantColor(t *testing.T) {
+	// A mostly-red image with a blue stripe should be red, not purple
+	img := newImage(1000, 1000, color.RGBA{200, 10, 10, 255})
+	for y := 0; y < 1000; y++ {
+		for x := 0; x < 300; x++ {
+			img.Set(x, y, color.RGBA{10, 10, 200, 255})
+		}
+	}
+	assert.Equal(t, "#c80a0a", computeDominantColor(img))
+
+	// An empty image should get a neutral fallback color
+	assert.Equal(t, "#cccccc", computeDominantColor(image.NewRGBA(image.Rect(0, 0, 0, 0))))
+}
+
+func newImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
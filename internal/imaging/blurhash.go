@@ -0,0 +1,150 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// blurhashSampleSize is the size to which an image is scaled down before computing its
+// blurhash: the hash only captures a handful of low-frequency components, so examining
+// every pixel of a full-res scan would be wasted effort
+const blurhashSampleSize = 64
+
+// blurhashMaxComponents is the number of components encoded along the longer axis of
+// an image; one fewer is used along the shorter axis
+const blurhashMaxComponents = 4
+
+// blurhashAlphabet is the base83 character set used to encode blurhash strings
+const blurhashAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// ComputeBlurhash returns a blurhash string (see https://blurha.sh) for the given
+// image: a compact representation of the image that can be decoded by the frontend to
+// render a blurred placeholder while the full image loads
+func ComputeBlurhash(img image.Image) string {
+	img = Fit(img, blurhashSampleSize)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	numX, numY := blurhashMaxComponents, blurhashMaxComponents
+	if width > height {
+		numY--
+	} else if height > width {
+		numX--
+	}
+
+	// Convert each pixel to linear RGB up front, since every component needs them all
+	pixels := make([][3]float64, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			pixels = append(pixels, [3]float64{srgbToLinear(r >> 8), srgbToLinear(g >> 8), srgbToLinear(b >> 8)})
+		}
+	}
+
+	// Compute the DCT coefficients for each component, DC first
+	factors := make([][3]float64, 0, numX*numY)
+	for j := 0; j < numY; j++ {
+		for i := 0; i < numX; i++ {
+			normalization := 2.0
+			if i == 0 && j == 0 {
+				normalization = 1.0
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) * math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					pixel := pixels[y*width+x]
+					for c := range factor {
+						factor[c] += basis * pixel[c]
+					}
+				}
+			}
+			scale := normalization / float64(width*height)
+			for c := range factor {
+				factor[c] *= scale
+			}
+			factors = append(factors, factor)
+		}
+	}
+	dc, ac := factors[0], factors[1:]
+
+	var sb strings.Builder
+	sb.WriteString(encodeBase83((numX-1)+(numY-1)*9, 1))
+
+	// Quantize the AC components relative to the largest of them
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, factor := range ac {
+			for _, v := range factor {
+				actualMax = math.Max(actualMax, math.Abs(v))
+			}
+		}
+		quantizedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantizedMax+1) / 166
+		sb.WriteString(encodeBase83(quantizedMax, 1))
+	} else {
+		sb.WriteString(encodeBase83(0, 1))
+	}
+
+	sb.WriteString(encodeBase83(linearToSrgb(dc[0])<<16|linearToSrgb(dc[1])<<8|linearToSrgb(dc[2]), 4))
+	for _, factor := range ac {
+		value := 0
+		for _, v := range factor {
+			quantized := int(math.Max(0, math.Min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+			value = value*19 + quantized
+		}
+		sb.WriteString(encodeBase83(value, 2))
+	}
+	return sb.String()
+}
+
+// ValidateBlurhash returns an error if s is not a well-formed blurhash string, i.e. if
+// it contains characters outside the base83 alphabet or its length doesn't match the
+// number of components it claims to encode
+func ValidateBlurhash(s string) error {
+	if len(s) < 6 {
+		return fmt.Errorf("blurhash must be at least 6 characters long")
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(blurhashAlphabet, c) {
+			return fmt.Errorf("blurhash contains invalid character '%c'", c)
+		}
+	}
+	sizeFlag := strings.IndexByte(blurhashAlphabet, s[0])
+	numX, numY := sizeFlag%9+1, sizeFlag/9+1
+	if expected := 4 + 2*numX*numY; len(s) != expected {
+		return fmt.Errorf("blurhash with %dx%d components must be %d characters long (got %d)", numX, numY, expected, len(s))
+	}
+	return nil
+}
+
+func encodeBase83(value int, length int) string {
+	result := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		result[i] = blurhashAlphabet[value%83]
+		value /= 83
+	}
+	return string(result)
+}
+
+func srgbToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ComputeBlurhash(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want string
+	}{
+		{
+			"portrait image has 3x4 components",
+			newImage(600, 1200, color.RGBA{255, 255, 255, 255}),
+			"T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ",
+		},
+		{
+			"landscape image has 4x3 components",
+			newImage(1200, 600, color.RGBA{0, 0, 0, 255}),
+			"L00000fQfQfQfQfQfQfQfQfQfQfQ",
+		},
+		{
+			"square image has 4x4 components",
+			newImage(10, 10, color.RGBA{255, 0, 0, 255}),
+			"UWTI:j|cfQ|c|csUfQsUfQfQfQfQ|csUfQsU",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeBlurhash(tt.img)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, ValidateBlurhash(got))
+		})
+	}
+}
+
+func Test_ComputeBlurhash_gradient(t *testing.T) {
+	// An image that's black on the left and white on the right should have a mid-gray
+	// DC component and a nonzero horizontal AC component
+	img := newImage(200, 400, color.RGBA{0, 0, 0, 255})
+	for y := 0; y < 400; y++ {
+		for x := 100; x < 200; x++ {
+			img.Set(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	got := ComputeBlurhash(img)
+	assert.NoError(t, ValidateBlurhash(got))
+	assert.Equal(t, "T", got[:1])
+	assert.NotEqual(t, "0", got[1:2])
+	assert.NotEqual(t, "fQ", got[6:8])
+}
+
+func Test_ValidateBlurhash(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		wantErr string
+	}{
+		{"valid 4x3 blurhash", "LEHV6nWB2yk8pyo0adR*.7kCMdnj", ""},
+		{"valid 1x1 blurhash", "00TSUA", ""},
+		{"too short", "00TSU", "blurhash must be at least 6 characters long"},
+		{"invalid character", "LEHV6nWB2yk8pyo0adR*.7kCMdn!", "blurhash contains invalid character '!'"},
+		{"length mismatch", "LEHV6nWB2yk8pyo0adR*.7kCMd", "blurhash with 4x3 components must be 28 characters long (got 26)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlurhash(tt.s)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
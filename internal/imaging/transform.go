@@ -0,0 +1,70 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+)
+
+// RotateCounterClockwise returns a copy of the given image, rotated 90 degrees
+// counter-clockwise: this is how landscape scans are stored, so that every gallery
+// image has a vertical aspect ratio
+func RotateCounterClockwise(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	result := image.NewRGBA(image.Rect(0, 0, height, width))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// The top-right corner of the source becomes the top-left of the result
+			result.Set(y, width-1-x, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return result
+}
+
+// Fit returns a copy of the given image, scaled down (preserving its aspect ratio) so
+// that its longest side is no larger than maxSize pixels. Images that already fit are
+// copied as-is, without being scaled up.
+func Fit(img image.Image, maxSize int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := srcWidth, srcHeight
+	if srcWidth > maxSize || srcHeight > maxSize {
+		if srcWidth >= srcHeight {
+			dstWidth = maxSize
+			dstHeight = max(1, (srcHeight*maxSize+srcWidth/2)/srcWidth)
+		} else {
+			dstHeight = maxSize
+			dstWidth = max(1, (srcWidth*maxSize+srcHeight/2)/srcHeight)
+		}
+	}
+
+	// Each destination pixel is the average of the block of source pixels that it
+	// covers, which avoids the aliasing we'd get from simply sampling one source pixel
+	result := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for dy := 0; dy < dstHeight; dy++ {
+		y0 := bounds.Min.Y + dy*srcHeight/dstHeight
+		y1 := max(y0+1, bounds.Min.Y+(dy+1)*srcHeight/dstHeight)
+		for dx := 0; dx < dstWidth; dx++ {
+			x0 := bounds.Min.X + dx*srcWidth/dstWidth
+			x1 := max(x0+1, bounds.Min.X+(dx+1)*srcWidth/dstWidth)
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(x, y).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			result.Set(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return result
+}
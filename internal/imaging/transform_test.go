@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RotateCounterClockwise(t *testing.T) {
+	// A 3x2 image with a red pixel in the top-right corner should become a 2x3 image
+	// with the red pixel in the top-left corner
+	red := color.RGBA{255, 0, 0, 255}
+	img := newImage(3, 2, color.RGBA{0, 0, 0, 255})
+	img.Set(2, 0, red)
+
+	got := RotateCounterClockwise(img)
+	assert.Equal(t, image.Rect(0, 0, 2, 3), got.Bounds())
+	assert.Equal(t, red, got.At(0, 0))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, got.At(1, 2))
+}
+
+func Test_Fit(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		maxSize    int
+		wantWidth  int
+		wantHeight int
+	}{
+		{"portrait image is scaled to fit height", 700, 1500, 300, 140, 300},
+		{"landscape image is scaled to fit width", 1500, 700, 300, 300, 140},
+		{"small image is not scaled up", 100, 200, 300, 100, 200},
+		{"extreme aspect ratio keeps at least one pixel", 1000, 2, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fit(newImage(tt.width, tt.height, color.RGBA{10, 20, 30, 255}), tt.maxSize)
+			assert.Equal(t, image.Rect(0, 0, tt.wantWidth, tt.wantHeight), got.Bounds())
+			assert.Equal(t, color.RGBA{10, 20, 30, 255}, got.At(0, 0))
+		})
+	}
+}
+
+func Test_Fit_averagesPixels(t *testing.T) {
+	// Scaling a black-and-white checkerboard down to a single pixel should yield gray
+	img := newImage(2, 2, color.RGBA{0, 0, 0, 255})
+	img.Set(0, 0, color.RGBA{255, 255, 255, 255})
+	img.Set(1, 1, color.RGBA{255, 255, 255, 255})
+	got := Fit(img, 1)
+	assert.Equal(t, color.RGBA{127, 127, 127, 255}, got.At(0, 0))
+}
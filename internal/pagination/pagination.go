@@ -0,0 +1,46 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ParseLimit parses the 'limit' parameter for a paginated request, returning
+// defaultLimit if no limit is specified, or an error if the supplied value is not an
+// integer between 1 and maxLimit
+func ParseLimit(values url.Values, defaultLimit int, maxLimit int) (int, error) {
+	limitStr := values.Get("limit")
+	if limitStr == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return 0, fmt.Errorf("'limit' must be an integer between 1 and %d", maxLimit)
+	}
+	return limit, nil
+}
+
+// EncodeCursor returns an opaque string that can be passed back to the same endpoint
+// as the 'cursor' parameter in order to resume a paginated listing. The cursor value
+// should identify the last item on the current page by its sort key, so that the next
+// page is unaffected by items being added or removed in the meantime.
+func EncodeCursor(cursor any) string {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		panic(fmt.Sprintf("cursor of type %T is not serializable: %v", cursor, err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a value previously returned by EncodeCursor into cursor, which
+// must be a pointer to a value of the same type that was encoded
+func DecodeCursor(s string, cursor any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, cursor)
+}
@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    int
+		wantErr string
+	}{
+		{
+			"default is used if no limit is supplied",
+			"",
+			20,
+			"",
+		},
+		{
+			"limit is parsed",
+			"limit=50",
+			50,
+			"",
+		},
+		{
+			"limit must be an integer",
+			"limit=lots",
+			0,
+			"'limit' must be an integer between 1 and 100",
+		},
+		{
+			"limit must be positive",
+			"limit=0",
+			0,
+			"'limit' must be an integer between 1 and 100",
+		},
+		{
+			"limit is capped",
+			"limit=101",
+			0,
+			"'limit' must be an integer between 1 and 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			assert.NoError(t, err)
+			got, err := ParseLimit(values, 20, 100)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_DecodeCursor(t *testing.T) {
+	type cursor struct {
+		Time time.Time `json:"time"`
+		Id   int       `json:"id"`
+	}
+	want := cursor{
+		Time: time.Date(2023, 10, 1, 12, 0, 0, 123456000, time.UTC),
+		Id:   125,
+	}
+	var got cursor
+	err := DecodeCursor(EncodeCursor(want), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	err = DecodeCursor("not a cursor!", &got)
+	assert.Error(t, err)
+
+	err = DecodeCursor(EncodeCursor("a string"), &got)
+	assert.Error(t, err)
+}
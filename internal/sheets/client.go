@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -27,12 +28,13 @@ type Client interface {
 }
 
 // NewClient returns a Client that will fetch data from an actual spreadsheet in Google
-// sheets
-func NewClient(sheetsApiKey string, spreadsheetId string) Client {
+// sheets, logging each request it makes to progress
+func NewClient(sheetsApiKey string, spreadsheetId string, progress io.Writer) Client {
 	return &client{
 		sheetsApiKey:  sheetsApiKey,
 		spreadsheetId: spreadsheetId,
 		sheetName:     SheetName,
+		progress:      progress,
 	}
 }
 
@@ -42,6 +44,7 @@ type client struct {
 	sheetsApiKey  string
 	spreadsheetId string
 	sheetName     string
+	progress      io.Writer
 }
 
 // errorResult is the payload that the Sheets API returns to provide more details about
@@ -62,7 +65,7 @@ type errorData struct {
 func (c *client) GetValues(ctx context.Context) (*GetValuesResult, error) {
 	// Build a request to the Google Sheets API to get the full contents of our desired sheet
 	url := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values/%s", c.spreadsheetId, c.sheetName)
-	fmt.Printf("> GET %s\n", url)
+	fmt.Fprintf(c.progress, "> GET %s\n", url)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -75,7 +78,7 @@ func (c *client) GetValues(ctx context.Context) (*GetValuesResult, error) {
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("< %d\n", res.StatusCode)
+	fmt.Fprintf(c.progress, "< %d\n", res.StatusCode)
 	if err := handleRequestError(res); err != nil {
 		return nil, err
 	}
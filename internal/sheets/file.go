@@ -0,0 +1,56 @@
+package sheets
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewFileClient returns a Client that reads values from a local file that's been
+// exported from the inventory spreadsheet, in the same tabular layout: the format is
+// determined by the file extension, which must be .csv or .xlsx. The file is read anew
+// on every call to GetValues.
+func NewFileClient(path string) (Client, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return &csvClient{path: path}, nil
+	case ".xlsx":
+		return &xlsxClient{path: path, sheetName: SheetName}, nil
+	}
+	return nil, fmt.Errorf("unsupported inventory file format '%s' (must be .csv or .xlsx)", filepath.Ext(path))
+}
+
+// csvClient implements sheets.Client by reading values from a CSV file
+type csvClient struct {
+	path string
+}
+
+func (c *csvClient) GetValues(ctx context.Context) (*GetValuesResult, error) {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Rows may have differing numbers of values, as with the Sheets API, which omits
+	// trailing empty cells
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	values, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", c.path, err)
+	}
+	return &GetValuesResult{
+		Range:          filepath.Base(c.path),
+		MajorDimension: "ROWS",
+		Values:         values,
+	}, nil
+}
+
+var _ Client = (*csvClient)(nil)
@@ -0,0 +1,272 @@
+package sheets
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewFileClient(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "inventory.csv"), "id,title\n")
+	writeFile(t, filepath.Join(dir, "inventory.ods"), "")
+
+	c, err := NewFileClient(filepath.Join(dir, "inventory.csv"))
+	assert.NoError(t, err)
+	assert.IsType(t, &csvClient{}, c)
+
+	_, err = NewFileClient(filepath.Join(dir, "inventory.ods"))
+	assert.EqualError(t, err, "unsupported inventory file format '.ods' (must be .csv or .xlsx)")
+
+	_, err = NewFileClient(filepath.Join(dir, "missing.csv"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_csvClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.csv")
+	writeFile(t, path, "id,title,year,runtime,contributor\n"+
+		"1,\"Tape one, with a comma\",1991,60\n"+
+		"\n"+
+		"2,Tape two,,,\n")
+
+	c := &csvClient{path: path}
+	result, err := c.GetValues(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "ROWS", result.MajorDimension)
+	assert.Equal(t, [][]string{
+		{"id", "title", "year", "runtime", "contributor"},
+		{"1", "Tape one, with a comma", "1991", "60"},
+		{"2", "Tape two", "", "", ""},
+	}, result.Values)
+
+	tapes, warnings, err := ListTapes(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, []Tape{
+		{Id: 1, Title: "Tape one, with a comma", Year: 1991, Runtime: 60, Tags: []string{}},
+		{Id: 2, Title: "Tape two", Tags: []string{}},
+	}, tapes)
+}
+
+func Test_xlsxClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.xlsx")
+	writeXlsx(t, path, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Notes" sheetId="1" r:id="rId1"/>
+    <sheet name="Tapes" sheetId="2" r:id="rId2"/>
+  </sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si><t>id</t></si>
+  <si><t>title</t></si>
+  <si><t>year</t></si>
+  <si><t>runtime</t></si>
+  <si><t>contributor</t></si>
+  <si><r><t>Tape </t></r><r><rPr><b/></rPr><t>one</t></r></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>Not the tapes</t></is></c></row></sheetData>
+</worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1">
+      <c r="A1" t="s"><v>0</v></c>
+      <c r="B1" t="s"><v>1</v></c>
+      <c r="C1" t="s"><v>2</v></c>
+      <c r="D1" t="s"><v>3</v></c>
+      <c r="E1" t="s"><v>4</v></c>
+    </row>
+    <row r="2">
+      <c r="A2"><v>1</v></c>
+      <c r="B2" t="s"><v>5</v></c>
+      <c r="C2"><f>1990+1</f><v>1991</v></c>
+      <c r="D2"><v>60</v></c>
+    </row>
+    <row r="4">
+      <c r="A4"><v>2</v></c>
+      <c r="B4" t="inlineStr"><is><t>Tape two</t></is></c>
+      <c r="E4" t="str"><v>someone</v></c>
+    </row>
+  </sheetData>
+</worksheet>`,
+	})
+
+	c := &xlsxClient{path: path, sheetName: SheetName}
+	result, err := c.GetValues(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Tapes", result.Range)
+	assert.Equal(t, [][]string{
+		{"id", "title", "year", "runtime", "contributor"},
+		{"1", "Tape one", "1991", "60"},
+		{},
+		{"2", "Tape two", "", "", "someone"},
+	}, result.Values)
+
+	// Row numbers should be preserved across the empty row, as with the Sheets API
+	tapes, warnings, err := ListTapes(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{
+		{Kind: WarningKindInvalidRow, RowNumber: 3, Message: "'id' value is required"},
+	}, warnings)
+	assert.Equal(t, []Tape{
+		{Id: 1, Title: "Tape one", Year: 1991, Runtime: 60, Tags: []string{}},
+		{Id: 2, Title: "Tape two", Contributor: "someone", Tags: []string{}},
+	}, tapes)
+}
+
+func Test_xlsxClient_numericCells(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.xlsx")
+	writeXlsx(t, path, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>
+    <sheet name="Tapes" sheetId="1" r:id="rId1"/>
+  </sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <sheetData>
+    <row r="1">
+      <c r="A1" t="inlineStr"><is><t>id</t></is></c>
+      <c r="B1" t="inlineStr"><is><t>title</t></is></c>
+      <c r="C1" t="inlineStr"><is><t>year</t></is></c>
+      <c r="D1" t="inlineStr"><is><t>runtime</t></is></c>
+      <c r="E1" t="inlineStr"><is><t>contributor</t></is></c>
+    </row>
+    <row r="2">
+      <c r="A2" t="n"><v>1.0</v></c>
+      <c r="B2" t="inlineStr"><is><t>Tape one</t></is></c>
+      <c r="C2"><v>1991.0</v></c>
+      <c r="D2" t="n"><v>60.0</v></c>
+    </row>
+    <row r="3">
+      <c r="A3"><v>2</v></c>
+      <c r="B3" t="inlineStr"><is><t>Tape two</t></is></c>
+      <c r="C3" t="n"><v>1.991E3</v></c>
+      <c r="D3"><v>1.2E2</v></c>
+    </row>
+    <row r="4">
+      <c r="A4"><v>3</v></c>
+      <c r="B4" t="inlineStr"><is><t>Tape three</t></is></c>
+      <c r="C4"><v>1989</v></c>
+      <c r="D4"><v>90.5</v></c>
+    </row>
+  </sheetData>
+</worksheet>`,
+	})
+
+	c := &xlsxClient{path: path, sheetName: SheetName}
+	result, err := c.GetValues(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "title", "year", "runtime", "contributor"},
+		{"1", "Tape one", "1991", "60"},
+		{"2", "Tape two", "1991", "120"},
+		{"3", "Tape three", "1989", "90.5"},
+	}, result.Values)
+
+	// Integral values should parse as they would from the Sheets API, while a
+	// fractional runtime is still rejected
+	tapes, warnings, err := ListTapes(context.Background(), c)
+	assert.NoError(t, err)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, 4, warnings[0].RowNumber)
+	}
+	assert.Equal(t, []Tape{
+		{Id: 1, Title: "Tape one", Year: 1991, Runtime: 60, Tags: []string{}},
+		{Id: 2, Title: "Tape two", Year: 1991, Runtime: 120, Tags: []string{}},
+	}, tapes)
+}
+
+func Test_formatXlsxNumber(t *testing.T) {
+	tests := []struct {
+		v    string
+		want string
+	}{
+		{"1991", "1991"},
+		{"1991.0", "1991"},
+		{"1.991E3", "1991"},
+		{"1.991e+3", "1991"},
+		{"-4.0", "-4"},
+		{"90.5", "90.5"},
+		{"2.5E-1", "0.25"},
+		{"", ""},
+		{"#N/A", "#N/A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatXlsxNumber(tt.v))
+		})
+	}
+}
+
+func Test_parseXlsxColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{"A1", 0, false},
+		{"E12", 4, false},
+		{"Z3", 25, false},
+		{"AA3", 26, false},
+		{"AB100", 27, false},
+		{"12", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := parseXlsxColumnIndex(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func writeXlsx(t *testing.T, path string, parts map[string]string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range parts {
+		pw, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s in %s: %v", name, path, err)
+		}
+		if _, err := pw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s in %s: %v", name, path, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
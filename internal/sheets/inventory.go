@@ -4,18 +4,35 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 )
 
 // Warning is a human-readable warning that indicates that there was a problem parsing a
 // row in the spreadsheet
 type Warning struct {
+	// Kind identifies the type of problem that occurred
+	Kind WarningKind
 	// RowNumber is the user-facing row number (i.e. starting at 1 for the heading row,
 	// 2 for the first tape) that indicates where the parsing error occurred
 	RowNumber int
+	// TapeId is the ID of the tape described by the row, or 0 if the row does not have
+	// a valid tape ID
+	TapeId int
 	// Message is the human-readable Message representing the error that occurred
 	Message string
 }
 
+// WarningKind is a machine-readable code identifying the type of problem that caused a
+// row to be rejected
+type WarningKind string
+
+const (
+	// WarningKindInvalidRow indicates that a row could not be parsed to a valid tape
+	WarningKindInvalidRow WarningKind = "invalid_row"
+	// WarningKindDuplicateTapeId indicates that multiple rows have the same tape ID
+	WarningKindDuplicateTapeId WarningKind = "duplicate_tape_id"
+)
+
 func ListTapes(ctx context.Context, c Client) ([]Tape, []Warning, error) {
 	// Fetch the full contents of the Golden VCR Inventory spreadsheet's 'Tapes' sheet
 	result, err := c.GetValues(ctx)
@@ -52,8 +69,15 @@ func ListTapes(ctx context.Context, c Client) ([]Tape, []Warning, error) {
 		values := rowValues(result.Values[i])
 		tape, err := indexMap.parseRow(values)
 		if err != nil {
+			// Identify the tape in question if the ID itself is valid
+			tapeId, _ := strconv.Atoi(values.read(indexMap.idColumnIndex))
+			if tapeId < 0 {
+				tapeId = 0
+			}
 			warnings = append(warnings, Warning{
+				Kind:      WarningKindInvalidRow,
 				RowNumber: i + 1,
+				TapeId:    tapeId,
 				Message:   err.Error(),
 			})
 			continue
@@ -64,7 +88,9 @@ func ListTapes(ctx context.Context, c Client) ([]Tape, []Warning, error) {
 		existing, found := tapesById[tape.Id]
 		if found {
 			warnings = append(warnings, Warning{
+				Kind:      WarningKindDuplicateTapeId,
 				RowNumber: i + 1,
+				TapeId:    tape.Id,
 				Message:   fmt.Sprintf("duplicate tape ID %d: used by both '%s' and '%s'; accepting neither", tape.Id, tape.Title, existing.Title),
 			})
 			// We can't remove the existing tape from the map while we're still
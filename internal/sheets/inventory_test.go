@@ -84,7 +84,9 @@ func Test_ListTapes(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:      WarningKindInvalidRow,
 					RowNumber: 2,
+					TapeId:    1,
 					Message:   "'year' value must be an integer (got '199X')",
 				},
 			},
@@ -107,7 +109,9 @@ func Test_ListTapes(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:      WarningKindDuplicateTapeId,
 					RowNumber: 4,
+					TapeId:    2,
 					Message:   "duplicate tape ID 2: used by both 'Tape three' and 'Tape two'; accepting neither",
 				},
 			},
@@ -0,0 +1,240 @@
+package sheets
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxClient implements sheets.Client by reading values from a single sheet in an
+// Excel (Office Open XML) workbook. Only cell values are read: formulas are ignored in
+// favor of the cached value computed when the file was saved.
+type xlsxClient struct {
+	path      string
+	sheetName string
+}
+
+// The subset of the SpreadsheetML schema that we need in order to read cell values
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RId  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	xlsxRelationships struct {
+		Relationships []struct {
+			Id     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	xlsxSharedStrings struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+	xlsxWorksheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string       `xml:"r,attr"`
+				T  string       `xml:"t,attr"`
+				V  string       `xml:"v"`
+				Is xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	xlsxRichText struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	}
+)
+
+// text returns the plain-text value of a string that may consist of multiple runs
+func (rt *xlsxRichText) text() string {
+	var sb strings.Builder
+	sb.WriteString(rt.T)
+	for _, run := range rt.Runs {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+func (c *xlsxClient) GetValues(ctx context.Context) (*GetValuesResult, error) {
+	z, err := zip.OpenReader(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", c.path, err)
+	}
+	defer z.Close()
+
+	// Find the worksheet we want by name, falling back to the first sheet in the
+	// workbook if there's no sheet with that name
+	var workbook xlsxWorkbook
+	if err := readXlsxPart(&z.Reader, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("workbook %s contains no sheets", c.path)
+	}
+	sheet := workbook.Sheets[0]
+	for _, s := range workbook.Sheets {
+		if s.Name == c.sheetName {
+			sheet = s
+			break
+		}
+	}
+
+	// Resolve the path to that sheet's XML file from its relationship ID
+	var rels xlsxRelationships
+	if err := readXlsxPart(&z.Reader, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.Id == sheet.RId {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+			break
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("workbook %s has no file for sheet '%s'", c.path, sheet.Name)
+	}
+
+	// Most string values are stored in a shared table and referenced by index; a
+	// workbook with no strings may omit this table entirely
+	var sharedStrings xlsxSharedStrings
+	if err := readXlsxPart(&z.Reader, "xl/sharedStrings.xml", &sharedStrings); err != nil && !isMissingXlsxPart(err) {
+		return nil, err
+	}
+	var worksheet xlsxWorksheet
+	if err := readXlsxPart(&z.Reader, sheetPath, &worksheet); err != nil {
+		return nil, err
+	}
+
+	// Build a 2D array of string values, matching the layout returned by the Sheets
+	// API: empty rows are represented as empty arrays, and trailing empty cells are
+	// omitted
+	values := make([][]string, 0, len(worksheet.Rows))
+	for _, row := range worksheet.Rows {
+		// Rows with no values may be omitted from the file entirely
+		rowIndex := len(values)
+		if row.R > 0 {
+			rowIndex = row.R - 1
+		}
+		for len(values) < rowIndex {
+			values = append(values, []string{})
+		}
+
+		rowValues := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			columnIndex := len(rowValues)
+			if cell.R != "" {
+				columnIndex, err = parseXlsxColumnIndex(cell.R)
+				if err != nil {
+					return nil, fmt.Errorf("invalid cell reference in sheet '%s': %w", sheet.Name, err)
+				}
+			}
+			for len(rowValues) <= columnIndex {
+				rowValues = append(rowValues, "")
+			}
+
+			switch cell.T {
+			case "s":
+				index, err := strconv.Atoi(cell.V)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string reference at %s in sheet '%s'", cell.R, sheet.Name)
+				}
+				rowValues[columnIndex] = sharedStrings.Items[index].text()
+			case "inlineStr":
+				rowValues[columnIndex] = cell.Is.text()
+			case "b":
+				if cell.V == "1" {
+					rowValues[columnIndex] = "TRUE"
+				} else {
+					rowValues[columnIndex] = "FALSE"
+				}
+			case "", "n":
+				rowValues[columnIndex] = formatXlsxNumber(cell.V)
+			default:
+				rowValues[columnIndex] = cell.V
+			}
+		}
+		for len(rowValues) > 0 && rowValues[len(rowValues)-1] == "" {
+			rowValues = rowValues[:len(rowValues)-1]
+		}
+		values = append(values, rowValues)
+	}
+
+	return &GetValuesResult{
+		Range:          sheet.Name,
+		MajorDimension: "ROWS",
+		Values:         values,
+	}, nil
+}
+
+// formatXlsxNumber returns the canonical text of a numeric cell value. Numbers are
+// stored as doubles, so a spreadsheet application may write an integer as "1991.0" or
+// "1.991E3": we render such values as "1991", as the Sheets API would. Values that
+// can't be parsed as numbers are returned unchanged.
+func formatXlsxNumber(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return v
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// errMissingXlsxPart is returned by readXlsxPart if the file does not exist
+type errMissingXlsxPart string
+
+func (e errMissingXlsxPart) Error() string {
+	return fmt.Sprintf("workbook has no %s", string(e))
+}
+
+func isMissingXlsxPart(err error) bool {
+	_, ok := err.(errMissingXlsxPart)
+	return ok
+}
+
+// readXlsxPart parses the XML file at the given path within the workbook
+func readXlsxPart(z *zip.Reader, name string, v interface{}) error {
+	f, err := z.Open(name)
+	if err != nil {
+		return errMissingXlsxPart(name)
+	}
+	defer f.Close()
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// parseXlsxColumnIndex returns the zero-based column index from a cell reference such
+// as "C12" or "AB3"
+func parseXlsxColumnIndex(ref string) (int, error) {
+	index := 0
+	numLetters := 0
+	for _, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			index = index*26 + int(r-'A'+1)
+			numLetters++
+		} else {
+			break
+		}
+	}
+	if numLetters == 0 {
+		return -1, fmt.Errorf("'%s' is not a valid cell reference", ref)
+	}
+	return index - 1, nil
+}
+
+var _ Client = (*xlsxClient)(nil)
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
+)
+
+// MetadataCache stores the metadata most recently retrieved for each file in the
+// bucket, so that we can avoid requesting metadata for files that haven't changed
+type MetadataCache interface {
+	// GetCachedMetadata returns cached metadata for any of the given files, keyed by
+	// filename: files without cached metadata are omitted from the result
+	GetCachedMetadata(ctx context.Context, filenames []string) (map[string]CachedMetadata, error)
+	// StoreMetadata records the metadata that was retrieved for the given file, along
+	// with the version of the analysis that was performed on its pixel data (0 if it was
+	// not analyzed) and the results of that analysis (nil if the file was not analyzed
+	// or is not a valid image)
+	StoreMetadata(ctx context.Context, file FileInfo, metadata Metadata, analysisVersion int, analysis *imaging.Analysis) error
+}
+
+// CachedMetadata is the metadata that was retrieved for a file, along with the details
+// that identify the version of the file at that time
+type CachedMetadata struct {
+	ETag            string
+	LastModified    time.Time
+	Metadata        Metadata
+	AnalysisVersion int
+	Analysis        *imaging.Analysis
+}
+
+// isValidFor returns true if the cached metadata is still valid for the given file.
+// We require both the ETag and the modification time to match: copying an object onto
+// itself with new metadata leaves its ETag unchanged, but updates its LastModified.
+func (c *CachedMetadata) isValidFor(file *FileInfo) bool {
+	return c.ETag != "" && c.ETag == file.ETag && c.LastModified.Equal(file.LastModified)
+}
+
+// hasCurrentAnalysis returns true if the cached analysis (which may be nil if the file
+// is not a valid image) was produced by the current version of imaging.Analyze
+func (c *CachedMetadata) hasCurrentAnalysis() bool {
+	return c.AnalysisVersion == imaging.AnalysisVersion
+}
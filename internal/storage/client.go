@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -14,15 +16,33 @@ import (
 // S3-compatible bucket, as a series of string key/value pairs
 type Metadata map[string]string
 
+// FileInfo describes a single object in an S3-compatible bucket, as returned in a
+// listing of the bucket's contents
+type FileInfo struct {
+	// Filename is the object's key
+	Filename string
+	// ETag is an opaque identifier for the object's contents
+	ETag string
+	// Size is the size of the object in bytes
+	Size int64
+	// LastModified is the time at which the object (or its metadata) last changed
+	LastModified time.Time
+}
+
 // Client handles listing files and metadata from an S3-compatible bucket
 type Client interface {
-	ListFilenames(ctx context.Context) ([]string, error)
+	ListFilenames(ctx context.Context) ([]FileInfo, error)
 	GetFileMetadata(ctx context.Context, filename string) (Metadata, error)
+	ReadFile(ctx context.Context, filename string) (io.ReadCloser, error)
 }
 
 // NewClient creates a new storage.Client that uses the AWS S3 client to access a
 // DigitalOcean Spaces bucket
 func NewClient(spacesAccessKeyId, spacesSecretKey, spacesEndpointOrigin, spacesRegionName, spacesBucketName string) (Client, error) {
+	return newClient(spacesAccessKeyId, spacesSecretKey, spacesEndpointOrigin, spacesRegionName, spacesBucketName)
+}
+
+func newClient(spacesAccessKeyId, spacesSecretKey, spacesEndpointOrigin, spacesRegionName, spacesBucketName string) (*client, error) {
 	config := &aws.Config{
 		Credentials:      credentials.NewStaticCredentials(spacesAccessKeyId, spacesSecretKey, ""),
 		Endpoint:         aws.String(fmt.Sprintf("https://%s", spacesEndpointOrigin)),
@@ -46,18 +66,23 @@ type client struct {
 	bucketName string
 }
 
-func (c *client) ListFilenames(ctx context.Context) ([]string, error) {
-	// Prepare a list of filenames as our result
-	filenames := make([]string, 0)
+func (c *client) ListFilenames(ctx context.Context) ([]FileInfo, error) {
+	// Prepare a list of files as our result
+	files := make([]FileInfo, 0)
 	input := &awsS3.ListObjectsV2Input{Bucket: aws.String(c.bucketName)}
 	for {
-		// Get a list of objects in the bucket, and append their keys to our list
+		// Get a list of objects in the bucket, and append their details to our list
 		res, err := c.s3.ListObjectsV2WithContext(ctx, input)
 		if err != nil {
 			return nil, err
 		}
 		for _, obj := range res.Contents {
-			filenames = append(filenames, *obj.Key)
+			files = append(files, FileInfo{
+				Filename:     aws.StringValue(obj.Key),
+				ETag:         aws.StringValue(obj.ETag),
+				Size:         aws.Int64Value(obj.Size),
+				LastModified: aws.TimeValue(obj.LastModified),
+			})
 		}
 
 		// Continue getting paginated results until we've seen all filenames
@@ -67,7 +92,7 @@ func (c *client) ListFilenames(ctx context.Context) ([]string, error) {
 			break
 		}
 	}
-	return filenames, nil
+	return files, nil
 }
 
 func (c *client) GetFileMetadata(ctx context.Context, filename string) (Metadata, error) {
@@ -91,4 +116,15 @@ func (c *client) GetFileMetadata(ctx context.Context, filename string) (Metadata
 	return result, nil
 }
 
+func (c *client) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	r, err := c.s3.GetObjectWithContext(ctx, &awsS3.GetObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(filename),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Body, nil
+}
+
 var _ Client = (*client)(nil)
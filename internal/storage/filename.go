@@ -6,7 +6,27 @@ import (
 	"strconv"
 )
 
-var imageFilenameRegex = regexp.MustCompile(`^(\d{4})_(thumb|[a-z])\.jpg$`)
+var imageFilenameRegex = regexp.MustCompile(`^(\d{4})_(thumb|[a-z]{1,3})\.jpg$`)
+
+// imageVariantFilenameRegex matches the filename of an alternate version of a gallery
+// image, identified by its width in pixels and its file extension
+var imageVariantFilenameRegex = regexp.MustCompile(`^(\d{4})_([a-z]{1,3})@([1-9]\d{0,4})w\.(jpg|webp|avif)$`)
+
+// imageFormatsByExtension maps the file extensions allowed for image variants to the
+// corresponding format
+var imageFormatsByExtension = map[string]ImageFormat{
+	"jpg":  ImageFormatJpeg,
+	"webp": ImageFormatWebp,
+	"avif": ImageFormatAvif,
+}
+
+// maxGallerySuffixLength is the maximum number of letters in the suffix that identifies
+// a gallery image: this keeps gallery suffixes distinct from 'thumb'
+const maxGallerySuffixLength = 3
+
+// MaxGalleryImages is the number of distinct gallery images a tape may have, i.e. the
+// number of suffixes from 'a' through 'zzz'
+const MaxGalleryImages = 26 + 26*26 + 26*26*26
 
 // imageId describes the details of an image file as encoded in the filename
 type imageId struct {
@@ -16,6 +36,9 @@ type imageId struct {
 	imageType ImageType
 	// galleryIndex indicates an image's ordering in the gallery, if type is "gallery"
 	galleryIndex int
+	// variant identifies the alternate version of the gallery image that the file
+	// contains, or nil if the file is the original gallery image
+	variant *ImageVariant
 }
 
 // parseImageFilename parses information about an image from its filename, returning an
@@ -30,22 +53,95 @@ func parseImageFilename(s string) (*imageId, error) {
 				imageType: ImageTypeThumbnail,
 			}, nil
 		}
-		ord := match[2][0]
-		galleryIndex := ord - 'a'
 		return &imageId{
 			tapeId:       tapeId,
 			imageType:    ImageTypeGallery,
-			galleryIndex: int(galleryIndex),
+			galleryIndex: parseGallerySuffix(match[2]),
+		}, nil
+	}
+	match = imageVariantFilenameRegex.FindStringSubmatch(s)
+	if match != nil {
+		tapeId, _ := strconv.Atoi(match[1])
+		width, _ := strconv.Atoi(match[3])
+		return &imageId{
+			tapeId:       tapeId,
+			imageType:    ImageTypeGallery,
+			galleryIndex: parseGallerySuffix(match[2]),
+			variant: &ImageVariant{
+				Format: imageFormatsByExtension[match[4]],
+				Width:  width,
+			},
 		}, nil
 	}
 	return nil, fmt.Errorf("not a valid image filename matching %s", imageFilenameRegex.String())
 }
 
-// GetImageFilename reconstructs the filename associated with an image
-func GetImageFilename(tapeId int, imageType ImageType, galleryIndex int) string {
+// GetTapeImageFilenames returns the names of all files in the given listing that are
+// valid images for the given tape
+func GetTapeImageFilenames(files []FileInfo, tapeId int) []string {
+	filenames := make([]string, 0)
+	for _, file := range files {
+		if imageId, err := parseImageFilename(file.Filename); err == nil && imageId.tapeId == tapeId {
+			filenames = append(filenames, file.Filename)
+		}
+	}
+	return filenames
+}
+
+// GetImageFilename reconstructs the filename associated with an image, returning an
+// error if the gallery index of a gallery image has no valid suffix
+func GetImageFilename(tapeId int, imageType ImageType, galleryIndex int) (string, error) {
 	if imageType == ImageTypeThumbnail {
-		return fmt.Sprintf("%04d_thumb.jpg", tapeId)
+		return fmt.Sprintf("%04d_thumb.jpg", tapeId), nil
+	}
+	suffix, err := formatGallerySuffix(galleryIndex)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d_%s.jpg", tapeId, suffix), nil
+}
+
+// GetImageVariantFilename reconstructs the filename associated with an alternate
+// version of a gallery image, returning an error if the gallery index has no valid
+// suffix
+func GetImageVariantFilename(tapeId int, galleryIndex int, variant ImageVariant) (string, error) {
+	suffix, err := formatGallerySuffix(galleryIndex)
+	if err != nil {
+		return "", err
+	}
+	extension := "jpg"
+	for ext, format := range imageFormatsByExtension {
+		if format == variant.Format {
+			extension = ext
+		}
+	}
+	return fmt.Sprintf("%04d_%s@%dw.%s", tapeId, suffix, variant.Width, extension), nil
+}
+
+// formatGallerySuffix returns the letters that identify the gallery image with the
+// given index, counting like spreadsheet columns: 'a' through 'z' for the first 26
+// images, then 'aa', 'ab', and so on. Indices outside the range of valid suffixes are
+// an error, since any such image would collide with another image's filename.
+func formatGallerySuffix(galleryIndex int) (string, error) {
+	if galleryIndex < 0 || galleryIndex >= MaxGalleryImages {
+		return "", fmt.Errorf("gallery index %d is out of range (a tape may have at most %d gallery images)", galleryIndex, MaxGalleryImages)
+	}
+	n := galleryIndex + 1
+	suffix := make([]byte, 0, maxGallerySuffixLength)
+	for n > 0 {
+		n--
+		suffix = append([]byte{byte('a' + n%26)}, suffix...)
+		n /= 26
+	}
+	return string(suffix), nil
+}
+
+// parseGallerySuffix returns the gallery index identified by the given suffix, which
+// must consist only of lowercase letters; the inverse of formatGallerySuffix
+func parseGallerySuffix(suffix string) int {
+	n := 0
+	for _, c := range suffix {
+		n = n*26 + int(c-'a') + 1
 	}
-	ord := 'a' + min(galleryIndex, 25)
-	return fmt.Sprintf("%04d_%c.jpg", tapeId, ord)
+	return n - 1
 }
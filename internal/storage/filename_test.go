@@ -14,10 +14,25 @@ func Test_parseImageFilename(t *testing.T) {
 		{"", nil},
 		{"34_thumb.jpg", nil},
 		{"0034.jpg", nil},
-		{"0034_thumb.jpg", &imageId{34, ImageTypeThumbnail, 0}},
-		{"0034_a.jpg", &imageId{34, ImageTypeGallery, 0}},
-		{"0034_b.jpg", &imageId{34, ImageTypeGallery, 1}},
-		{"0034_c.jpg", &imageId{34, ImageTypeGallery, 2}},
+		{"0034_thumb.jpg", &imageId{34, ImageTypeThumbnail, 0, nil}},
+		{"0034_a.jpg", &imageId{34, ImageTypeGallery, 0, nil}},
+		{"0034_b.jpg", &imageId{34, ImageTypeGallery, 1, nil}},
+		{"0034_c.jpg", &imageId{34, ImageTypeGallery, 2, nil}},
+		{"0034_z.jpg", &imageId{34, ImageTypeGallery, 25, nil}},
+		{"0034_aa.jpg", &imageId{34, ImageTypeGallery, 26, nil}},
+		{"0034_ab.jpg", &imageId{34, ImageTypeGallery, 27, nil}},
+		{"0034_zz.jpg", &imageId{34, ImageTypeGallery, 701, nil}},
+		{"0034_aaa.jpg", &imageId{34, ImageTypeGallery, 702, nil}},
+		{"0034_zzz.jpg", &imageId{34, ImageTypeGallery, 18277, nil}},
+		{"0034_aaaa.jpg", nil},
+		{"0034_A.jpg", nil},
+		{"0034_a@800w.webp", &imageId{34, ImageTypeGallery, 0, &ImageVariant{ImageFormatWebp, 800}}},
+		{"0034_ab@400w.avif", &imageId{34, ImageTypeGallery, 27, &ImageVariant{ImageFormatAvif, 400}}},
+		{"0034_c@1200w.jpg", &imageId{34, ImageTypeGallery, 2, &ImageVariant{ImageFormatJpeg, 1200}}},
+		{"0034_a@0w.webp", nil},
+		{"0034_a@800.webp", nil},
+		{"0034_a@800w.png", nil},
+		{"0034_thumb@800w.webp", nil},
 	}
 	for _, tt := range tests {
 		t.Run(tt.filename, func(t *testing.T) {
@@ -39,6 +54,7 @@ func Test_GetImageFilename(t *testing.T) {
 		imageType    ImageType
 		galleryIndex int
 		want         string
+		wantErr      string
 	}{
 		{
 			"thumbnail image",
@@ -46,6 +62,7 @@ func Test_GetImageFilename(t *testing.T) {
 			ImageTypeThumbnail,
 			0,
 			"0123_thumb.jpg",
+			"",
 		},
 		{
 			"gallery image 0",
@@ -53,6 +70,7 @@ func Test_GetImageFilename(t *testing.T) {
 			ImageTypeGallery,
 			0,
 			"0123_a.jpg",
+			"",
 		},
 		{
 			"gallery image 1",
@@ -60,19 +78,115 @@ func Test_GetImageFilename(t *testing.T) {
 			ImageTypeGallery,
 			1,
 			"0123_b.jpg",
+			"",
 		},
 		{
-			"gallery past the 26th are clamped at z",
+			"gallery image 25",
 			123,
 			ImageTypeGallery,
-			99999,
+			25,
 			"0123_z.jpg",
+			"",
+		},
+		{
+			"gallery image 26 uses two letters",
+			123,
+			ImageTypeGallery,
+			26,
+			"0123_aa.jpg",
+			"",
+		},
+		{
+			"gallery image 702 uses three letters",
+			123,
+			ImageTypeGallery,
+			702,
+			"0123_aaa.jpg",
+			"",
+		},
+		{
+			"last valid gallery image uses zzz",
+			123,
+			ImageTypeGallery,
+			MaxGalleryImages - 1,
+			"0123_zzz.jpg",
+			"",
+		},
+		{
+			"gallery images past the last valid suffix are an error",
+			123,
+			ImageTypeGallery,
+			MaxGalleryImages,
+			"",
+			"gallery index 18278 is out of range (a tape may have at most 18278 gallery images)",
+		},
+		{
+			"negative gallery indices are an error",
+			123,
+			ImageTypeGallery,
+			-1,
+			"",
+			"gallery index -1 is out of range (a tape may have at most 18278 gallery images)",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := GetImageFilename(tt.tapeId, tt.imageType, tt.galleryIndex)
-			assert.Equal(t, tt.want, got)
+			got, err := GetImageFilename(tt.tapeId, tt.imageType, tt.galleryIndex)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
 		})
 	}
 }
+
+func Test_GetImageFilename_roundTrip(t *testing.T) {
+	for i := 0; i < MaxGalleryImages; i++ {
+		filename, err := GetImageFilename(42, ImageTypeGallery, i)
+		if !assert.NoError(t, err) {
+			return
+		}
+		got, err := parseImageFilename(filename)
+		if !assert.NoError(t, err) || !assert.Equal(t, &imageId{42, ImageTypeGallery, i, nil}, got) {
+			return
+		}
+	}
+}
+
+func Test_GetImageVariantFilename(t *testing.T) {
+	tests := []struct {
+		galleryIndex int
+		variant      ImageVariant
+		want         string
+		wantErr      string
+	}{
+		{0, ImageVariant{ImageFormatWebp, 800}, "0123_a@800w.webp", ""},
+		{26, ImageVariant{ImageFormatAvif, 400}, "0123_aa@400w.avif", ""},
+		{1, ImageVariant{ImageFormatJpeg, 1200}, "0123_b@1200w.jpg", ""},
+		{MaxGalleryImages - 1, ImageVariant{ImageFormatWebp, 800}, "0123_zzz@800w.webp", ""},
+		{MaxGalleryImages, ImageVariant{ImageFormatWebp, 800}, "", "gallery index 18278 is out of range (a tape may have at most 18278 gallery images)"},
+	}
+	for _, tt := range tests {
+		got, err := GetImageVariantFilename(123, tt.galleryIndex, tt.variant)
+		if tt.wantErr != "" {
+			assert.EqualError(t, err, tt.wantErr)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		}
+	}
+}
+
+func Test_GetTapeImageFilenames(t *testing.T) {
+	files := []FileInfo{
+		{Filename: "0042_thumb.jpg"},
+		{Filename: "0042_a.jpg"},
+		{Filename: "0043_a.jpg"},
+		{Filename: "0042_notes.txt"},
+		{Filename: "0042_b.jpg"},
+	}
+	assert.Equal(t, []string{"0042_thumb.jpg", "0042_a.jpg", "0042_b.jpg"}, GetTapeImageFilenames(files, 42))
+	assert.Equal(t, []string{}, GetTapeImageFilenames(files, 44))
+}
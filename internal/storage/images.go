@@ -1,19 +1,84 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
 )
 
+// Warning is a human-readable warning that indicates that there was a problem with an
+// image file in the storage bucket
 type Warning struct {
+	// Kind identifies the type of problem that occurred
+	Kind WarningKind
+	// Filename is the name of the image file in question
 	Filename string
-	Message  string
+	// TapeId is the ID of the tape to which the image belongs, or 0 if the filename
+	// could not be parsed
+	TapeId int
+	// Message is the human-readable message describing the problem
+	Message string
 }
 
-func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
+// WarningKind is a machine-readable code identifying the type of problem that caused
+// an image (and the tape it belongs to) to be rejected
+type WarningKind string
+
+const (
+	// WarningKindInvalidFilename indicates that a file is not named like a tape image
+	WarningKindInvalidFilename WarningKind = "invalid_filename"
+	// WarningKindDuplicateThumbnail indicates that a tape has multiple thumbnails
+	WarningKindDuplicateThumbnail WarningKind = "duplicate_thumbnail"
+	// WarningKindInvalidMetadata indicates that a gallery image is missing required
+	// metadata, or has invalid metadata values
+	WarningKindInvalidMetadata WarningKind = "invalid_metadata"
+	// WarningKindMissingGalleryImages indicates that a tape has a thumbnail image but no
+	// gallery images
+	WarningKindMissingGalleryImages WarningKind = "missing_gallery_images"
+	// WarningKindMissingThumbnail indicates that a tape has gallery images but no
+	// thumbnail image
+	WarningKindMissingThumbnail WarningKind = "missing_thumbnail"
+	// WarningKindMetadataMismatch indicates that a gallery image's metadata has values
+	// that are invalid or that disagree with the image itself: unlike other warnings,
+	// this does not cause the tape to be excluded, since the image's details can be
+	// derived from its pixel data instead
+	WarningKindMetadataMismatch WarningKind = "metadata_mismatch"
+	// WarningKindInvalidThumbnail indicates that a thumbnail image could not be decoded,
+	// so its dimensions and other details are unknown
+	WarningKindInvalidThumbnail WarningKind = "invalid_thumbnail"
+	// WarningKindOrphanedVariant indicates that there's an alternate version of a
+	// gallery image for which the original image does not exist: the variant is
+	// ignored, but the tape is not excluded
+	WarningKindOrphanedVariant WarningKind = "orphaned_variant"
+)
+
+// galleryImageKey identifies a single gallery image across all of its variants
+type galleryImageKey struct {
+	tapeId       int
+	galleryIndex int
+}
+
+// maxConcurrentMetadataRequests is the maximum number of requests we'll have in flight
+// at once when retrieving metadata for image files
+const maxConcurrentMetadataRequests = 8
+
+// ListImages lists all valid tape images in the bucket, along with warnings for any
+// files that aren't valid. Thumbnails, and any gallery images whose metadata is missing
+// or invalid, are downloaded and analyzed so that their details can be derived from
+// their pixel data; gallery images with complete, valid metadata are trusted as-is,
+// requiring only a HEAD request. If cache is
+// non-nil, metadata is only requested for files that have changed since their metadata
+// was cached. Any problems that don't prevent images from being listed are reported to
+// progress.
+func ListImages(ctx context.Context, c Client, cache MetadataCache, progress io.Writer) ([]Image, []Warning, error) {
 	// List the files in the S3-compatible bucket where we store scanned images of tapes
-	rawFilenames, err := c.ListFilenames(ctx)
+	files, err := c.ListFilenames(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list filenames from storage bucket: %w", err)
 	}
@@ -28,22 +93,38 @@ func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
 	// Parse each image filename, and sort each valid image file into one of two
 	// categories - thumbnail images and gallery images - indexed by tape ID
 	thumbnailImagesByTapeId := make(map[int]*Image)
-	galleryImagesByTapeId := make(map[int][]*Image)
-	for _, filename := range rawFilenames {
+	thumbnailFiles := make([]FileInfo, 0, len(files))
+	galleryFiles := make([]FileInfo, 0, len(files))
+	galleryImageIds := make(map[string]*imageId)
+	variantsByImage := make(map[galleryImageKey][]ImageVariant)
+	variantFilenamesByImage := make(map[galleryImageKey][]string)
+	for _, file := range files {
+		filename := file.Filename
 		imageId, err := parseImageFilename(filename)
 		if err != nil {
 			// If any file in the bucket is not a valid tape image, log a warning
 			warnings = append(warnings, Warning{
+				Kind:     WarningKindInvalidFilename,
 				Filename: filename,
 				Message:  err.Error(),
 			})
 			continue
 		}
+		if imageId.variant != nil {
+			// Variants don't require metadata: just keep track of which variants exist
+			// for each gallery image
+			key := galleryImageKey{imageId.tapeId, imageId.galleryIndex}
+			variantsByImage[key] = append(variantsByImage[key], *imageId.variant)
+			variantFilenamesByImage[key] = append(variantFilenamesByImage[key], filename)
+			continue
+		}
 		if imageId.imageType == ImageTypeThumbnail {
 			// Cache this image as the thumbnail for its tape
 			if existing, found := thumbnailImagesByTapeId[imageId.tapeId]; found {
 				warnings = append(warnings, Warning{
+					Kind:     WarningKindDuplicateThumbnail,
 					Filename: filename,
+					TapeId:   imageId.tapeId,
 					Message:  fmt.Sprintf("duplicate thumbnail image for tape %d (already have %s)", imageId.tapeId, existing.Filename),
 				})
 				tapeIdsWithWarnings[imageId.tapeId] = struct{}{}
@@ -54,35 +135,118 @@ func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
 				TapeId:   imageId.tapeId,
 				Type:     ImageTypeThumbnail,
 			}
+			thumbnailFiles = append(thumbnailFiles, file)
 		} else {
-			// For a gallery image, fetch metadata from S3: if unable, fail hard
-			md, err := c.GetFileMetadata(ctx, filename)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to get metadata for image file %s: %w", filename, err)
-			}
+			// Gallery images require metadata, which we'll retrieve once we know the
+			// full set of gallery images
+			galleryFiles = append(galleryFiles, file)
+			galleryImageIds[filename] = imageId
+		}
+	}
 
-			// Parse the raw key/value pairs to an ImageMetadata struct, which
-			// represents the required fields that all images must have: if unable, log
-			// a warning
-			metadata, err := md.toImageMetadata()
-			if err != nil {
-				warnings = append(warnings, Warning{
-					Filename: filename,
-					Message:  err.Error(),
-				})
-				tapeIdsWithWarnings[imageId.tapeId] = struct{}{}
-				continue
-			}
+	// Get metadata for all thumbnail and gallery images: if unable, fail hard
+	metadataByFilename, err := getMetadata(ctx, c, cache, append(thumbnailFiles, galleryFiles...), progress)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			// Add this image to the list of gallery images cached for its tape
-			galleryImagesByTapeId[imageId.tapeId] = append(galleryImagesByTapeId[imageId.tapeId], &Image{
+	// Every thumbnail must be a valid image, so that we can record its dimensions and
+	// other details: if it's not, log a warning and exclude its tape
+	for tapeId, thumbnailImage := range thumbnailImagesByTapeId {
+		filename := thumbnailImage.Filename
+		details := metadataByFilename[filename]
+		if details.analysis == nil {
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindInvalidThumbnail,
+				Filename: filename,
+				TapeId:   tapeId,
+				Message:  "thumbnail image could not be decoded",
+			})
+			tapeIdsWithWarnings[tapeId] = struct{}{}
+			continue
+		}
+		metadata, discrepancies, err := details.metadata.resolveImageMetadata(details.analysis)
+		for _, discrepancy := range discrepancies {
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindMetadataMismatch,
+				Filename: filename,
+				TapeId:   tapeId,
+				Message:  discrepancy,
+			})
+		}
+		if err != nil {
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindInvalidMetadata,
+				Filename: filename,
+				TapeId:   tapeId,
+				Message:  err.Error(),
+			})
+			tapeIdsWithWarnings[tapeId] = struct{}{}
+			continue
+		}
+		thumbnailImage.ThumbnailData = &ThumbnailImageData{
+			Metadata:    metadata,
+			ContentHash: details.analysis.ContentHash,
+		}
+	}
+
+	galleryImagesByTapeId := make(map[int][]*Image)
+	for _, file := range galleryFiles {
+		filename := file.Filename
+		imageId := galleryImageIds[filename]
+
+		// Parse the raw key/value pairs to an ImageMetadata struct, which represents the
+		// required fields that all images must have, filling in any missing values from
+		// the image itself: if unable, log a warning. If values are present but don't
+		// agree with the image, log a warning but keep the image.
+		details := metadataByFilename[filename]
+		metadata, discrepancies, err := details.metadata.resolveImageMetadata(details.analysis)
+		for _, discrepancy := range discrepancies {
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindMetadataMismatch,
+				Filename: filename,
+				TapeId:   imageId.tapeId,
+				Message:  discrepancy,
+			})
+		}
+		if err != nil {
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindInvalidMetadata,
 				Filename: filename,
 				TapeId:   imageId.tapeId,
-				Type:     ImageTypeGallery,
-				GalleryData: &GalleryImageData{
-					Index:    imageId.galleryIndex,
-					Metadata: metadata,
-				},
+				Message:  err.Error(),
+			})
+			tapeIdsWithWarnings[imageId.tapeId] = struct{}{}
+			continue
+		}
+
+		// Add this image to the list of gallery images cached for its tape
+		galleryImagesByTapeId[imageId.tapeId] = append(galleryImagesByTapeId[imageId.tapeId], &Image{
+			Filename: filename,
+			TapeId:   imageId.tapeId,
+			Type:     ImageTypeGallery,
+			GalleryData: &GalleryImageData{
+				Index:    imageId.galleryIndex,
+				Metadata: metadata,
+				Variants: sortVariants(variantsByImage[galleryImageKey{imageId.tapeId, imageId.galleryIndex}]),
+			},
+		})
+	}
+
+	// Any variant of a gallery image that doesn't exist is ignored, with a warning
+	for _, imageId := range galleryImageIds {
+		delete(variantFilenamesByImage, galleryImageKey{imageId.tapeId, imageId.galleryIndex})
+	}
+	for key, filenames := range variantFilenamesByImage {
+		for _, filename := range filenames {
+			// A variant's filename is its gallery image's filename with the variant's
+			// width and format in place of the extension
+			galleryFilename, _, _ := strings.Cut(filename, "@")
+			warnings = append(warnings, Warning{
+				Kind:     WarningKindOrphanedVariant,
+				Filename: filename,
+				TapeId:   key.tapeId,
+				Message:  fmt.Sprintf("variant has no corresponding gallery image %s.jpg", galleryFilename),
 			})
 		}
 	}
@@ -102,7 +266,9 @@ func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
 	for thumbnailTapeId, thumbnailImage := range thumbnailImagesByTapeId {
 		if galleryImages, ok := galleryImagesByTapeId[thumbnailTapeId]; !ok || len(galleryImages) == 0 {
 			warnings = append(warnings, Warning{
+				Kind:     WarningKindMissingGalleryImages,
 				Filename: thumbnailImage.Filename,
+				TapeId:   thumbnailTapeId,
 				Message:  fmt.Sprintf("tape %d has thumbnail image but no accompanying gallery image(s)", thumbnailTapeId),
 			})
 			tapeIdsWithWarnings[thumbnailTapeId] = struct{}{}
@@ -111,7 +277,9 @@ func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
 	for galleryTapeId, galleryImages := range galleryImagesByTapeId {
 		if _, ok := thumbnailImagesByTapeId[galleryTapeId]; !ok {
 			warnings = append(warnings, Warning{
+				Kind:     WarningKindMissingThumbnail,
 				Filename: galleryImages[0].Filename,
+				TapeId:   galleryTapeId,
 				Message:  fmt.Sprintf("tape %d has gallery image(s) but no accompanying thumbnail image", galleryTapeId),
 			})
 			tapeIdsWithWarnings[galleryTapeId] = struct{}{}
@@ -145,3 +313,151 @@ func ListImages(ctx context.Context, c Client) ([]Image, []Warning, error) {
 
 	return images, warnings, nil
 }
+
+// sortVariants sorts the given variants by format and then by width, in place
+func sortVariants(variants []ImageVariant) []ImageVariant {
+	sort.Slice(variants, func(i, j int) bool {
+		if variants[i].Format != variants[j].Format {
+			return variants[i].Format < variants[j].Format
+		}
+		return variants[i].Width < variants[j].Width
+	})
+	return variants
+}
+
+// fileDetails is the metadata retrieved for a single file, along with the analysis of
+// its pixel data, or nil if the file is not a valid image or did not need analyzing
+type fileDetails struct {
+	metadata        Metadata
+	analysisVersion int
+	analysis        *imaging.Analysis
+}
+
+// getMetadata returns the metadata for each of the given files, keyed by filename. Any
+// cached metadata that's still valid is used as-is; all other files have their
+// metadata requested concurrently (and their contents analyzed, if requiresAnalysis),
+// with the results then stored in the cache.
+func getMetadata(ctx context.Context, c Client, cache MetadataCache, files []FileInfo, progress io.Writer) (map[string]fileDetails, error) {
+	metadataByFilename := make(map[string]fileDetails, len(files))
+	filesToFetch := files
+	if cache != nil {
+		filenames := make([]string, 0, len(files))
+		for _, file := range files {
+			filenames = append(filenames, file.Filename)
+		}
+		cached, err := cache.GetCachedMetadata(ctx, filenames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cached image metadata: %w", err)
+		}
+		filesToFetch = make([]FileInfo, 0)
+		for i := range files {
+			// Cached metadata is only usable if the file hasn't changed, and if the file
+			// doesn't require an analysis that's missing or out of date
+			entry, ok := cached[files[i].Filename]
+			if ok && entry.isValidFor(&files[i]) && (entry.hasCurrentAnalysis() || !requiresAnalysis(files[i].Filename, entry.Metadata)) {
+				metadataByFilename[files[i].Filename] = fileDetails{
+					metadata:        entry.Metadata,
+					analysisVersion: entry.AnalysisVersion,
+					analysis:        entry.Analysis,
+				}
+			} else {
+				filesToFetch = append(filesToFetch, files[i])
+			}
+		}
+	}
+
+	// Fetch metadata for all remaining files, with a limit on the number of requests in
+	// flight: if any request fails, cancel the rest
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	sem := make(chan struct{}, maxConcurrentMetadataRequests)
+	for _, file := range filesToFetch {
+		wg.Add(1)
+		go func(file FileInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			md, err := c.GetFileMetadata(ctx, file.Filename)
+			if err != nil {
+				err = fmt.Errorf("failed to get metadata for image file %s: %w", file.Filename, err)
+			}
+			analysisVersion := 0
+			var analysis *imaging.Analysis
+			if err == nil && requiresAnalysis(file.Filename, md) {
+				analysisVersion = imaging.AnalysisVersion
+				analysis, err = analyzeFile(ctx, c, file.Filename)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			metadataByFilename[file.Filename] = fileDetails{
+				metadata:        md,
+				analysisVersion: analysisVersion,
+				analysis:        analysis,
+			}
+		}(file)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Cache the metadata we fetched: if that fails, we can carry on without it
+	if cache != nil {
+		for _, file := range filesToFetch {
+			details := metadataByFilename[file.Filename]
+			if err := cache.StoreMetadata(ctx, file, details.metadata, details.analysisVersion, details.analysis); err != nil {
+				fmt.Fprintf(progress, "WARNING: Failed to cache metadata for image file %s: %v\n", file.Filename, err)
+			}
+		}
+	}
+	return metadataByFilename, nil
+}
+
+// requiresAnalysis returns true if the image file with the given name and metadata
+// must be downloaded and analyzed in order to be synced: thumbnails have no metadata
+// and are identified by a hash of their contents, so they're always analyzed, whereas a
+// gallery image only needs analyzing if its metadata is incomplete or invalid. Since a
+// blurhash can only be derived from the image itself, a gallery image whose metadata
+// doesn't supply one is also analyzed.
+func requiresAnalysis(filename string, md Metadata) bool {
+	imageId, err := parseImageFilename(filename)
+	if err != nil || imageId.imageType == ImageTypeThumbnail {
+		return true
+	}
+	metadata, err := md.toImageMetadata()
+	return err != nil || metadata.Blurhash == ""
+}
+
+// analyzeFile downloads the given file and analyzes its pixel data. If the file can't
+// be downloaded, an error is returned; if it's not a valid image, the result is nil.
+func analyzeFile(ctx context.Context, c Client, filename string) (*imaging.Analysis, error) {
+	r, err := c.ReadFile(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file %s: %w", filename, err)
+	}
+	defer r.Close()
+
+	// Read the entire file before decoding it, so that a failed download isn't
+	// mistaken for an invalid image
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file %s: %w", filename, err)
+	}
+	analysis, err := imaging.Analyze(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	return analysis, nil
+}
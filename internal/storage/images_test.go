@@ -1,11 +1,25 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
 )
 
 func Test_ListImages(t *testing.T) {
@@ -28,11 +42,7 @@ func Test_ListImages(t *testing.T) {
 			"",
 			[]Warning{},
 			[]Image{
-				{
-					Filename: "0042_thumb.jpg",
-					TapeId:   42,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(42),
 				{
 					Filename: "0042_a.jpg",
 					TapeId:   42,
@@ -61,11 +71,7 @@ func Test_ListImages(t *testing.T) {
 						},
 					},
 				},
-				{
-					Filename: "0115_thumb.jpg",
-					TapeId:   115,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(115),
 				{
 					Filename: "0115_a.jpg",
 					TapeId:   115,
@@ -95,20 +101,18 @@ func Test_ListImages(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:     WarningKindInvalidFilename,
 					Filename: "0043_somethingelse.jpg",
-					Message:  "not a valid image filename matching ^(\\d{4})_(thumb|[a-z])\\.jpg$",
+					Message:  "not a valid image filename matching ^(\\d{4})_(thumb|[a-z]{1,3})\\.jpg$",
 				},
 				{
+					Kind:     WarningKindInvalidFilename,
 					Filename: "whatever.txt",
-					Message:  "not a valid image filename matching ^(\\d{4})_(thumb|[a-z])\\.jpg$",
+					Message:  "not a valid image filename matching ^(\\d{4})_(thumb|[a-z]{1,3})\\.jpg$",
 				},
 			},
 			[]Image{
-				{
-					Filename: "0041_thumb.jpg",
-					TapeId:   41,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(41),
 				{
 					Filename: "0041_a.jpg",
 					TapeId:   41,
@@ -123,11 +127,7 @@ func Test_ListImages(t *testing.T) {
 						},
 					},
 				},
-				{
-					Filename: "0042_thumb.jpg",
-					TapeId:   42,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(42),
 				{
 					Filename: "0042_a.jpg",
 					TapeId:   42,
@@ -155,16 +155,14 @@ func Test_ListImages(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:     WarningKindInvalidMetadata,
 					Filename: "0041_a.jpg",
+					TapeId:   41,
 					Message:  "metadata value 'Height' is required",
 				},
 			},
 			[]Image{
-				{
-					Filename: "0042_thumb.jpg",
-					TapeId:   42,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(42),
 				{
 					Filename: "0042_a.jpg",
 					TapeId:   42,
@@ -191,16 +189,14 @@ func Test_ListImages(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:     WarningKindMissingThumbnail,
 					Filename: "0041_a.jpg",
+					TapeId:   41,
 					Message:  "tape 41 has gallery image(s) but no accompanying thumbnail image",
 				},
 			},
 			[]Image{
-				{
-					Filename: "0042_thumb.jpg",
-					TapeId:   42,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(42),
 				{
 					Filename: "0042_a.jpg",
 					TapeId:   42,
@@ -227,16 +223,14 @@ func Test_ListImages(t *testing.T) {
 			"",
 			[]Warning{
 				{
+					Kind:     WarningKindMissingGalleryImages,
 					Filename: "0041_thumb.jpg",
+					TapeId:   41,
 					Message:  "tape 41 has thumbnail image but no accompanying gallery image(s)",
 				},
 			},
 			[]Image{
-				{
-					Filename: "0042_thumb.jpg",
-					TapeId:   42,
-					Type:     ImageTypeThumbnail,
-				},
+				mockThumbnailImage(42),
 				{
 					Filename: "0042_a.jpg",
 					TapeId:   42,
@@ -285,7 +279,7 @@ func Test_ListImages(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			images, warnings, err := ListImages(context.Background(), tt.c)
+			images, warnings, err := ListImages(context.Background(), tt.c, nil, io.Discard)
 			if tt.wantErr != "" {
 				assert.Error(t, err)
 				assert.ErrorContains(t, err, tt.wantErr)
@@ -300,24 +294,40 @@ func Test_ListImages(t *testing.T) {
 	}
 }
 
+// mockLastModified is the modification time reported for all files by mockClient
+var mockLastModified = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
 type mockClient struct {
 	listFilenamesErr   error
 	getFileMetadataErr error
 	metadataByFilename map[string]Metadata
+	etagsByFilename    map[string]string
+	dataByFilename     map[string][]byte
+
+	mu                  sync.Mutex
+	numMetadataRequests int
+	numReadRequests     int
 }
 
-func (m *mockClient) ListFilenames(ctx context.Context) ([]string, error) {
+func (m *mockClient) ListFilenames(ctx context.Context) ([]FileInfo, error) {
 	if m.listFilenamesErr != nil {
 		return nil, m.listFilenamesErr
 	}
-	filenames := make([]string, 0, len(m.metadataByFilename))
+	files := make([]FileInfo, 0, len(m.metadataByFilename))
 	for filename := range m.metadataByFilename {
-		filenames = append(filenames, filename)
+		files = append(files, FileInfo{
+			Filename:     filename,
+			ETag:         m.etagsByFilename[filename],
+			LastModified: mockLastModified,
+		})
 	}
-	return filenames, nil
+	return files, nil
 }
 
 func (m *mockClient) GetFileMetadata(ctx context.Context, filename string) (Metadata, error) {
+	m.mu.Lock()
+	m.numMetadataRequests++
+	m.mu.Unlock()
 	if m.getFileMetadataErr != nil {
 		return nil, m.getFileMetadataErr
 	}
@@ -328,4 +338,342 @@ func (m *mockClient) GetFileMetadata(ctx context.Context, filename string) (Meta
 	return metadata, nil
 }
 
+func (m *mockClient) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	m.numReadRequests++
+	m.mu.Unlock()
+	if _, ok := m.metadataByFilename[filename]; !ok {
+		return nil, fmt.Errorf("no such file")
+	}
+
+	// Thumbnails with no data configured are served as a valid placeholder image, so
+	// that tests concerned with gallery images needn't supply them; any other file with
+	// no data configured is not a valid image
+	data, ok := m.dataByFilename[filename]
+	if !ok && strings.HasSuffix(filename, "_thumb.jpg") {
+		data = mockThumbnailData
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 var _ Client = (*mockClient)(nil)
+
+// mockThumbnailData is a small, solid gray JPEG served by mockClient for thumbnails
+var mockThumbnailData = func() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 4))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{128, 128, 128, 255}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}()
+
+// mockThumbnailImage returns the thumbnail image that ListImages should produce for
+// the given tape when mockClient serves mockThumbnailData
+func mockThumbnailImage(tapeId int) Image {
+	analysis, err := imaging.Analyze(bytes.NewReader(mockThumbnailData))
+	if err != nil {
+		panic(err)
+	}
+	filename, err := GetImageFilename(tapeId, ImageTypeThumbnail, -1)
+	if err != nil {
+		panic(err)
+	}
+	return Image{
+		Filename: filename,
+		TapeId:   tapeId,
+		Type:     ImageTypeThumbnail,
+		ThumbnailData: &ThumbnailImageData{
+			Metadata: &ImageMetadata{
+				Width:    2,
+				Height:   4,
+				Color:    HexColor(analysis.Color),
+				Blurhash: analysis.Blurhash,
+			},
+			ContentHash: analysis.ContentHash,
+		},
+	}
+}
+
+func Test_ListImages_cache(t *testing.T) {
+	blurhash := "LEHV6nWB2yk8pyo0adR*.7kCMdnj"
+	c := &mockClient{
+		metadataByFilename: map[string]Metadata{
+			"0042_thumb.jpg": {},
+			"0042_a.jpg":     {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"},
+			"0042_b.jpg":     {"Width": "703", "Height": "1550", "Color": "#beb001", "Rotated": "true"},
+			"0042_c.jpg":     {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false", "Blurhash": blurhash},
+			"0042_d.jpg":     {"Color": "#febe99"},
+		},
+		dataByFilename: map[string][]byte{
+			"0042_d.jpg": encodeJpeg(t, 60, 120, color.RGBA{255, 255, 255, 255}),
+		},
+		etagsByFilename: map[string]string{
+			"0042_thumb.jpg": `"tttt"`,
+			"0042_a.jpg":     `"aaaa"`,
+			"0042_b.jpg":     `"bbbb"`,
+			"0042_c.jpg":     `"cccc"`,
+			"0042_d.jpg":     `"dddd"`,
+		},
+	}
+	cache := &mockMetadataCache{
+		entries: map[string]CachedMetadata{
+			// Still valid: should be used in place of requesting metadata
+			"0042_a.jpg": {
+				ETag:            `"aaaa"`,
+				LastModified:    mockLastModified,
+				Metadata:        Metadata{"Width": "1", "Height": "1", "Color": "#000000", "Rotated": "false"},
+				AnalysisVersion: imaging.AnalysisVersion,
+			},
+			// Stale: file has been modified since it was cached
+			"0042_b.jpg": {
+				ETag:            `"bbbb"`,
+				LastModified:    mockLastModified.Add(-time.Hour),
+				Metadata:        Metadata{"Width": "2", "Height": "2", "Color": "#000000", "Rotated": "false"},
+				AnalysisVersion: imaging.AnalysisVersion,
+			},
+			// Never analyzed, but complete metadata means that it doesn't need to be
+			"0042_c.jpg": {
+				ETag:         `"cccc"`,
+				LastModified: mockLastModified,
+				Metadata:     Metadata{"Width": "3", "Height": "3", "Color": "#000000", "Rotated": "false", "Blurhash": blurhash},
+			},
+			// Incomplete metadata, cached before the current version of the analysis:
+			// should be analyzed again
+			"0042_d.jpg": {
+				ETag:         `"dddd"`,
+				LastModified: mockLastModified,
+				Metadata:     Metadata{"Color": "#febe99"},
+			},
+		},
+	}
+
+	images, warnings, err := ListImages(context.Background(), c, cache, io.Discard)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Len(t, images, 5)
+	assert.Equal(t, 1, images[1].GalleryData.Metadata.Width)
+	assert.Equal(t, 703, images[2].GalleryData.Metadata.Width)
+	assert.Equal(t, 3, images[3].GalleryData.Metadata.Width)
+	assert.Equal(t, 60, images[4].GalleryData.Metadata.Width)
+	assert.Equal(t, 3, c.numMetadataRequests)
+	assert.Equal(t, 3, c.numReadRequests)
+
+	// Freshly-requested metadata should be cached, along with the version of the
+	// analysis that was performed
+	assert.Equal(t, CachedMetadata{
+		ETag:            `"bbbb"`,
+		LastModified:    mockLastModified,
+		Metadata:        c.metadataByFilename["0042_b.jpg"],
+		AnalysisVersion: imaging.AnalysisVersion,
+	}, cache.entries["0042_b.jpg"])
+	assert.Equal(t, imaging.AnalysisVersion, cache.entries["0042_d.jpg"].AnalysisVersion)
+
+	// A second listing shouldn't need to request any metadata or read any files
+	_, _, err = ListImages(context.Background(), c, cache, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, c.numMetadataRequests)
+	assert.Equal(t, 3, c.numReadRequests)
+}
+
+func Test_ListImages_cacheFailure(t *testing.T) {
+	c := &mockClient{
+		metadataByFilename: map[string]Metadata{
+			"0042_thumb.jpg": {},
+		},
+	}
+	cache := &mockMetadataCache{
+		entries:  map[string]CachedMetadata{},
+		storeErr: errors.New("cache is down"),
+	}
+
+	// Failing to cache metadata isn't fatal, but should be reported as progress
+	var progress bytes.Buffer
+	_, _, err := ListImages(context.Background(), c, cache, &progress)
+	assert.NoError(t, err)
+	assert.Equal(t, "WARNING: Failed to cache metadata for image file 0042_thumb.jpg: cache is down\n", progress.String())
+}
+
+func Test_ListImages_analysis(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	c := &mockClient{
+		metadataByFilename: map[string]Metadata{
+			"0042_thumb.jpg": {},
+			"0042_a.jpg":     {},
+			"0042_b.jpg":     {"Width": "700", "Height": "120", "Color": "#beb001", "Rotated": "true", "Blurhash": "invalid!"},
+			"0043_thumb.jpg": {},
+			"0043_a.jpg":     {"Width": "120", "Height": "60", "Color": "#beb", "Rotated": "yes"},
+			"0044_thumb.jpg": {},
+			"0044_a.jpg":     {"Width": "120", "Height": "60", "Color": "#beb", "Rotated": "true", "Blurhash": "LEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+		},
+		dataByFilename: map[string][]byte{
+			"0042_a.jpg": encodeJpeg(t, 60, 120, white),
+			"0042_b.jpg": encodeJpeg(t, 60, 120, white),
+			"0043_a.jpg": encodeJpeg(t, 120, 60, white),
+			"0044_a.jpg": encodeJpeg(t, 120, 60, white),
+		},
+	}
+
+	// Missing values should be derived from the image, and values that are invalid or
+	// that disagree with the image should result in warnings without excluding the tape
+	images, warnings, err := ListImages(context.Background(), c, nil, io.Discard)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Warning{
+		{
+			Kind:     WarningKindMetadataMismatch,
+			Filename: "0042_b.jpg",
+			TapeId:   42,
+			Message:  "metadata value 'Width' is 700, but image is 60 pixels wide",
+		},
+		{
+			Kind:     WarningKindMetadataMismatch,
+			Filename: "0042_b.jpg",
+			TapeId:   42,
+			Message:  "metadata value 'Blurhash' must be a valid blurhash (got 'invalid!'): blurhash contains invalid character '!'",
+		},
+		{
+			Kind:     WarningKindMetadataMismatch,
+			Filename: "0043_a.jpg",
+			TapeId:   43,
+			Message:  "metadata value 'Rotated' must be a bool (got 'yes')",
+		},
+	}, warnings)
+	assert.Len(t, images, 7)
+	portraitBlurhash := "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"
+	landscapeBlurhash := "L9TSUA-;fQ-;~qj[fQj[fQfQfQfQ"
+	assert.Equal(t, &ImageMetadata{Width: 60, Height: 120, Color: "#ffffff", Blurhash: portraitBlurhash}, images[1].GalleryData.Metadata)
+	assert.Equal(t, &ImageMetadata{Width: 60, Height: 120, Color: "#beb001", Rotated: true, Blurhash: portraitBlurhash}, images[2].GalleryData.Metadata)
+	assert.Equal(t, &ImageMetadata{Width: 120, Height: 60, Color: "#beb", Blurhash: landscapeBlurhash}, images[4].GalleryData.Metadata)
+
+	// Complete, valid metadata should be trusted without downloading the image, even
+	// if it disagrees with the image itself
+	assert.Equal(t, &ImageMetadata{Width: 120, Height: 60, Color: "#beb", Rotated: true, Blurhash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"}, images[6].GalleryData.Metadata)
+	assert.Equal(t, 6, c.numReadRequests)
+}
+
+func Test_ListImages_thumbnails(t *testing.T) {
+	thumbnailData := encodeJpeg(t, 60, 120, color.RGBA{0, 0, 0, 255})
+	c := &mockClient{
+		metadataByFilename: map[string]Metadata{
+			"0042_thumb.jpg": {"Color": "#beb001"},
+			"0042_a.jpg":     {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"},
+			"0043_thumb.jpg": {},
+			"0043_a.jpg":     {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"},
+		},
+		dataByFilename: map[string][]byte{
+			"0042_thumb.jpg": thumbnailData,
+			"0043_thumb.jpg": []byte("not a jpeg"),
+		},
+	}
+
+	// Thumbnails should be analyzed like gallery images, and a tape whose thumbnail
+	// can't be decoded should be excluded
+	images, warnings, err := ListImages(context.Background(), c, nil, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{
+		{
+			Kind:     WarningKindInvalidThumbnail,
+			Filename: "0043_thumb.jpg",
+			TapeId:   43,
+			Message:  "thumbnail image could not be decoded",
+		},
+	}, warnings)
+	assert.Len(t, images, 2)
+	digest := sha256.Sum256(thumbnailData)
+	assert.Equal(t, &ThumbnailImageData{
+		Metadata: &ImageMetadata{
+			Width:    60,
+			Height:   120,
+			Color:    "#beb001",
+			Blurhash: "T00000fQfQfQfQfQfQfQfQfQfQfQ",
+		},
+		ContentHash: hex.EncodeToString(digest[:]),
+	}, images[0].ThumbnailData)
+	assert.Nil(t, images[1].ThumbnailData)
+}
+
+func Test_ListImages_variants(t *testing.T) {
+	c := &mockClient{
+		metadataByFilename: map[string]Metadata{
+			"0042_thumb.jpg":    {},
+			"0042_a.jpg":        {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"},
+			"0042_a@800w.webp":  {},
+			"0042_a@400w.webp":  {},
+			"0042_a@400w.avif":  {},
+			"0042_b.jpg":        {"Width": "703", "Height": "1550", "Color": "#beb001", "Rotated": "true"},
+			"0042_c@400w.webp":  {},
+			"0043_thumb.jpg":    {},
+			"0043_aa.jpg":       {"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"},
+			"0043_aa@400w.webp": {},
+		},
+	}
+
+	// Variants should be attached to their gallery images without requesting metadata,
+	// and variants with no gallery image should be ignored with a warning
+	images, warnings, err := ListImages(context.Background(), c, nil, io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []Warning{
+		{
+			Kind:     WarningKindOrphanedVariant,
+			Filename: "0042_c@400w.webp",
+			TapeId:   42,
+			Message:  "variant has no corresponding gallery image 0042_c.jpg",
+		},
+	}, warnings)
+	assert.Equal(t, 5, c.numMetadataRequests)
+	assert.Len(t, images, 5)
+	assert.Equal(t, []ImageVariant{
+		{Format: ImageFormatAvif, Width: 400},
+		{Format: ImageFormatWebp, Width: 400},
+		{Format: ImageFormatWebp, Width: 800},
+	}, images[1].GalleryData.Variants)
+	assert.Nil(t, images[2].GalleryData.Variants)
+	assert.Equal(t, []ImageVariant{{Format: ImageFormatWebp, Width: 400}}, images[4].GalleryData.Variants)
+}
+
+func Test_ListImages_concurrentFailure(t *testing.T) {
+	c := &mockClient{
+		getFileMetadataErr: fmt.Errorf("mock error"),
+		metadataByFilename: map[string]Metadata{},
+	}
+	for i := 1; i <= 50; i++ {
+		c.metadataByFilename[fmt.Sprintf("%04d_thumb.jpg", i)] = Metadata{}
+		c.metadataByFilename[fmt.Sprintf("%04d_a.jpg", i)] = Metadata{}
+	}
+	images, warnings, err := ListImages(context.Background(), c, nil, io.Discard)
+	assert.ErrorContains(t, err, "mock error")
+	assert.Nil(t, images)
+	assert.Nil(t, warnings)
+}
+
+type mockMetadataCache struct {
+	entries  map[string]CachedMetadata
+	storeErr error
+}
+
+func (m *mockMetadataCache) GetCachedMetadata(ctx context.Context, filenames []string) (map[string]CachedMetadata, error) {
+	result := make(map[string]CachedMetadata)
+	for _, filename := range filenames {
+		if entry, ok := m.entries[filename]; ok {
+			result[filename] = entry
+		}
+	}
+	return result, nil
+}
+
+func (m *mockMetadataCache) StoreMetadata(ctx context.Context, file FileInfo, metadata Metadata, analysisVersion int, analysis *imaging.Analysis) error {
+	if m.storeErr != nil {
+		return m.storeErr
+	}
+	m.entries[file.Filename] = CachedMetadata{
+		ETag:            file.ETag,
+		LastModified:    file.LastModified,
+		Metadata:        metadata,
+		AnalysisVersion: analysisVersion,
+		Analysis:        analysis,
+	}
+	return nil
+}
+
+var _ MetadataCache = (*mockMetadataCache)(nil)
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sidecarExtension is appended to the name of an image file in order to get the name of
+// the JSON file that contains its metadata, e.g. 0042_a.jpg.json
+const sidecarExtension = ".json"
+
+// NewLocalClient creates a new storage.Client that reads image files from a directory
+// on the local filesystem, for running syncs without access to a storage bucket.
+// Metadata for each file is read from a sidecar JSON file if present (e.g.
+// 0042_a.jpg.json, containing an object with string values), or computed from the
+// image itself otherwise.
+func NewLocalClient(dir string) (Client, error) {
+	return newLocalClient(dir)
+}
+
+func newLocalClient(dir string) (*localClient, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &localClient{dir: dir}, nil
+}
+
+// localClient is the implementation of storage.Client for use with a local directory
+type localClient struct {
+	dir string
+}
+
+func (c *localClient) ListFilenames(ctx context.Context) ([]FileInfo, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// List all regular files, except for sidecar files and hidden files
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, sidecarExtension) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		// If the file's metadata comes from a sidecar file, changes to that file should
+		// be treated as a modification of the image
+		lastModified := info.ModTime()
+		if sidecarInfo, err := os.Stat(c.sidecarPath(name)); err == nil && sidecarInfo.ModTime().After(lastModified) {
+			lastModified = sidecarInfo.ModTime()
+		}
+
+		files = append(files, FileInfo{
+			Filename:     name,
+			ETag:         fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()),
+			Size:         info.Size(),
+			LastModified: lastModified,
+		})
+	}
+	return files, nil
+}
+
+func (c *localClient) GetFileMetadata(ctx context.Context, filename string) (Metadata, error) {
+	// Metadata may be explicitly supplied in a sidecar file: if not, report empty
+	// metadata, and all values will be derived from the image itself
+	data, err := os.ReadFile(c.sidecarPath(filename))
+	if os.IsNotExist(err) {
+		return Metadata{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var md Metadata
+	if err := json.Unmarshal(data, &md); err != nil {
+		return nil, fmt.Errorf("failed to parse %s%s: %w", filename, sidecarExtension, err)
+	}
+	return md, nil
+}
+
+func (c *localClient) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(c.dir, filename))
+}
+
+// sidecarPath returns the path to the sidecar file for the given image file
+func (c *localClient) sidecarPath(filename string) string {
+	return filepath.Join(c.dir, filename+sidecarExtension)
+}
+
+var _ Client = (*localClient)(nil)
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_localClient(t *testing.T) {
+	dir := t.TempDir()
+	writeJpeg(t, filepath.Join(dir, "0042_thumb.jpg"), 40, 80, color.RGBA{0, 0, 0, 255})
+	writeJpeg(t, filepath.Join(dir, "0042_a.jpg"), 60, 120, color.RGBA{255, 255, 255, 255})
+	writeJpeg(t, filepath.Join(dir, "0042_b.jpg"), 60, 120, color.RGBA{0, 0, 0, 255})
+	writeFile(t, filepath.Join(dir, "0042_b.jpg.json"), `{"Width":"60","Height":"120","Color":"#beb001","Rotated":"true"}`)
+	writeFile(t, filepath.Join(dir, "0043_a.jpg"), "not a jpeg")
+	writeFile(t, filepath.Join(dir, ".DS_Store"), "")
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0755))
+
+	c, err := NewLocalClient(dir)
+	assert.NoError(t, err)
+
+	files, err := c.ListFilenames(context.Background())
+	assert.NoError(t, err)
+	filenames := make([]string, 0, len(files))
+	for _, file := range files {
+		filenames = append(filenames, file.Filename)
+		assert.NotEmpty(t, file.ETag)
+		assert.Positive(t, file.Size)
+	}
+	assert.ElementsMatch(t, []string{"0042_thumb.jpg", "0042_a.jpg", "0042_b.jpg", "0043_a.jpg"}, filenames)
+
+	// Metadata should only be reported if supplied in a sidecar file
+	md, err := c.GetFileMetadata(context.Background(), "0042_a.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, Metadata{}, md)
+	md, err = c.GetFileMetadata(context.Background(), "0042_b.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, Metadata{"Width": "60", "Height": "120", "Color": "#beb001", "Rotated": "true"}, md)
+
+	// Listing images end-to-end should compute metadata from the image where it's not
+	// supplied, and produce a warning for the invalid file
+	images, warnings, err := ListImages(context.Background(), c, nil, io.Discard)
+	assert.NoError(t, err)
+	assert.Len(t, images, 3)
+	assert.Equal(t, &ImageMetadata{Width: 60, Height: 120, Color: "#ffffff", Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"}, images[1].GalleryData.Metadata)
+	assert.Equal(t, &ImageMetadata{Width: 60, Height: 120, Color: "#beb001", Rotated: true, Blurhash: "T00000fQfQfQfQfQfQfQfQfQfQfQ"}, images[2].GalleryData.Metadata)
+	assert.Equal(t, []Warning{
+		{
+			Kind:     WarningKindInvalidMetadata,
+			Filename: "0043_a.jpg",
+			TapeId:   43,
+			Message:  "metadata value 'Width' is required",
+		},
+	}, warnings)
+}
+
+func Test_NewLocalClient_notADirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeFile(t, path, "")
+	_, err := NewLocalClient(path)
+	assert.Error(t, err)
+	_, err = NewLocalClient(filepath.Join(t.TempDir(), "nonexistent"))
+	assert.Error(t, err)
+}
+
+func writeJpeg(t *testing.T, path string, width int, height int, c color.Color) {
+	assert.NoError(t, os.WriteFile(path, encodeJpeg(t, width, height, c), 0644))
+}
+
+func encodeJpeg(t *testing.T, width int, height int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
 )
 
 // toImageMetadata parses the key/value pairs returned as S3 metadata into a valid
@@ -20,33 +22,120 @@ func (md Metadata) toImageMetadata() (*ImageMetadata, error) {
 	}
 
 	// 'Color' must be specified as a hex string
-	colorStr, ok := md["Color"]
-	if !ok {
-		return nil, fmt.Errorf("metadata value 'Color' is required")
-	}
-	color, err := parseHexColor(colorStr)
+	color, err := md.parseColor()
 	if err != nil {
-		return nil, fmt.Errorf("metadata value 'Color' must be a hex color (got '%s')", colorStr)
+		return nil, err
 	}
 
 	// 'Rotated' must be specified as a boolean
-	rotatedStr, ok := md["Rotated"]
-	if !ok {
-		return nil, fmt.Errorf("metadata value 'Rotated' is required")
+	rotated, err := md.parseRotated()
+	if err != nil {
+		return nil, err
 	}
-	if rotatedStr != "true" && rotatedStr != "false" {
-		return nil, fmt.Errorf("metadata value 'Rotated' must be a bool (got '%s')", rotatedStr)
+
+	// 'Blurhash' is optional, but must be well-formed if specified
+	blurhash, err := md.parseBlurhash()
+	if err != nil {
+		return nil, err
 	}
-	rotated := rotatedStr == "true"
 
 	return &ImageMetadata{
-		Width:   width,
-		Height:  height,
-		Color:   color,
-		Rotated: rotated,
+		Width:    width,
+		Height:   height,
+		Color:    color,
+		Rotated:  rotated,
+		Blurhash: blurhash,
 	}, nil
 }
 
+// resolveImageMetadata combines the key/value pairs returned as S3 metadata with the
+// details derived from the image's pixel data. If analysis is nil (i.e. the file could
+// not be decoded), the metadata must be complete and valid, as with toImageMetadata.
+// Otherwise, the image's true dimensions take precedence, missing or invalid values
+// fall back to what we derived from the image, and a human-readable message is
+// returned for each value that's invalid or that disagrees with the image.
+func (md Metadata) resolveImageMetadata(analysis *imaging.Analysis) (*ImageMetadata, []string, error) {
+	if analysis == nil {
+		result, err := md.toImageMetadata()
+		return result, nil, err
+	}
+	discrepancies := make([]string, 0)
+
+	// Width and height always come from the image itself
+	for _, dimension := range []struct {
+		name   string
+		actual int
+		unit   string
+	}{
+		{"Width", analysis.Width, "wide"},
+		{"Height", analysis.Height, "tall"},
+	} {
+		if _, ok := md[dimension.name]; !ok {
+			continue
+		}
+		value, err := md.parsePositiveInt(dimension.name)
+		if err != nil {
+			discrepancies = append(discrepancies, err.Error())
+		} else if value != dimension.actual {
+			discrepancies = append(discrepancies, fmt.Sprintf("metadata value '%s' is %d, but image is %d pixels %s", dimension.name, value, dimension.actual, dimension.unit))
+		}
+	}
+
+	// Prefer the color chosen by the uploader, since it's a matter of taste
+	color, err := md.parseColor()
+	if err != nil {
+		if _, ok := md["Color"]; ok {
+			discrepancies = append(discrepancies, err.Error())
+		}
+		color = HexColor(analysis.Color)
+	}
+
+	// Whether the image was rotated can't be determined from its pixels, but a rotated
+	// image should always have a vertical aspect ratio
+	rotated, err := md.parseRotated()
+	if err != nil {
+		if _, ok := md["Rotated"]; ok {
+			discrepancies = append(discrepancies, err.Error())
+		}
+		rotated = false
+	} else if rotated && analysis.Orientation == imaging.OrientationLandscape {
+		discrepancies = append(discrepancies, "metadata value 'Rotated' is true, but image has landscape orientation")
+	}
+
+	// A blurhash computed by the uploader may have been encoded with different
+	// parameters, so it's used if valid; otherwise we use the one we computed
+	blurhash, err := md.parseBlurhash()
+	if err != nil {
+		discrepancies = append(discrepancies, err.Error())
+	}
+	if blurhash == "" {
+		blurhash = analysis.Blurhash
+	}
+
+	return &ImageMetadata{
+		Width:    analysis.Width,
+		Height:   analysis.Height,
+		Color:    color,
+		Rotated:  rotated,
+		Blurhash: blurhash,
+	}, discrepancies, nil
+}
+
+// ToMetadata encodes the image metadata as the key/value pairs that are stored as S3
+// metadata, i.e. the inverse of toImageMetadata
+func (m *ImageMetadata) ToMetadata() Metadata {
+	md := Metadata{
+		"Width":   strconv.Itoa(m.Width),
+		"Height":  strconv.Itoa(m.Height),
+		"Color":   string(m.Color),
+		"Rotated": strconv.FormatBool(m.Rotated),
+	}
+	if m.Blurhash != "" {
+		md["Blurhash"] = m.Blurhash
+	}
+	return md
+}
+
 func (md Metadata) parsePositiveInt(name string) (int, error) {
 	strValue, ok := md[name]
 	if !ok {
@@ -61,3 +150,37 @@ func (md Metadata) parsePositiveInt(name string) (int, error) {
 	}
 	return value, nil
 }
+
+func (md Metadata) parseColor() (HexColor, error) {
+	colorStr, ok := md["Color"]
+	if !ok {
+		return "", fmt.Errorf("metadata value 'Color' is required")
+	}
+	color, err := parseHexColor(colorStr)
+	if err != nil {
+		return "", fmt.Errorf("metadata value 'Color' must be a hex color (got '%s')", colorStr)
+	}
+	return color, nil
+}
+
+func (md Metadata) parseRotated() (bool, error) {
+	rotatedStr, ok := md["Rotated"]
+	if !ok {
+		return false, fmt.Errorf("metadata value 'Rotated' is required")
+	}
+	if rotatedStr != "true" && rotatedStr != "false" {
+		return false, fmt.Errorf("metadata value 'Rotated' must be a bool (got '%s')", rotatedStr)
+	}
+	return rotatedStr == "true", nil
+}
+
+func (md Metadata) parseBlurhash() (string, error) {
+	blurhash, ok := md["Blurhash"]
+	if !ok {
+		return "", nil
+	}
+	if err := imaging.ValidateBlurhash(blurhash); err != nil {
+		return "", fmt.Errorf("metadata value 'Blurhash' must be a valid blurhash (got '%s'): %w", blurhash, err)
+	}
+	return blurhash, nil
+}
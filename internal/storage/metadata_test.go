@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
 )
 
 func Test_Metadata_toImageMetadata(t *testing.T) {
@@ -135,6 +137,18 @@ func Test_Metadata_toImageMetadata(t *testing.T) {
 			"metadata value 'Rotated' must be a bool (got 'maybe')",
 			nil,
 		},
+		{
+			"blurhash is optional but must be valid",
+			Metadata{
+				"Width":    "700",
+				"Height":   "1500",
+				"Color":    "#fe99cc",
+				"Rotated":  "true",
+				"Blurhash": "LEHV6nWB2yk8pyo0adR*.7kCMd",
+			},
+			"metadata value 'Blurhash' must be a valid blurhash (got 'LEHV6nWB2yk8pyo0adR*.7kCMd'): blurhash with 4x3 components must be 28 characters long (got 26)",
+			nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -150,3 +164,92 @@ func Test_Metadata_toImageMetadata(t *testing.T) {
 		})
 	}
 }
+
+func Test_Metadata_resolveImageMetadata(t *testing.T) {
+	analysis := &imaging.Analysis{Width: 700, Height: 1500, Color: "#ffffff", Orientation: imaging.OrientationPortrait, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"}
+	tests := []struct {
+		name              string
+		md                Metadata
+		analysis          *imaging.Analysis
+		wantErr           string
+		wantDiscrepancies []string
+		want              *ImageMetadata
+	}{
+		{
+			"without analysis, metadata is required",
+			Metadata{"Width": "700", "Height": "1500", "Color": "#fe99cc"},
+			nil,
+			"metadata value 'Rotated' is required",
+			nil,
+			nil,
+		},
+		{
+			"without analysis, metadata is used as-is",
+			Metadata{"Width": "700", "Height": "1500", "Color": "#fe99cc", "Rotated": "true"},
+			nil,
+			"",
+			nil,
+			&ImageMetadata{Width: 700, Height: 1500, Color: "#fe99cc", Rotated: true},
+		},
+		{
+			"missing values are derived from analysis",
+			Metadata{},
+			analysis,
+			"",
+			[]string{},
+			&ImageMetadata{Width: 700, Height: 1500, Color: "#ffffff", Rotated: false, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"},
+		},
+		{
+			"matching values are used as-is",
+			Metadata{"Width": "700", "Height": "1500", "Color": "#fe99cc", "Rotated": "true", "Blurhash": "TEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+			analysis,
+			"",
+			[]string{},
+			&ImageMetadata{Width: 700, Height: 1500, Color: "#fe99cc", Rotated: true, Blurhash: "TEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+		},
+		{
+			"disagreeing and invalid values are reported",
+			Metadata{"Width": "7000", "Height": "15OO", "Color": "fe99cc", "Rotated": "true", "Blurhash": "?"},
+			analysis,
+			"",
+			[]string{
+				"metadata value 'Width' is 7000, but image is 700 pixels wide",
+				"metadata value 'Height' must be an integer (got '15OO')",
+				"metadata value 'Color' must be a hex color (got 'fe99cc')",
+				"metadata value 'Blurhash' must be a valid blurhash (got '?'): blurhash must be at least 6 characters long",
+			},
+			&ImageMetadata{Width: 700, Height: 1500, Color: "#ffffff", Rotated: true, Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"},
+		},
+		{
+			"rotated landscape image is reported",
+			Metadata{"Rotated": "true"},
+			&imaging.Analysis{Width: 1500, Height: 700, Color: "#ffffff", Orientation: imaging.OrientationLandscape},
+			"",
+			[]string{"metadata value 'Rotated' is true, but image has landscape orientation"},
+			&ImageMetadata{Width: 1500, Height: 700, Color: "#ffffff", Rotated: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, discrepancies, err := tt.md.resolveImageMetadata(tt.analysis)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.Equal(t, tt.wantDiscrepancies, discrepancies)
+		})
+	}
+}
+
+func Test_ImageMetadata_ToMetadata(t *testing.T) {
+	m := &ImageMetadata{Width: 700, Height: 1500, Color: "#fe99cc", Rotated: true, Blurhash: "TEHV6nWB2yk8pyo0adR*.7kCMdnj"}
+	md := m.ToMetadata()
+	assert.Equal(t, Metadata{"Width": "700", "Height": "1500", "Color": "#fe99cc", "Rotated": "true", "Blurhash": "TEHV6nWB2yk8pyo0adR*.7kCMdnj"}, md)
+
+	roundTripped, err := md.toImageMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, m, roundTripped)
+}
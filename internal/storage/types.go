@@ -24,6 +24,18 @@ type Image struct {
 	Type ImageType
 	// GalleryImageData includes additional metadata for images of type gallery
 	GalleryData *GalleryImageData
+	// ThumbnailData includes additional metadata for images of type thumbnail
+	ThumbnailData *ThumbnailImageData
+}
+
+// ThumbnailImageData specifies extra data for an image of type thumbnail
+type ThumbnailImageData struct {
+	// Metadata describes the thumbnail's width, height, dominant color, and other
+	// information required to render it without layout shift
+	Metadata *ImageMetadata
+	// ContentHash is the hex-encoded SHA-256 digest of the thumbnail file, which allows
+	// us to tell when a thumbnail has been replaced
+	ContentHash string
 }
 
 // GalleryImageData specifies extra data for an image of type gallery
@@ -33,10 +45,33 @@ type GalleryImageData struct {
 	// Metadata describes the image's width, height, dominant color, and other
 	// information required to render the image in the gallery
 	Metadata *ImageMetadata
+	// Variants lists the alternate versions of the image that are available in the
+	// bucket, sorted by format and then by width
+	Variants []ImageVariant
+}
+
+// ImageFormat identifies the file format of an image variant
+type ImageFormat string
+
+const (
+	ImageFormatJpeg ImageFormat = "jpeg"
+	ImageFormatWebp ImageFormat = "webp"
+	ImageFormatAvif ImageFormat = "avif"
+)
+
+// ImageVariant is an alternate version of a gallery image that's been converted to a
+// different format and/or scaled down (preserving its aspect ratio), stored alongside
+// the original image, e.g. 0042_a@800w.webp
+type ImageVariant struct {
+	// Format is the file format of the variant
+	Format ImageFormat
+	// Width is the width of the variant in pixels
+	Width int
 }
 
 // ImageMetadata provides additional data required to render the image in the webapp, as
-// encoded in the file metadata (i.e. S3 x-amz-meta-* headers)
+// encoded in the file metadata (i.e. S3 x-amz-meta-* headers) and validated against the
+// image's pixel data
 type ImageMetadata struct {
 	// Width is the width of the image in pixels
 	Width int
@@ -48,4 +83,7 @@ type ImageMetadata struct {
 	// vertical aspect ratio: if so, it may be rotated 90 degrees CW to be displayed to
 	// the user with the text in a readable orientation
 	Rotated bool
+	// Blurhash is a compact encoding of the image from which the frontend can render a
+	// blurred placeholder while the full image loads; empty if not known
+	Blurhash string
 }
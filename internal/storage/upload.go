@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsS3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Uploader handles writing image files, along with their metadata, to an S3-compatible
+// bucket
+type Uploader interface {
+	Client
+	UploadFile(ctx context.Context, filename string, data []byte, metadata Metadata) error
+	DeleteFile(ctx context.Context, filename string) error
+}
+
+// NewUploader creates a new storage.Uploader that uses the AWS S3 client to write to a
+// DigitalOcean Spaces bucket
+func NewUploader(spacesAccessKeyId, spacesSecretKey, spacesEndpointOrigin, spacesRegionName, spacesBucketName string) (Uploader, error) {
+	return newClient(spacesAccessKeyId, spacesSecretKey, spacesEndpointOrigin, spacesRegionName, spacesBucketName)
+}
+
+// NewLocalUploader creates a new storage.Uploader that writes image files to a
+// directory on the local filesystem, with metadata written to sidecar JSON files
+func NewLocalUploader(dir string) (Uploader, error) {
+	return newLocalClient(dir)
+}
+
+func (c *client) UploadFile(ctx context.Context, filename string, data []byte, metadata Metadata) error {
+	// Images are served directly from the bucket, so they must be publicly readable;
+	// metadata values are sent as 'x-amz-meta-*' headers
+	input := &awsS3.PutObjectInput{
+		Bucket:      aws.String(c.bucketName),
+		Key:         aws.String(filename),
+		Body:        bytes.NewReader(data),
+		ACL:         aws.String(awsS3.ObjectCannedACLPublicRead),
+		ContentType: aws.String(mime.TypeByExtension(filepath.Ext(filename))),
+		Metadata:    aws.StringMap(metadata),
+	}
+	_, err := c.s3.PutObjectWithContext(ctx, input)
+	return err
+}
+
+func (c *client) DeleteFile(ctx context.Context, filename string) error {
+	_, err := c.s3.DeleteObjectWithContext(ctx, &awsS3.DeleteObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(filename),
+	})
+	return err
+}
+
+func (c *localClient) UploadFile(ctx context.Context, filename string, data []byte, metadata Metadata) error {
+	// Write the sidecar file first, so that an image is never present with stale
+	// metadata; images without metadata have no sidecar file
+	if len(metadata) > 0 {
+		sidecarData, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(c.sidecarPath(filename), sidecarData, 0644); err != nil {
+			return err
+		}
+	} else if err := os.Remove(c.sidecarPath(filename)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, filename), data, 0644)
+}
+
+func (c *localClient) DeleteFile(ctx context.Context, filename string) error {
+	if err := os.Remove(filepath.Join(c.dir, filename)); err != nil {
+		return err
+	}
+	if err := os.Remove(c.sidecarPath(filename)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s%s: %w", filename, sidecarExtension, err)
+	}
+	return nil
+}
+
+var _ Uploader = (*client)(nil)
+var _ Uploader = (*localClient)(nil)
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_localClient_upload(t *testing.T) {
+	dir := t.TempDir()
+	u, err := NewLocalUploader(dir)
+	assert.NoError(t, err)
+
+	// Uploaded metadata should be written to a sidecar file and read back as-is
+	md := Metadata{"Width": "60", "Height": "120", "Color": "#beb001", "Rotated": "true"}
+	err = u.UploadFile(context.Background(), "0042_a.jpg", []byte("image data"), md)
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "0042_a.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image data", string(data))
+	got, err := u.GetFileMetadata(context.Background(), "0042_a.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, md, got)
+
+	// Re-uploading a file without metadata should remove its sidecar file
+	err = u.UploadFile(context.Background(), "0042_a.jpg", []byte("new image data"), nil)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "0042_a.jpg.json"))
+
+	// Deleting a file should also delete its sidecar file
+	err = u.UploadFile(context.Background(), "0042_b.jpg", []byte("image data"), md)
+	assert.NoError(t, err)
+	err = u.DeleteFile(context.Background(), "0042_b.jpg")
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "0042_b.jpg"))
+	assert.NoFileExists(t, filepath.Join(dir, "0042_b.jpg.json"))
+
+	files, err := u.ListFilenames(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "0042_a.jpg", files[0].Filename)
+}
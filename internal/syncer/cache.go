@@ -0,0 +1,82 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/imaging"
+	"github.com/golden-vcr/tapes/internal/storage"
+)
+
+type metadataCacheQueries interface {
+	GetImageMetadata(ctx context.Context, filenames []string) ([]queries.GetImageMetadataRow, error)
+	RecordImageMetadata(ctx context.Context, arg queries.RecordImageMetadataParams) error
+}
+
+// metadataCache is an implementation of storage.MetadataCache that's backed by the
+// tapes.image_metadata table
+type metadataCache struct {
+	q metadataCacheQueries
+
+	// readOnly causes newly-retrieved metadata to be discarded rather than recorded
+	readOnly bool
+}
+
+// NewReadOnlyMetadataCache returns a storage.MetadataCache that serves metadata cached
+// by previous syncs, without recording anything new in the database: this allows dry
+// runs to skip retrieving metadata for (and analyzing) files that haven't changed
+func NewReadOnlyMetadataCache(q *queries.Queries) storage.MetadataCache {
+	return &metadataCache{q: q, readOnly: true}
+}
+
+func (c *metadataCache) GetCachedMetadata(ctx context.Context, filenames []string) (map[string]storage.CachedMetadata, error) {
+	rows, err := c.q.GetImageMetadata(ctx, filenames)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]storage.CachedMetadata, len(rows))
+	for _, row := range rows {
+		var metadata storage.Metadata
+		if err := json.Unmarshal(row.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse cached metadata for %s: %w", row.Filename, err)
+		}
+		var analysis *imaging.Analysis
+		if err := json.Unmarshal(row.Analysis, &analysis); err != nil {
+			return nil, fmt.Errorf("failed to parse cached analysis for %s: %w", row.Filename, err)
+		}
+		result[row.Filename] = storage.CachedMetadata{
+			ETag:            row.Etag,
+			LastModified:    row.LastModified,
+			Metadata:        metadata,
+			AnalysisVersion: int(row.AnalysisVersion),
+			Analysis:        analysis,
+		}
+	}
+	return result, nil
+}
+
+func (c *metadataCache) StoreMetadata(ctx context.Context, file storage.FileInfo, metadata storage.Metadata, analysisVersion int, analysis *imaging.Analysis) error {
+	if c.readOnly {
+		return nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	analysisData, err := json.Marshal(analysis)
+	if err != nil {
+		return err
+	}
+	return c.q.RecordImageMetadata(ctx, queries.RecordImageMetadataParams{
+		Filename:        file.Filename,
+		Etag:            file.ETag,
+		LastModified:    file.LastModified,
+		Metadata:        data,
+		AnalysisVersion: int32(analysisVersion),
+		Analysis:        analysisData,
+	})
+}
+
+var _ storage.MetadataCache = (*metadataCache)(nil)
@@ -0,0 +1,86 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/imaging"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_metadataCache(t *testing.T) {
+	q := &mockMetadataCacheQueries{rows: make(map[string]queries.GetImageMetadataRow)}
+	c := &metadataCache{q: q}
+
+	result, err := c.GetCachedMetadata(context.Background(), []string{"0042_a.jpg"})
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+
+	file := storage.FileInfo{
+		Filename:     "0042_a.jpg",
+		ETag:         `"aaaa"`,
+		Size:         1234,
+		LastModified: time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	metadata := storage.Metadata{"Width": "700", "Height": "1500", "Color": "#febe99", "Rotated": "false"}
+	analysis := &imaging.Analysis{Width: 700, Height: 1500, Color: "#febe99", Orientation: imaging.OrientationPortrait}
+	err = c.StoreMetadata(context.Background(), file, metadata, imaging.AnalysisVersion, analysis)
+	assert.NoError(t, err)
+
+	// Files that couldn't be analyzed should be cached with a nil analysis
+	invalidFile := storage.FileInfo{Filename: "0042_b.jpg", ETag: `"bbbb"`, LastModified: file.LastModified}
+	err = c.StoreMetadata(context.Background(), invalidFile, storage.Metadata{}, imaging.AnalysisVersion, nil)
+	assert.NoError(t, err)
+
+	result, err = c.GetCachedMetadata(context.Background(), []string{"0042_a.jpg", "0042_b.jpg"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]storage.CachedMetadata{
+		"0042_a.jpg": {
+			ETag:            `"aaaa"`,
+			LastModified:    file.LastModified,
+			Metadata:        metadata,
+			AnalysisVersion: imaging.AnalysisVersion,
+			Analysis:        analysis,
+		},
+		"0042_b.jpg": {
+			ETag:            `"bbbb"`,
+			LastModified:    file.LastModified,
+			Metadata:        storage.Metadata{},
+			AnalysisVersion: imaging.AnalysisVersion,
+		},
+	}, result)
+}
+
+func Test_metadataCache_readOnly(t *testing.T) {
+	q := &mockMetadataCacheQueries{rows: make(map[string]queries.GetImageMetadataRow)}
+	c := &metadataCache{q: q, readOnly: true}
+
+	file := storage.FileInfo{Filename: "0042_a.jpg", ETag: `"aaaa"`}
+	err := c.StoreMetadata(context.Background(), file, storage.Metadata{}, 0, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, q.rows)
+}
+
+type mockMetadataCacheQueries struct {
+	rows map[string]queries.GetImageMetadataRow
+}
+
+func (m *mockMetadataCacheQueries) GetImageMetadata(ctx context.Context, filenames []string) ([]queries.GetImageMetadataRow, error) {
+	result := make([]queries.GetImageMetadataRow, 0)
+	for _, filename := range filenames {
+		if row, ok := m.rows[filename]; ok {
+			result = append(result, row)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockMetadataCacheQueries) RecordImageMetadata(ctx context.Context, arg queries.RecordImageMetadataParams) error {
+	m.rows[arg.Filename] = queries.GetImageMetadataRow(arg)
+	return nil
+}
+
+var _ metadataCacheQueries = (*mockMetadataCacheQueries)(nil)
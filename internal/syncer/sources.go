@@ -0,0 +1,174 @@
+package syncer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golden-vcr/tapes/internal/sheets"
+	"github.com/golden-vcr/tapes/internal/storage"
+)
+
+// SourceData is the full set of data that's been gathered from the inventory
+// spreadsheet and the storage bucket in preparation for a sync
+type SourceData struct {
+	tapes         []sheets.Tape
+	sheetWarnings []sheets.Warning
+	images        []storage.Image
+	imageWarnings []storage.Warning
+}
+
+// TapeToSync is a tape from the spreadsheet that's eligible to be synced, along with
+// the thumbnail and gallery images that were found for it in the storage bucket
+type TapeToSync struct {
+	Tape          sheets.Tape
+	Thumbnail     storage.Image
+	GalleryImages []storage.Image
+}
+
+// ListSources gathers all tape data from the inventory spreadsheet and all image data
+// from the storage bucket, using cache (if non-nil) to avoid requesting metadata for
+// image files that haven't changed. Progress messages are written to progress.
+func ListSources(ctx context.Context, sheetsClient sheets.Client, storageClient storage.Client, cache storage.MetadataCache, progress io.Writer) (*SourceData, error) {
+	// Get a listing of all tapes with valid rows in the inventory spreadsheet
+	fmt.Fprintf(progress, "Listing tapes in the Golden VCR Inventory spreadsheet...\n")
+	tapes, sheetWarnings, err := sheets.ListTapes(ctx, sheetsClient)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tapes from spreadsheet: %w", err)
+	}
+	fmt.Fprintf(progress, "Got %d tapes:\n", len(tapes))
+	for _, tape := range tapes {
+		fmt.Fprintf(progress, "- %3d | %4d | %3d | %s\n", tape.Id, tape.Year, tape.Runtime, tape.Title)
+	}
+
+	// Get image URLs and metadata from our Spaces bucket
+	fmt.Fprintf(progress, "Retrieving image filenames and metadata from storage bucket...\n")
+	images, imageWarnings, err := storage.ListImages(ctx, storageClient, cache, progress)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving image data from storage bucket: %w", err)
+	}
+	fmt.Fprintf(progress, "Got %d images:\n", len(images))
+	for _, image := range images {
+		summary := fmt.Sprintf("%3d | %-9s | %s", image.TapeId, image.Type, image.Filename)
+		if image.Type == storage.ImageTypeGallery {
+			index := image.GalleryData.Index
+			md := image.GalleryData.Metadata
+			flag := ""
+			if md.Rotated {
+				flag = "rotated"
+			}
+			fmt.Fprintf(progress, "- %s | %d | %d x %d | %s | %s\n", summary, index, md.Width, md.Height, md.Color, flag)
+		} else {
+			fmt.Fprintf(progress, "- %s\n", summary)
+		}
+	}
+
+	return &SourceData{
+		tapes:         tapes,
+		sheetWarnings: sheetWarnings,
+		images:        images,
+		imageWarnings: imageWarnings,
+	}, nil
+}
+
+// ListedTapeIds returns the IDs of all tapes with valid rows in the spreadsheet,
+// regardless of whether they're eligible to be synced
+func (d *SourceData) ListedTapeIds() []int {
+	tapeIds := make([]int, 0, len(d.tapes))
+	for _, tape := range d.tapes {
+		tapeIds = append(tapeIds, tape.Id)
+	}
+	return tapeIds
+}
+
+// ExcludedTapeIds returns the IDs of all tapes that are listed in the spreadsheet but
+// that aren't among the given tapes to sync (as returned by Plan), i.e. tapes that
+// have no eligible gallery images, sorted by ID
+func (d *SourceData) ExcludedTapeIds(tapesToSync []TapeToSync) []int {
+	synced := make(map[int]struct{}, len(tapesToSync))
+	for _, t := range tapesToSync {
+		synced[t.Tape.Id] = struct{}{}
+	}
+	tapeIds := make([]int, 0)
+	for _, tape := range d.tapes {
+		if _, ok := synced[tape.Id]; !ok {
+			tapeIds = append(tapeIds, tape.Id)
+		}
+	}
+	sort.Ints(tapeIds)
+	return tapeIds
+}
+
+// Fingerprint returns a hash of all data gathered from the spreadsheet and storage
+// bucket, such that two syncs with the same fingerprint would have identical results
+func (d *SourceData) Fingerprint() string {
+	// Warnings about images aren't necessarily emitted in a consistent order, so sort
+	// them before hashing
+	warnings := make([]string, 0, len(d.sheetWarnings)+len(d.imageWarnings))
+	for _, warning := range d.sheetWarnings {
+		warnings = append(warnings, newSheetWarning(warning).String())
+	}
+	for _, warning := range d.imageWarnings {
+		warnings = append(warnings, newImageWarning(warning).String())
+	}
+	sort.Strings(warnings)
+
+	h := sha256.New()
+	json.NewEncoder(h).Encode(struct {
+		Tapes    []sheets.Tape
+		Images   []storage.Image
+		Warnings []string
+	}{d.tapes, d.images, warnings})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Plan determines which tapes should be synced, returning those tapes along with a
+// list of all warnings, so we can present a summary when finished
+func (d *SourceData) Plan() ([]TapeToSync, []Warning) {
+	// Collect the thumbnail and all of the gallery images that we need to record for
+	// each tape: storage.ListImages guarantees that every tape with gallery images also
+	// has a thumbnail image
+	thumbnailsByTapeId := make(map[int]storage.Image)
+	galleryImagesByTapeId := make(map[int][]storage.Image)
+	for _, image := range d.images {
+		if image.Type == storage.ImageTypeThumbnail {
+			thumbnailsByTapeId[image.TapeId] = image
+		} else if image.Type == storage.ImageTypeGallery {
+			galleryImagesByTapeId[image.TapeId] = append(galleryImagesByTapeId[image.TapeId], image)
+		}
+	}
+
+	// Collect a list of all warnings
+	warnings := make([]Warning, 0, len(d.sheetWarnings)+len(d.imageWarnings))
+	for _, warning := range d.sheetWarnings {
+		warnings = append(warnings, newSheetWarning(warning))
+	}
+	for _, warning := range d.imageWarnings {
+		warnings = append(warnings, newImageWarning(warning))
+	}
+
+	// Don't sync a tape unless it has at least one gallery image stored
+	tapes := make([]TapeToSync, 0, len(d.tapes))
+	for _, tape := range d.tapes {
+		galleryImages, ok := galleryImagesByTapeId[tape.Id]
+		if !ok || len(galleryImages) == 0 {
+			warnings = append(warnings, Warning{
+				source:  warningSourceSync,
+				kind:    warningKindNoGalleryImages,
+				tapeId:  tape.Id,
+				message: fmt.Sprintf("Tape %d has no gallery images; ignoring it.", tape.Id),
+			})
+			continue
+		}
+		tapes = append(tapes, TapeToSync{
+			Tape:          tape,
+			Thumbnail:     thumbnailsByTapeId[tape.Id],
+			GalleryImages: galleryImages,
+		})
+	}
+	return tapes, warnings
+}
@@ -0,0 +1,93 @@
+package syncer
+
+import (
+	"testing"
+
+	"github.com/golden-vcr/tapes/internal/sheets"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SourceData_Plan(t *testing.T) {
+	thumbnailImage := storage.Image{
+		Filename: "0001_thumb.jpg",
+		TapeId:   1,
+		Type:     storage.ImageTypeThumbnail,
+		ThumbnailData: &storage.ThumbnailImageData{
+			Metadata:    &storage.ImageMetadata{Width: 128, Height: 256, Color: "#febe99"},
+			ContentHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+	galleryImage := storage.Image{
+		Filename: "0001_a.jpg",
+		TapeId:   1,
+		Type:     storage.ImageTypeGallery,
+		GalleryData: &storage.GalleryImageData{
+			Index:    0,
+			Metadata: &storage.ImageMetadata{Width: 700, Height: 1500, Color: "#febe99"},
+		},
+	}
+	d := &SourceData{
+		tapes: []sheets.Tape{
+			{Id: 1, Title: "Tape one"},
+			{Id: 2, Title: "Tape two"},
+		},
+		sheetWarnings: []sheets.Warning{
+			{Kind: sheets.WarningKindInvalidRow, RowNumber: 4, TapeId: 3, Message: "'title' value is required"},
+		},
+		images: []storage.Image{
+			thumbnailImage,
+			galleryImage,
+		},
+		imageWarnings: []storage.Warning{
+			{Kind: storage.WarningKindMissingThumbnail, Filename: "0002_a.jpg", TapeId: 2, Message: "tape 2 has gallery image(s) but no accompanying thumbnail image"},
+		},
+	}
+
+	tapes, warnings := d.Plan()
+	assert.Equal(t, []TapeToSync{
+		{
+			Tape:          sheets.Tape{Id: 1, Title: "Tape one"},
+			Thumbnail:     thumbnailImage,
+			GalleryImages: []storage.Image{galleryImage},
+		},
+	}, tapes)
+	assert.Equal(t, []Warning{
+		{source: "spreadsheet", kind: "invalid_row", tapeId: 3, rowNumber: 4, message: "'title' value is required"},
+		{source: "storage", kind: "missing_thumbnail", tapeId: 2, filename: "0002_a.jpg", message: "tape 2 has gallery image(s) but no accompanying thumbnail image"},
+		{source: "sync", kind: "no_gallery_images", tapeId: 2, message: "Tape 2 has no gallery images; ignoring it."},
+	}, warnings)
+	assert.Equal(t, []string{
+		"Spreadsheet row 4: 'title' value is required",
+		"Image file 0002_a.jpg: tape 2 has gallery image(s) but no accompanying thumbnail image",
+		"Tape 2 has no gallery images; ignoring it.",
+	}, FormatWarnings(warnings))
+	assert.Equal(t, []int{1, 2}, d.ListedTapeIds())
+	assert.Equal(t, []int{2}, d.ExcludedTapeIds(tapes))
+}
+
+func Test_SourceData_Fingerprint(t *testing.T) {
+	newSourceData := func(title string, imageWarnings []storage.Warning) *SourceData {
+		return &SourceData{
+			tapes: []sheets.Tape{
+				{Id: 1, Title: title},
+			},
+			images: []storage.Image{
+				{Filename: "0001_thumb.jpg", TapeId: 1, Type: storage.ImageTypeThumbnail},
+			},
+			imageWarnings: imageWarnings,
+		}
+	}
+	warningA := storage.Warning{Kind: storage.WarningKindMissingThumbnail, Filename: "0002_a.jpg", TapeId: 2, Message: "no thumbnail"}
+	warningB := storage.Warning{Kind: storage.WarningKindMissingGalleryImages, Filename: "0003_thumb.jpg", TapeId: 3, Message: "no gallery images"}
+
+	fingerprint := newSourceData("Tape one", []storage.Warning{warningA, warningB}).Fingerprint()
+	assert.Len(t, fingerprint, 64)
+
+	// Warnings may be emitted in any order without affecting the fingerprint
+	assert.Equal(t, fingerprint, newSourceData("Tape one", []storage.Warning{warningB, warningA}).Fingerprint())
+
+	// Any change to the source data should result in a different fingerprint
+	assert.NotEqual(t, fingerprint, newSourceData("Tape one (edited)", []storage.Warning{warningA, warningB}).Fingerprint())
+	assert.NotEqual(t, fingerprint, newSourceData("Tape one", []storage.Warning{warningA}).Fingerprint())
+}
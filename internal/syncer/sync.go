@@ -0,0 +1,195 @@
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/db"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/google/uuid"
+)
+
+// syncTapes writes all tape and image data that's eligible to be synced to the
+// database, retiring any tapes that are no longer listed in the spreadsheet. Returns
+// the number of tapes synced, along with all warnings encountered. Progress messages are
+// written to progress.
+func syncTapes(ctx context.Context, syncUuid uuid.UUID, data *SourceData, q *queries.Queries, progress io.Writer) (int, []Warning, error) {
+	tapesToSync, warnings := data.Plan()
+
+	// Iterate over all tapes in the spreadsheet that are eligible to be synced
+	fmt.Fprintf(progress, "Syncing tape and image data to the tapes database...\n")
+	numTapesSynced := 0
+	for _, t := range tapesToSync {
+		tape := t.Tape
+
+		// Store year and runtime as NULL if not specified
+		yearValue := sql.NullInt32{}
+		if tape.Year > 0 {
+			yearValue.Valid = true
+			yearValue.Int32 = int32(tape.Year)
+		}
+		runtimeValue := sql.NullInt32{}
+		if tape.Runtime > 0 {
+			runtimeValue.Valid = true
+			runtimeValue.Int32 = int32(tape.Runtime)
+		}
+		contributorValue := sql.NullString{}
+		if tape.Contributor != "" {
+			contributorValue.Valid = true
+			contributorValue.String = tape.Contributor
+		}
+
+		// Upsert into the tape table to register our tape with its latest details
+		if err := q.SyncTape(ctx, queries.SyncTapeParams{
+			ID:            int32(tape.Id),
+			Title:         tape.Title,
+			Year:          yearValue,
+			Runtime:       runtimeValue,
+			ContributorID: contributorValue,
+		}); err != nil {
+			return -1, nil, fmt.Errorf("failed to sync tape %d: %w", tape.Id, err)
+		}
+
+		// Update tape_to_tag records for this tape ID, ensuring that the set of tags
+		// associated with this tape matches exactly what we parsed from the spreadsheet
+		if err := q.SyncTapeTags(ctx, queries.SyncTapeTagsParams{
+			TapeID:   int32(tape.Id),
+			TagNames: tape.Tags,
+		}); err != nil {
+			return -1, nil, fmt.Errorf("failed to sync tags for tape %d: %w", tape.Id, err)
+		}
+
+		// Register the tape's thumbnail image, so that the catalog can report its
+		// dimensions
+		if thumbnail := t.Thumbnail.ThumbnailData; thumbnail != nil {
+			if err := q.SyncThumbnail(ctx, queries.SyncThumbnailParams{
+				TapeID:      int32(tape.Id),
+				Color:       string(thumbnail.Metadata.Color),
+				Width:       int32(thumbnail.Metadata.Width),
+				Height:      int32(thumbnail.Metadata.Height),
+				Blurhash:    thumbnail.Metadata.Blurhash,
+				ContentHash: thumbnail.ContentHash,
+			}); err != nil {
+				return -1, nil, fmt.Errorf("failed to sync thumbnail for tape %d: %w", tape.Id, err)
+			}
+		}
+
+		// Get the metadata for all images associated with this tape, and register each
+		// of those images
+		imageIndices := make([]int32, 0, len(t.GalleryImages))
+		for _, image := range t.GalleryImages {
+			// Upsert into the image table to register the latest image metadata
+			variants, err := encodeVariants(image.GalleryData.Variants)
+			if err != nil {
+				return -1, nil, fmt.Errorf("failed to encode variants of image %d for tape %d: %w", image.GalleryData.Index, tape.Id, err)
+			}
+			if err := q.SyncImage(ctx, queries.SyncImageParams{
+				TapeID:   int32(tape.Id),
+				Index:    int32(image.GalleryData.Index),
+				Color:    string(image.GalleryData.Metadata.Color),
+				Width:    int32(image.GalleryData.Metadata.Width),
+				Height:   int32(image.GalleryData.Metadata.Height),
+				Rotated:  image.GalleryData.Metadata.Rotated,
+				Blurhash: image.GalleryData.Metadata.Blurhash,
+				Variants: variants,
+			}); err != nil {
+				return -1, nil, fmt.Errorf("failed to sync image %d for tape %d: %w", image.GalleryData.Index, tape.Id, err)
+			}
+			imageIndices = append(imageIndices, int32(image.GalleryData.Index))
+		}
+
+		// Delete any image records for this tape that no longer have a corresponding
+		// gallery image in the bucket
+		numImagesDeleted, err := q.DeleteStaleImages(ctx, queries.DeleteStaleImagesParams{
+			TapeID:  int32(tape.Id),
+			Indices: imageIndices,
+		})
+		if err != nil {
+			return -1, nil, fmt.Errorf("failed to delete stale images for tape %d: %w", tape.Id, err)
+		}
+		if numImagesDeleted > 0 {
+			fmt.Fprintf(progress, "Deleted %d stale image(s) for tape %d.\n", numImagesDeleted, tape.Id)
+		}
+		numTapesSynced++
+	}
+
+	// A tape that's still listed in the spreadsheet but that's been excluded from the
+	// sync (because its images were deleted or produced warnings) must not keep
+	// pointing at images that may no longer exist: delete all of its image records
+	// (including its thumbnail, which may be missing or invalid), so that it's omitted
+	// from the catalog until it has valid images again
+	for _, tapeId := range data.ExcludedTapeIds(tapesToSync) {
+		if _, err := q.DeleteThumbnail(ctx, int32(tapeId)); err != nil {
+			return -1, nil, fmt.Errorf("failed to delete thumbnail for excluded tape %d: %w", tapeId, err)
+		}
+		numImagesDeleted, err := q.DeleteStaleImages(ctx, queries.DeleteStaleImagesParams{
+			TapeID:  int32(tapeId),
+			Indices: []int32{},
+		})
+		if err != nil {
+			return -1, nil, fmt.Errorf("failed to delete images for excluded tape %d: %w", tapeId, err)
+		}
+		if numImagesDeleted > 0 {
+			fmt.Fprintf(progress, "Deleted %d image(s) for tape %d, which has no eligible images.\n", numImagesDeleted, tapeId)
+		}
+	}
+
+	// Any tape that's no longer listed in the spreadsheet has been removed from the
+	// library: mark it as retired so that it's excluded from the catalog, while leaving
+	// its data (and any favorites that reference it) intact. As a safeguard against
+	// wiping out the entire catalog, refuse to retire anything if the spreadsheet
+	// yielded no tapes whatsoever.
+	numTapesRetired := int64(0)
+	if len(data.tapes) > 0 {
+		activeTapeIds := make([]int32, 0, len(data.tapes))
+		for _, tape := range data.tapes {
+			activeTapeIds = append(activeTapeIds, int32(tape.Id))
+		}
+		var err error
+		numTapesRetired, err = q.RetireTapes(ctx, activeTapeIds)
+		if err != nil {
+			return -1, nil, fmt.Errorf("failed to retire tapes: %w", err)
+		}
+	} else {
+		warnings = append(warnings, Warning{
+			source:  warningSourceSync,
+			kind:    warningKindNoValidTapes,
+			message: "Spreadsheet contains no valid tapes; not retiring any existing tapes.",
+		})
+	}
+
+	// Record all warnings individually, so that we can easily look up which tapes
+	// have had problems in recent syncs
+	if err := recordWarnings(ctx, q, syncUuid, warnings); err != nil {
+		return -1, nil, err
+	}
+
+	fmt.Fprintf(progress, "Synced data for %d tape(s).\n", numTapesSynced)
+	if numTapesRetired > 0 {
+		fmt.Fprintf(progress, "Retired %d tape(s) that are no longer in the spreadsheet.\n", numTapesRetired)
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintf(progress, "Encountered %d warning(s):\n", len(warnings))
+		for _, warning := range warnings {
+			fmt.Fprintf(progress, "- %s\n", warning)
+		}
+	}
+	return numTapesSynced, warnings, nil
+}
+
+// encodeVariants converts the variants of a gallery image to the JSON array that's
+// stored in the image table
+func encodeVariants(variants []storage.ImageVariant) (json.RawMessage, error) {
+	values := make([]db.TapeImageVariant, 0, len(variants))
+	for _, variant := range variants {
+		values = append(values, db.TapeImageVariant{
+			Format: string(variant.Format),
+			Width:  int32(variant.Width),
+		})
+	}
+	return json.Marshal(values)
+}
@@ -0,0 +1,250 @@
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/sheets"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/google/uuid"
+)
+
+// ErrSyncInProgress is returned when attempting to start a sync while another sync is
+// already running in the same process
+var ErrSyncInProgress = errors.New("a sync is already in progress")
+
+// Syncer syncs tape data from the inventory spreadsheet and image data from the storage
+// bucket to the tapes database, recording the results of each sync in tapes.sync. Only
+// one sync may run at a time.
+type Syncer struct {
+	db            *sql.DB
+	sheetsClient  sheets.Client
+	storageClient storage.Client
+	cache         storage.MetadataCache
+	progress      io.Writer
+
+	mu      sync.Mutex
+	running bool
+}
+
+func New(db *sql.DB, sheetsClient sheets.Client, storageClient storage.Client, progress io.Writer) *Syncer {
+	return &Syncer{
+		db:            db,
+		sheetsClient:  sheetsClient,
+		storageClient: storageClient,
+		cache:         &metadataCache{q: queries.New(db)},
+		progress:      progress,
+	}
+}
+
+// Run performs a complete sync, blocking until it's finished. The UUID of the sync is
+// returned even if the sync fails, provided that it was recorded in the database.
+func (s *Syncer) Run(ctx context.Context) (uuid.UUID, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer unlock()
+
+	syncUuid, err := s.create(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	data, err := ListSources(ctx, s.sheetsClient, s.storageClient, s.cache, s.progress)
+	return syncUuid, s.run(ctx, syncUuid, data, err)
+}
+
+// RunIfChanged performs a complete sync, unless the data in the spreadsheet and storage
+// bucket is identical to what was synced by the last successful sync, in which case no
+// sync is recorded and ok is false
+func (s *Syncer) RunIfChanged(ctx context.Context) (syncUuid uuid.UUID, ok bool, err error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	defer unlock()
+
+	// If we can't list our sources, proceed with the sync anyway so that the failure
+	// is recorded
+	data, listErr := ListSources(ctx, s.sheetsClient, s.storageClient, s.cache, s.progress)
+	if listErr == nil {
+		latestFingerprint, err := queries.New(s.db).GetLatestSyncFingerprint(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, false, fmt.Errorf("failed to get fingerprint of latest sync: %w", err)
+		}
+		if latestFingerprint.Valid && latestFingerprint.String == data.Fingerprint() {
+			fmt.Fprintf(s.progress, "Spreadsheet and storage bucket are unchanged since the last sync.\n")
+			return uuid.Nil, false, nil
+		}
+	}
+
+	syncUuid, err = s.create(ctx)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return syncUuid, true, s.run(ctx, syncUuid, data, listErr)
+}
+
+// Start records the start of a new sync, then runs the rest of the sync in the
+// background, returning its UUID immediately so that the caller can check on its
+// progress. The given context governs the entire sync, not just the call to Start.
+func (s *Syncer) Start(ctx context.Context) (uuid.UUID, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	syncUuid, err := s.create(ctx)
+	if err != nil {
+		unlock()
+		return uuid.Nil, err
+	}
+	go func() {
+		defer unlock()
+		data, err := ListSources(ctx, s.sheetsClient, s.storageClient, s.cache, s.progress)
+		if err := s.run(ctx, syncUuid, data, err); err != nil {
+			fmt.Fprintf(s.progress, "Sync %s failed: %v\n", syncUuid, err)
+		} else {
+			fmt.Fprintf(s.progress, "Sync %s finished.\n", syncUuid)
+		}
+	}()
+	return syncUuid, nil
+}
+
+// lock ensures that no other sync is running, either in this process or any other
+// process connected to the same database, failing with ErrSyncInProgress if one is.
+// The caller must call the returned function once finished.
+func (s *Syncer) lock(ctx context.Context) (func(), error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+
+	// Advisory locks are held by a database session, so we need to use a dedicated
+	// connection that stays open until the sync is finished
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		s.release()
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	q := queries.New(conn)
+	acquired, err := q.TryAcquireSyncLock(ctx)
+	if err == nil && !acquired {
+		err = ErrSyncInProgress
+	}
+	if err != nil {
+		conn.Close()
+		s.release()
+		if errors.Is(err, ErrSyncInProgress) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to acquire sync lock: %w", err)
+	}
+
+	return func() {
+		// Release the lock even if the sync was canceled; closing the connection will
+		// release it in any case
+		if err := q.ReleaseSyncLock(context.Background()); err != nil {
+			fmt.Fprintf(s.progress, "WARNING: Failed to release sync lock: %v\n", err)
+		}
+		conn.Close()
+		s.release()
+	}, nil
+}
+
+// acquire marks a sync as running, failing with ErrSyncInProgress if one already is
+func (s *Syncer) acquire() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return ErrSyncInProgress
+	}
+	s.running = true
+	return nil
+}
+
+// release marks the current sync as finished
+func (s *Syncer) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+// create records the start of a new sync in the database (outside of a transaction;
+// we want this recorded immediately)
+func (s *Syncer) create(ctx context.Context) (uuid.UUID, error) {
+	syncUuid := uuid.New()
+	if err := queries.New(s.db).CreateSync(ctx, syncUuid); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to record new sync in database: %w", err)
+	}
+	fmt.Fprintf(s.progress, "sync uuid: %s\n", syncUuid)
+	return syncUuid, nil
+}
+
+// run syncs the data gathered from the spreadsheet and storage bucket to the database,
+// then records the results of the sync: if listErr is set, we failed to gather that
+// data, and the sync is recorded as failed
+func (s *Syncer) run(ctx context.Context, syncUuid uuid.UUID, data *SourceData, listErr error) error {
+	q := queries.New(s.db)
+
+	// Run the sync in a transaction, so that we only commit tape/image changes when
+	// finished syncing everything
+	var numTapesSynced int
+	var warnings []Warning
+	err := listErr
+	if err == nil {
+		numTapesSynced, warnings, err = s.syncInTx(ctx, syncUuid, data)
+	}
+
+	// Update the database to record the results of our sync
+	var recordResultErr error
+	if err == nil {
+		recordResultErr = q.RecordSuccessfulSync(ctx, queries.RecordSuccessfulSyncParams{
+			Uuid:     syncUuid,
+			NumTapes: int32(numTapesSynced),
+			Warnings: strings.Join(FormatWarnings(warnings), "\n"),
+			SourceFingerprint: sql.NullString{
+				Valid:  true,
+				String: data.Fingerprint(),
+			},
+		})
+	} else {
+		recordResultErr = q.RecordFailedSync(ctx, queries.RecordFailedSyncParams{
+			Uuid:  syncUuid,
+			Error: err.Error(),
+		})
+	}
+
+	// Treat that final DB update as a fatal error if the sync was successful; otherwise
+	// log it as a warning so we don't supersede the actual sync error
+	if recordResultErr != nil {
+		if err == nil {
+			return fmt.Errorf("sync results were not recorded: %w", recordResultErr)
+		}
+		fmt.Fprintf(s.progress, "WARNING: Sync results were not recorded: %v\n", recordResultErr)
+	}
+	return err
+}
+
+// syncInTx syncs the given data to the database in a single transaction
+func (s *Syncer) syncInTx(ctx context.Context, syncUuid uuid.UUID, data *SourceData) (int, []Warning, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, nil, fmt.Errorf("failed to begin database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	numTapesSynced, warnings, err := syncTapes(ctx, syncUuid, data, queries.New(tx), s.progress)
+	if err != nil {
+		return -1, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return -1, nil, fmt.Errorf("failed to commit database transaction: %w", err)
+	}
+	return numTapesSynced, warnings, nil
+}
@@ -0,0 +1,20 @@
+package syncer
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Syncer_acquire(t *testing.T) {
+	s := New(nil, nil, nil, io.Discard)
+
+	// Only one sync may run at a time
+	assert.NoError(t, s.acquire())
+	assert.ErrorIs(t, s.acquire(), ErrSyncInProgress)
+
+	// Once the running sync is finished, another may start
+	s.release()
+	assert.NoError(t, s.acquire())
+}
@@ -0,0 +1,107 @@
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/golden-vcr/tapes/internal/sheets"
+	"github.com/golden-vcr/tapes/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Each warning is attributed to one of these sources, identifying where the problem
+// was found
+const (
+	warningSourceSpreadsheet = "spreadsheet"
+	warningSourceStorage     = "storage"
+	warningSourceSync        = "sync"
+)
+
+// Tape-level problems that are detected while reconciling spreadsheet data with the
+// contents of the storage bucket
+const (
+	warningKindNoGalleryImages = "no_gallery_images"
+	warningKindNoValidTapes    = "no_valid_tapes"
+)
+
+// Warning is a non-fatal problem encountered during a sync, from any source
+type Warning struct {
+	source    string
+	kind      string
+	tapeId    int
+	rowNumber int
+	filename  string
+	message   string
+}
+
+// newSheetWarning converts a warning emitted while parsing the inventory spreadsheet
+func newSheetWarning(w sheets.Warning) Warning {
+	return Warning{
+		source:    warningSourceSpreadsheet,
+		kind:      string(w.Kind),
+		tapeId:    w.TapeId,
+		rowNumber: w.RowNumber,
+		message:   w.Message,
+	}
+}
+
+// newImageWarning converts a warning emitted while listing images in the storage bucket
+func newImageWarning(w storage.Warning) Warning {
+	return Warning{
+		source:   warningSourceStorage,
+		kind:     string(w.Kind),
+		tapeId:   w.TapeId,
+		filename: w.Filename,
+		message:  w.Message,
+	}
+}
+
+// String formats the warning as a single human-readable line, as presented in the
+// sync's output and recorded in tapes.sync.warnings
+func (w Warning) String() string {
+	switch w.source {
+	case warningSourceSpreadsheet:
+		return fmt.Sprintf("Spreadsheet row %d: %s", w.rowNumber, w.message)
+	case warningSourceStorage:
+		return fmt.Sprintf("Image file %s: %s", w.filename, w.message)
+	}
+	return w.message
+}
+
+// FormatWarnings returns each warning formatted as a single line
+func FormatWarnings(warnings []Warning) []string {
+	lines := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		lines = append(lines, w.String())
+	}
+	return lines
+}
+
+// recordWarnings stores all warnings from a sync in the tapes.sync_warning table, in
+// the order they were emitted
+func recordWarnings(ctx context.Context, q *queries.Queries, syncUuid uuid.UUID, warnings []Warning) error {
+	for i, w := range warnings {
+		params := queries.RecordSyncWarningParams{
+			SyncUuid: syncUuid,
+			Index:    int32(i),
+			Source:   w.source,
+			Kind:     w.kind,
+			Message:  w.message,
+		}
+		if w.tapeId > 0 {
+			params.TapeID = sql.NullInt32{Valid: true, Int32: int32(w.tapeId)}
+		}
+		if w.rowNumber > 0 {
+			params.RowNumber = sql.NullInt32{Valid: true, Int32: int32(w.rowNumber)}
+		}
+		if w.filename != "" {
+			params.Filename = sql.NullString{Valid: true, String: w.filename}
+		}
+		if err := q.RecordSyncWarning(ctx, params); err != nil {
+			return fmt.Errorf("failed to record warning %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package upload prepares scanned images of a tape for upload to the storage bucket,
+// producing the thumbnail and gallery files (along with the metadata) that a sync
+// expects to find there
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/golden-vcr/tapes/internal/imaging"
+	"github.com/golden-vcr/tapes/internal/storage"
+)
+
+// DefaultThumbnailSize is the default size, in pixels, of the longest side of a tape's
+// thumbnail image
+const DefaultThumbnailSize = 256
+
+// jpegQuality is the quality used when an image has to be re-encoded as a JPEG
+const jpegQuality = 90
+
+// Scan is a single scanned image of a tape, as read from disk
+type Scan struct {
+	// Name identifies the scan in error messages, e.g. the path it was read from
+	Name string
+	// Data is the raw content of the image file, in JPEG or PNG format
+	Data []byte
+}
+
+// File is a single image file that's ready to be uploaded to the storage bucket
+type File struct {
+	// Filename is the key under which the file should be stored
+	Filename string
+	// Data is the JPEG-encoded image data
+	Data []byte
+	// Metadata is the set of key/value pairs to be stored as S3 metadata, or nil if
+	// the file requires no metadata
+	Metadata storage.Metadata
+}
+
+// PrepareFiles converts the given scans, in display order, into the gallery images for
+// the given tape, followed by a thumbnail generated from the first scan. Landscape
+// scans are rotated to have a vertical aspect ratio, and each gallery image's metadata
+// is computed from its pixel data.
+func PrepareFiles(tapeId int, scans []Scan, thumbnailSize int) ([]File, error) {
+	if len(scans) == 0 {
+		return nil, fmt.Errorf("at least one scan is required")
+	}
+	if len(scans) > storage.MaxGalleryImages {
+		return nil, fmt.Errorf("got %d scans; a tape may have at most %d gallery images", len(scans), storage.MaxGalleryImages)
+	}
+	if thumbnailSize <= 0 {
+		return nil, fmt.Errorf("thumbnail size must be positive (got %d)", thumbnailSize)
+	}
+
+	files := make([]File, 0, len(scans)+1)
+	var thumbnail image.Image
+	for i, scan := range scans {
+		img, format, err := image.Decode(bytes.NewReader(scan.Data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", scan.Name, err)
+		}
+
+		// The thumbnail isn't rotated, so it's generated from the scan as-is
+		if i == 0 {
+			thumbnail = imaging.Fit(img, thumbnailSize)
+		}
+
+		// Rotate landscape scans so that every gallery image is vertical, and
+		// re-encode the image only if necessary, to avoid losing quality
+		rotated := false
+		if img.Bounds().Dx() > img.Bounds().Dy() {
+			img = imaging.RotateCounterClockwise(img)
+			rotated = true
+		}
+		data := scan.Data
+		if rotated || format != "jpeg" {
+			data, err = encodeJpeg(img)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s: %w", scan.Name, err)
+			}
+		}
+
+		analysis := imaging.AnalyzeImage(img)
+		metadata := &storage.ImageMetadata{
+			Width:    analysis.Width,
+			Height:   analysis.Height,
+			Color:    storage.HexColor(analysis.Color),
+			Rotated:  rotated,
+			Blurhash: analysis.Blurhash,
+		}
+		filename, err := storage.GetImageFilename(tapeId, storage.ImageTypeGallery, i)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{
+			Filename: filename,
+			Data:     data,
+			Metadata: metadata.ToMetadata(),
+		})
+	}
+
+	thumbnailData, err := encodeJpeg(thumbnail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	thumbnailFilename, err := storage.GetImageFilename(tapeId, storage.ImageTypeThumbnail, 0)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, File{
+		Filename: thumbnailFilename,
+		Data:     thumbnailData,
+	})
+	return files, nil
+}
+
+func encodeJpeg(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
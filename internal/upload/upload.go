@@ -0,0 +1,58 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golden-vcr/tapes/internal/storage"
+)
+
+// Result summarizes the changes made to the storage bucket by an upload
+type Result struct {
+	// Uploaded lists the names of all files that were written
+	Uploaded []string
+	// Deleted lists the names of any existing images for the tape that were removed
+	// because they were not replaced by a new file
+	Deleted []string
+}
+
+// Upload writes the given files for a tape to the storage bucket. If the tape already
+// has images in the bucket, the upload fails unless replace is true, in which case any
+// existing images that aren't overwritten are deleted once the upload is complete.
+func Upload(ctx context.Context, u storage.Uploader, tapeId int, files []File, replace bool) (*Result, error) {
+	listing, err := u.ListFilenames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing files: %w", err)
+	}
+	existing := storage.GetTapeImageFilenames(listing, tapeId)
+	if len(existing) > 0 && !replace {
+		return nil, fmt.Errorf("tape %d already has images in storage (%s)", tapeId, strings.Join(existing, ", "))
+	}
+
+	result := &Result{
+		Uploaded: make([]string, 0, len(files)),
+		Deleted:  make([]string, 0),
+	}
+	uploaded := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		if err := u.UploadFile(ctx, file.Filename, file.Data, file.Metadata); err != nil {
+			return result, fmt.Errorf("failed to upload %s: %w", file.Filename, err)
+		}
+		result.Uploaded = append(result.Uploaded, file.Filename)
+		uploaded[file.Filename] = struct{}{}
+	}
+
+	// Remove stale images (e.g. extra gallery images from a previous upload with more
+	// scans) so that the tape's gallery consists only of the new files
+	for _, filename := range existing {
+		if _, ok := uploaded[filename]; ok {
+			continue
+		}
+		if err := u.DeleteFile(ctx, filename); err != nil {
+			return result, fmt.Errorf("failed to delete %s: %w", filename, err)
+		}
+		result.Deleted = append(result.Deleted, filename)
+	}
+	return result, nil
+}
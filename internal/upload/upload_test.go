@@ -0,0 +1,107 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/golden-vcr/tapes/internal/storage"
+)
+
+func Test_PrepareFiles(t *testing.T) {
+	front := encodeTestImage(t, 60, 120, color.RGBA{255, 255, 255, 255}, "jpeg")
+	back := encodeTestImage(t, 120, 60, color.RGBA{0, 0, 0, 255}, "png")
+
+	files, err := PrepareFiles(42, []Scan{{"front.jpg", front}, {"back.png", back}}, 30)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// Portrait JPEG scans should be uploaded as-is
+	assert.Equal(t, "0042_a.jpg", files[0].Filename)
+	assert.Equal(t, front, files[0].Data)
+	assert.Equal(t, storage.Metadata{"Width": "60", "Height": "120", "Color": "#ffffff", "Rotated": "false", "Blurhash": "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"}, files[0].Metadata)
+
+	// Landscape scans should be rotated and re-encoded as JPEG
+	assert.Equal(t, "0042_b.jpg", files[1].Filename)
+	assert.Equal(t, storage.Metadata{"Width": "60", "Height": "120", "Color": "#000000", "Rotated": "true", "Blurhash": "T00000fQfQfQfQfQfQfQfQfQfQfQ"}, files[1].Metadata)
+	img, format, err := image.Decode(bytes.NewReader(files[1].Data))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, image.Rect(0, 0, 60, 120), img.Bounds())
+
+	// The thumbnail should be a scaled-down copy of the first scan, with no metadata
+	assert.Equal(t, "0042_thumb.jpg", files[2].Filename)
+	assert.Nil(t, files[2].Metadata)
+	img, err = jpeg.Decode(bytes.NewReader(files[2].Data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 15, 30), img.Bounds())
+}
+
+func Test_PrepareFiles_errors(t *testing.T) {
+	_, err := PrepareFiles(42, nil, 30)
+	assert.EqualError(t, err, "at least one scan is required")
+
+	_, err = PrepareFiles(42, []Scan{{"front.jpg", []byte("not an image")}}, 30)
+	assert.ErrorContains(t, err, "failed to decode front.jpg")
+
+	scans := make([]Scan, storage.MaxGalleryImages+1)
+	_, err = PrepareFiles(42, scans, 30)
+	assert.EqualError(t, err, "got 18279 scans; a tape may have at most 18278 gallery images")
+}
+
+func Test_Upload(t *testing.T) {
+	dir := t.TempDir()
+	u, err := storage.NewLocalUploader(dir)
+	assert.NoError(t, err)
+	for _, filename := range []string{"0042_thumb.jpg", "0042_a.jpg", "0042_b.jpg", "0042_c.jpg"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte("old"), 0644))
+	}
+
+	scan := encodeTestImage(t, 60, 120, color.RGBA{255, 255, 255, 255}, "jpeg")
+	files, err := PrepareFiles(42, []Scan{{"front.jpg", scan}, {"back.jpg", scan}}, 30)
+	assert.NoError(t, err)
+
+	// Existing images should not be overwritten unless requested
+	_, err = Upload(context.Background(), u, 42, files, false)
+	assert.EqualError(t, err, "tape 42 already has images in storage (0042_a.jpg, 0042_b.jpg, 0042_c.jpg, 0042_thumb.jpg)")
+
+	// When replacing, stale images should be removed
+	result, err := Upload(context.Background(), u, 42, files, true)
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{
+		Uploaded: []string{"0042_a.jpg", "0042_b.jpg", "0042_thumb.jpg"},
+		Deleted:  []string{"0042_c.jpg"},
+	}, result)
+
+	// The uploaded images should be accepted by a sync without any warnings
+	images, warnings, err := storage.ListImages(context.Background(), u, nil, io.Discard)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Len(t, images, 3)
+	assert.Equal(t, &storage.ImageMetadata{Width: 60, Height: 120, Color: "#ffffff", Blurhash: "T9TSUA~qfQ-;j[fQfQfQfQ-;j[fQ"}, images[1].GalleryData.Metadata)
+}
+
+func encodeTestImage(t *testing.T, width int, height int, c color.Color, format string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if format == "png" {
+		assert.NoError(t, png.Encode(&buf, img))
+	} else {
+		assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,211 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+)
+
+// retryDelay is how long we'll wait before trying to fetch display names again after
+// a failed request
+const retryDelay = 30 * time.Second
+
+type Queries interface {
+	GetTwitchUsers(ctx context.Context, twitchUserIds []string) ([]queries.TapesTwitchUser, error)
+	RecordTwitchUser(ctx context.Context, arg queries.RecordTwitchUserParams) error
+}
+
+// CachedLookup is an implementation of users.Lookup that serves display names from
+// memory, backed by the tapes.twitch_user table. Resolve never waits on the Fetcher:
+// any users that are unknown or whose names are older than the configured TTL are
+// queued up, and their names are fetched in the background by Run.
+type CachedLookup struct {
+	q   Queries
+	f   Fetcher
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	pending map[string]struct{}
+	wake    chan struct{}
+}
+
+// cacheEntry is the last known display name for a single user, where an empty display
+// name indicates that the user could not be found
+type cacheEntry struct {
+	displayName string
+	updatedAt   time.Time
+}
+
+func NewCachedLookup(q Queries, f Fetcher, ttl time.Duration) *CachedLookup {
+	return &CachedLookup{
+		q:       q,
+		f:       f,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+		pending: make(map[string]struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (l *CachedLookup) Resolve(ctx context.Context, ids []string) error {
+	// Find the subset of requested user IDs that we don't yet have in memory
+	idsToLoad := make([]string, 0)
+	l.mu.RLock()
+	for _, id := range ids {
+		if _, ok := l.entries[id]; !ok {
+			idsToLoad = append(idsToLoad, id)
+		}
+	}
+	l.mu.RUnlock()
+
+	// Load whatever names we've previously recorded for those users from the database
+	var err error
+	if len(idsToLoad) > 0 {
+		var rows []queries.TapesTwitchUser
+		rows, err = l.q.GetTwitchUsers(ctx, idsToLoad)
+		if err == nil {
+			l.mu.Lock()
+			for _, row := range rows {
+				if _, ok := l.entries[row.TwitchUserID]; !ok {
+					l.entries[row.TwitchUserID] = cacheEntry{
+						displayName: row.DisplayName,
+						updatedAt:   row.UpdatedAt,
+					}
+				}
+			}
+			l.mu.Unlock()
+		} else {
+			err = fmt.Errorf("failed to load cached Twitch users: %w", err)
+		}
+	}
+
+	// Any users that are still unknown, or whose names have expired, should be fetched
+	// in the background
+	l.mu.Lock()
+	numQueued := 0
+	for _, id := range ids {
+		entry, ok := l.entries[id]
+		if !ok || l.now().Sub(entry.updatedAt) >= l.ttl {
+			if _, ok := l.pending[id]; !ok {
+				l.pending[id] = struct{}{}
+				numQueued++
+			}
+		}
+	}
+	l.mu.Unlock()
+	if numQueued > 0 {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+func (l *CachedLookup) GetDisplayName(id string) string {
+	l.mu.RLock()
+	entry, ok := l.entries[id]
+	l.mu.RUnlock()
+	if !ok || entry.displayName == "" {
+		return fmt.Sprintf("User %s", id)
+	}
+	return entry.displayName
+}
+
+// Run fetches display names for users that have been queued up by Resolve, recording
+// them in the database, until the given context is canceled
+func (l *CachedLookup) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.wake:
+		}
+
+		if err := l.refresh(ctx); err != nil {
+			fmt.Printf("Error refreshing Twitch display names: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			// Try again with whatever is still pending
+			select {
+			case l.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// refresh fetches display names for all pending users: users remain pending until
+// their names have been fetched, so that a failed request will be retried
+func (l *CachedLookup) refresh(ctx context.Context) error {
+	l.mu.RLock()
+	ids := make([]string, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	l.mu.RUnlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// If some users couldn't be fetched, leave them pending but still record whatever
+	// names we did manage to get
+	displayNamesById, err := l.f.FetchDisplayNames(ctx, ids)
+	var partialErr *PartialFetchError
+	if errors.As(err, &partialErr) {
+		failed := make(map[string]struct{}, len(partialErr.FailedIds))
+		for _, id := range partialErr.FailedIds {
+			failed[id] = struct{}{}
+		}
+		fetchedIds := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := failed[id]; !ok {
+				fetchedIds = append(fetchedIds, id)
+			}
+		}
+		ids = fetchedIds
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch display names: %w", err)
+	}
+
+	// Record the names we fetched so they'll survive a restart; if we fail to write to
+	// the database, we can still serve the new names from memory
+	for id, displayName := range displayNamesById {
+		if err := l.q.RecordTwitchUser(ctx, queries.RecordTwitchUserParams{
+			TwitchUserID: id,
+			DisplayName:  displayName,
+		}); err != nil {
+			fmt.Printf("Error recording display name for Twitch user %s: %v\n", id, err)
+		}
+	}
+
+	// Update our in-memory cache: users that weren't found keep their last known name
+	// (if any) until the TTL elapses again, so that we don't refetch them constantly
+	now := l.now()
+	l.mu.Lock()
+	for _, id := range ids {
+		displayName, ok := displayNamesById[id]
+		if !ok {
+			displayName = l.entries[id].displayName
+		}
+		l.entries[id] = cacheEntry{
+			displayName: displayName,
+			updatedAt:   now,
+		}
+		delete(l.pending, id)
+	}
+	l.mu.Unlock()
+	return err
+}
+
+var _ Lookup = (*CachedLookup)(nil)
@@ -0,0 +1,212 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/gen/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CachedLookup(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	q := &mockQueries{
+		rows: map[string]queries.TapesTwitchUser{
+			"1000": {TwitchUserID: "1000", DisplayName: "FreshName", UpdatedAt: now.Add(-time.Hour)},
+			"2000": {TwitchUserID: "2000", DisplayName: "OldName", UpdatedAt: now.Add(-48 * time.Hour)},
+		},
+	}
+	f := &mockFetcher{
+		displayNamesById: map[string]string{
+			"1000": "FreshName",
+			"2000": "NewName",
+			"3000": "NewUser",
+		},
+	}
+	l := NewCachedLookup(q, f, 24*time.Hour)
+	l.now = func() time.Time { return now }
+
+	// Resolving should load names from the database without waiting on the fetcher,
+	// queueing up unknown and expired users to be fetched in the background
+	err := l.Resolve(context.Background(), []string{"1000", "2000", "3000", "4000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "FreshName", l.GetDisplayName("1000"))
+	assert.Equal(t, "OldName", l.GetDisplayName("2000"))
+	assert.Equal(t, "User 3000", l.GetDisplayName("3000"))
+	assert.Equal(t, "User 4000", l.GetDisplayName("4000"))
+	assert.Empty(t, f.requestedIds)
+
+	// Once refreshed, new names should be served from memory and recorded in the
+	// database, and users that couldn't be found should fall back to a generic name
+	err = l.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"2000", "3000", "4000"}, f.requestedIds)
+	assert.Equal(t, "FreshName", l.GetDisplayName("1000"))
+	assert.Equal(t, "NewName", l.GetDisplayName("2000"))
+	assert.Equal(t, "NewUser", l.GetDisplayName("3000"))
+	assert.Equal(t, "User 4000", l.GetDisplayName("4000"))
+	assert.Equal(t, "NewName", q.rows["2000"].DisplayName)
+	assert.Equal(t, "NewUser", q.rows["3000"].DisplayName)
+	assert.NotContains(t, q.rows, "4000")
+
+	// Resolving again should not require any further fetches until the TTL elapses
+	f.requestedIds = nil
+	err = l.Resolve(context.Background(), []string{"1000", "2000", "3000", "4000"})
+	assert.NoError(t, err)
+	assert.Empty(t, l.pending)
+	now = now.Add(25 * time.Hour)
+	err = l.Resolve(context.Background(), []string{"1000", "2000", "3000", "4000"})
+	assert.NoError(t, err)
+	assert.Len(t, l.pending, 4)
+}
+
+func Test_CachedLookup_refresh_failure(t *testing.T) {
+	q := &mockQueries{}
+	f := &mockFetcher{err: fmt.Errorf("mock error")}
+	l := NewCachedLookup(q, f, 24*time.Hour)
+
+	err := l.Resolve(context.Background(), []string{"1000"})
+	assert.NoError(t, err)
+
+	// If the fetch fails, the user should remain pending so we can try again later
+	err = l.refresh(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "User 1000", l.GetDisplayName("1000"))
+	assert.Len(t, l.pending, 1)
+
+	f.err = nil
+	f.displayNamesById = map[string]string{"1000": "JoeBob"}
+	err = l.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "JoeBob", l.GetDisplayName("1000"))
+	assert.Empty(t, l.pending)
+}
+
+func Test_CachedLookup_refresh_partialFailure(t *testing.T) {
+	q := &mockQueries{}
+	f := &mockFetcher{
+		displayNamesById: map[string]string{"1000": "JoeBob", "2000": "BigBilly"},
+		failedIds:        []string{"2000"},
+	}
+	l := NewCachedLookup(q, f, 24*time.Hour)
+
+	err := l.Resolve(context.Background(), []string{"1000", "2000"})
+	assert.NoError(t, err)
+
+	// Users that were fetched should be recorded, while the rest remain pending
+	err = l.refresh(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "JoeBob", l.GetDisplayName("1000"))
+	assert.Equal(t, "User 2000", l.GetDisplayName("2000"))
+	assert.Contains(t, q.rows, "1000")
+	assert.NotContains(t, q.rows, "2000")
+	assert.Equal(t, map[string]struct{}{"2000": {}}, l.pending)
+
+	f.failedIds = nil
+	err = l.refresh(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "BigBilly", l.GetDisplayName("2000"))
+	assert.Empty(t, l.pending)
+}
+
+func Test_CachedLookup_concurrency(t *testing.T) {
+	q := &mockQueries{}
+	f := &mockFetcher{displayNamesById: map[string]string{"1000": "JoeBob"}}
+	l := NewCachedLookup(q, f, 24*time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, l.Resolve(context.Background(), []string{"1000", "2000"}))
+			l.GetDisplayName("1000")
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		return l.GetDisplayName("1000") == "JoeBob"
+	}, time.Second, 10*time.Millisecond)
+}
+
+type mockQueries struct {
+	mu   sync.Mutex
+	rows map[string]queries.TapesTwitchUser
+}
+
+func (m *mockQueries) GetTwitchUsers(ctx context.Context, twitchUserIds []string) ([]queries.TapesTwitchUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]queries.TapesTwitchUser, 0)
+	for _, id := range twitchUserIds {
+		if row, ok := m.rows[id]; ok {
+			result = append(result, row)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockQueries) RecordTwitchUser(ctx context.Context, arg queries.RecordTwitchUserParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rows == nil {
+		m.rows = make(map[string]queries.TapesTwitchUser)
+	}
+	m.rows[arg.TwitchUserID] = queries.TapesTwitchUser{
+		TwitchUserID: arg.TwitchUserID,
+		DisplayName:  arg.DisplayName,
+		UpdatedAt:    time.Now(),
+	}
+	return nil
+}
+
+var _ Queries = (*mockQueries)(nil)
+
+type mockFetcher struct {
+	mu               sync.Mutex
+	err              error
+	failedIds        []string
+	displayNamesById map[string]string
+	requestedIds     []string
+}
+
+func (m *mockFetcher) FetchDisplayNames(ctx context.Context, ids []string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requestedIds = append(m.requestedIds, ids...)
+	if m.err != nil {
+		return nil, m.err
+	}
+	failed := make([]string, 0)
+	result := make(map[string]string)
+	for _, id := range ids {
+		if containsString(m.failedIds, id) {
+			failed = append(failed, id)
+		} else if displayName, ok := m.displayNamesById[id]; ok {
+			result[id] = displayName
+		}
+	}
+	if len(failed) > 0 {
+		return result, &PartialFetchError{FailedIds: failed, Err: fmt.Errorf("mock error")}
+	}
+	return result, nil
+}
+
+var _ Fetcher = (*mockFetcher)(nil)
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
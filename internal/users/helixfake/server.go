@@ -0,0 +1,180 @@
+// Package helixfake provides an in-process stand-in for the subset of the Twitch API
+// that we use to resolve users' display names, so that the tapes server can be run (and
+// tested) without network access or real Twitch credentials.
+package helixfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxUsersPerRequest mirrors the limit imposed by the real Twitch API on the number of
+// IDs that may be supplied in a single GetUsers call
+const maxUsersPerRequest = 100
+
+// Server is a fake Twitch API server that issues app access tokens and serves user
+// details from an in-memory map of display names, keyed by Twitch User ID
+type Server struct {
+	*httptest.Server
+
+	mu               sync.Mutex
+	displayNamesById map[string]string
+	validTokens      map[string]struct{}
+	numTokens        int
+	numUsersRequests int
+}
+
+// NewServer starts a new fake Twitch API server that knows about the given users; the
+// caller is responsible for calling Close when finished
+func NewServer(displayNamesById map[string]string) *Server {
+	s := &Server{
+		displayNamesById: make(map[string]string, len(displayNamesById)),
+		validTokens:      make(map[string]struct{}),
+	}
+	for id, displayName := range displayNamesById {
+		s.displayNamesById[id] = displayName
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", s.handleToken)
+	mux.HandleFunc("/helix/users", s.handleUsers)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// HTTPClient returns an HTTP client that redirects all requests intended for the real
+// Twitch API (i.e. api.twitch.tv and id.twitch.tv) to this server
+func (s *Server) HTTPClient() *http.Client {
+	target, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return &http.Client{
+		Transport: &redirectTransport{
+			target: target,
+			next:   http.DefaultTransport,
+		},
+	}
+}
+
+// SetDisplayName adds or renames a user
+func (s *Server) SetDisplayName(id string, displayName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.displayNamesById[id] = displayName
+}
+
+// ExpireTokens invalidates all app access tokens issued so far, so that subsequent API
+// requests will fail with a 401 until a new token is requested
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validTokens = make(map[string]struct{})
+}
+
+// NumTokensIssued returns the number of app access tokens that have been requested
+func (s *Server) NumTokensIssued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.numTokens
+}
+
+// NumUsersRequests returns the number of GetUsers calls that have been handled
+func (s *Server) NumUsersRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.numUsersRequests
+}
+
+func (s *Server) handleToken(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.URL.Query().Get("grant_type") != "client_credentials" {
+		writeError(res, http.StatusBadRequest, "unsupported grant_type")
+		return
+	}
+
+	s.mu.Lock()
+	s.numTokens++
+	token := fmt.Sprintf("fake-app-access-token-%d", s.numTokens)
+	s.validTokens[token] = struct{}{}
+	s.mu.Unlock()
+
+	writeJSON(res, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"expires_in":   3600,
+		"token_type":   "bearer",
+	})
+}
+
+func (s *Server) handleUsers(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.numUsersRequests++
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if _, ok := s.validTokens[token]; !ok {
+		writeError(res, http.StatusUnauthorized, "Invalid OAuth token")
+		return
+	}
+
+	ids := req.URL.Query()["id"]
+	if len(ids) > maxUsersPerRequest {
+		writeError(res, http.StatusBadRequest, fmt.Sprintf("The parameter \"id\" was malformed: the value must be less than or equal to %d", maxUsersPerRequest))
+		return
+	}
+
+	users := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		if displayName, ok := s.displayNamesById[id]; ok {
+			users = append(users, map[string]string{
+				"id":           id,
+				"login":        strings.ToLower(displayName),
+				"display_name": displayName,
+			})
+		}
+	}
+	writeJSON(res, http.StatusOK, map[string]interface{}{
+		"data": users,
+	})
+}
+
+func writeError(res http.ResponseWriter, status int, message string) {
+	writeJSON(res, status, map[string]interface{}{
+		"error":   http.StatusText(status),
+		"status":  status,
+		"message": message,
+	})
+}
+
+func writeJSON(res http.ResponseWriter, status int, value interface{}) {
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(status)
+	json.NewEncoder(res).Encode(value)
+}
+
+// redirectTransport is an http.RoundTripper that sends all requests to a fixed host
+type redirectTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	redirected := req.Clone(req.Context())
+	redirected.URL.Scheme = t.target.Scheme
+	redirected.URL.Host = t.target.Host
+	redirected.Host = t.target.Host
+	return t.next.RoundTrip(redirected)
+}
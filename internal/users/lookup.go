@@ -3,9 +3,6 @@ package users
 import (
 	"context"
 	"fmt"
-	"net/http"
-
-	"github.com/nicklaw5/helix/v2"
 )
 
 type Lookup interface {
@@ -13,75 +10,27 @@ type Lookup interface {
 	GetDisplayName(id string) string
 }
 
-func NewLookup(twitchClientId string, twitchClientSecret string) (Lookup, error) {
-	c, err := helix.NewClient(&helix.Options{
-		ClientID:     twitchClientId,
-		ClientSecret: twitchClientSecret,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Twitch API client: %w", err)
-	}
-
-	res, err := c.RequestAppAccessToken(nil)
-	if err == nil && res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("got status %d: %s", res.StatusCode, res.ErrorMessage)
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to get app access token from Twitch API: %w", err)
-	}
-
-	c.SetAppAccessToken(res.Data.AccessToken)
-	return &twitchUserLookup{
-		c:                c,
-		displayNamesById: make(map[string]string),
-	}, nil
+// Fetcher is the source of truth for users' display names, given their numeric Twitch
+// User IDs (e.g. the Twitch API)
+type Fetcher interface {
+	// FetchDisplayNames returns the current display name of each user that could be
+	// found: IDs that don't identify a user are omitted from the resulting map. If only
+	// some users could be fetched, a *PartialFetchError is returned along with the map.
+	FetchDisplayNames(ctx context.Context, ids []string) (map[string]string, error)
 }
 
-// twitchUserLookup is an implementation of users.Lookup that uses the Twitch API in
-// order to resolve user's human-facing display names given their numeric Twitch User ID
-// values.
-type twitchUserLookup struct {
-	c                *helix.Client
-	displayNamesById map[string]string
+// PartialFetchError is returned by a Fetcher when display names could not be fetched
+// for some of the requested users, in which case the accompanying map contains the
+// names that were successfully fetched for all other users
+type PartialFetchError struct {
+	FailedIds []string
+	Err       error
 }
 
-func (l *twitchUserLookup) Resolve(ctx context.Context, ids []string) error {
-	// Find the subset of requested user IDs that we haven't already cached names for
-	idsToResolve := make([]string, 0, len(ids))
-	for _, id := range ids {
-		if _, ok := l.displayNamesById[id]; !ok {
-			idsToResolve = append(idsToResolve, id)
-			// Twitch API limits us to 100 user IDs per GetUsers call, so just cap our
-			// input at 100 and we'll resolve the remainder on subsequent calls
-			if len(idsToResolve) == 100 {
-				break
-			}
-		}
-	}
-
-	// If we have nothing to look up, early-out
-	if len(idsToResolve) == 0 {
-		return nil
-	}
-
-	// Make a call to the Twitch API to resolve display names for all of our users
-	res, err := l.c.GetUsers(&helix.UsersParams{
-		IDs: idsToResolve,
-	})
-	if err != nil {
-		return err
-	}
-
-	for _, user := range res.Data.Users {
-		l.displayNamesById[user.ID] = user.DisplayName
-	}
-	return nil
+func (e *PartialFetchError) Error() string {
+	return fmt.Sprintf("failed to fetch display names for %d user(s): %v", len(e.FailedIds), e.Err)
 }
 
-func (l *twitchUserLookup) GetDisplayName(id string) string {
-	displayName, ok := l.displayNamesById[id]
-	if !ok {
-		return fmt.Sprintf("User %s", id)
-	}
-	return displayName
+func (e *PartialFetchError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,51 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadDisplayNames reads a JSON file containing an object that maps Twitch User IDs to
+// display names, e.g. {"90790024": "wasabimilkshake"}
+func LoadDisplayNames(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var displayNamesById map[string]string
+	if err := json.Unmarshal(data, &displayNamesById); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if displayNamesById == nil {
+		displayNamesById = make(map[string]string)
+	}
+	return displayNamesById, nil
+}
+
+// NewStaticLookup returns a users.Lookup that serves display names from a fixed map,
+// without any external dependencies
+func NewStaticLookup(displayNamesById map[string]string) Lookup {
+	return &staticLookup{
+		displayNamesById: displayNamesById,
+	}
+}
+
+// staticLookup is an implementation of users.Lookup that resolves display names from a
+// fixed set of users, e.g. as loaded from a local JSON file during development
+type staticLookup struct {
+	displayNamesById map[string]string
+}
+
+func (l *staticLookup) Resolve(ctx context.Context, ids []string) error {
+	return nil
+}
+
+func (l *staticLookup) GetDisplayName(id string) string {
+	displayName, ok := l.displayNamesById[id]
+	if !ok {
+		return fmt.Sprintf("User %s", id)
+	}
+	return displayName
+}
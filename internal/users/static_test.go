@@ -0,0 +1,38 @@
+package users
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadDisplayNames(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "users.json")
+	err := os.WriteFile(filename, []byte(`{"1234": "JoeBob", "5678": "BigBilly"}`), 0644)
+	assert.NoError(t, err)
+	displayNamesById, err := LoadDisplayNames(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"1234": "JoeBob", "5678": "BigBilly"}, displayNamesById)
+
+	invalidFilename := filepath.Join(dir, "invalid.json")
+	err = os.WriteFile(invalidFilename, []byte(`["JoeBob"]`), 0644)
+	assert.NoError(t, err)
+	_, err = LoadDisplayNames(invalidFilename)
+	assert.Error(t, err)
+
+	_, err = LoadDisplayNames(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func Test_staticLookup(t *testing.T) {
+	l := NewStaticLookup(map[string]string{"1234": "JoeBob"})
+	err := l.Resolve(context.Background(), []string{"1234", "5678"})
+	assert.NoError(t, err)
+	assert.Equal(t, "JoeBob", l.GetDisplayName("1234"))
+	assert.Equal(t, "User 5678", l.GetDisplayName("5678"))
+}
@@ -0,0 +1,163 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/nicklaw5/helix/v2"
+)
+
+// maxUsersPerRequest is the maximum number of user IDs that the Twitch API will accept
+// in a single GetUsers call
+const maxUsersPerRequest = 100
+
+// maxConcurrentRequests is the maximum number of GetUsers calls that we'll have in
+// flight at once when fetching display names for a large number of users
+const maxConcurrentRequests = 4
+
+// NewTwitchFetcher initializes a Fetcher that gets display names from the Twitch API,
+// using the given HTTP client to make requests, or http.DefaultClient if nil
+func NewTwitchFetcher(twitchClientId string, twitchClientSecret string, httpClient *http.Client) (Fetcher, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	c, err := helix.NewClient(&helix.Options{
+		ClientID:     twitchClientId,
+		ClientSecret: twitchClientSecret,
+		HTTPClient:   httpClient,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Twitch API client: %w", err)
+	}
+
+	f := &twitchFetcher{
+		c: c,
+	}
+	if err := f.refreshAppAccessToken(""); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// twitchFetcher is an implementation of users.Fetcher that uses the Twitch API in
+// order to resolve user's human-facing display names given their numeric Twitch User ID
+// values.
+type twitchFetcher struct {
+	c *helix.Client
+
+	// tokenMu guards the client's app access token, which may be replaced if it expires:
+	// requests hold a read lock, and the token may only be replaced under a write lock
+	tokenMu sync.RWMutex
+}
+
+func (f *twitchFetcher) FetchDisplayNames(ctx context.Context, ids []string) (map[string]string, error) {
+	// Split our IDs into batches small enough to be accepted by the Twitch API
+	batches := make([][]string, 0, (len(ids)+maxUsersPerRequest-1)/maxUsersPerRequest)
+	for start := 0; start < len(ids); start += maxUsersPerRequest {
+		end := start + maxUsersPerRequest
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches = append(batches, ids[start:end])
+	}
+
+	// Fetch each batch concurrently, with a limit on the number of requests in flight
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRequests)
+	displayNamesById := make(map[string]string, len(ids))
+	failedIds := make([]string, 0)
+	errs := make([]error, 0)
+	for _, batch := range batches {
+		wg.Add(1)
+		go func(batch []string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			users, err := f.fetchBatch(ctx, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failedIds = append(failedIds, batch...)
+				errs = append(errs, err)
+				return
+			}
+			for _, user := range users {
+				displayNamesById[user.ID] = user.DisplayName
+			}
+		}(batch)
+	}
+	wg.Wait()
+
+	// If any batches failed, report the IDs that weren't fetched along with the names
+	// we did manage to fetch
+	if len(errs) > 0 {
+		return displayNamesById, &PartialFetchError{
+			FailedIds: failedIds,
+			Err:       errors.Join(errs...),
+		}
+	}
+	return displayNamesById, nil
+}
+
+// fetchBatch makes a single GetUsers call to the Twitch API: if our app access token
+// has expired, we request a new one and retry the call once
+func (f *twitchFetcher) fetchBatch(ctx context.Context, ids []string) ([]helix.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	token, res, err := f.getUsers(ids)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		if err := f.refreshAppAccessToken(token); err != nil {
+			return nil, err
+		}
+		_, res, err = f.getUsers(ids)
+	}
+	if err == nil && res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("got status %d: %s", res.StatusCode, res.ErrorMessage)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users from Twitch API: %w", err)
+	}
+	return res.Data.Users, nil
+}
+
+// getUsers makes a GetUsers call with the current app access token, returning that
+// token along with the response
+func (f *twitchFetcher) getUsers(ids []string) (string, *helix.UsersResponse, error) {
+	f.tokenMu.RLock()
+	defer f.tokenMu.RUnlock()
+	token := f.c.GetAppAccessToken()
+	res, err := f.c.GetUsers(&helix.UsersParams{
+		IDs: ids,
+	})
+	return token, res, err
+}
+
+// refreshAppAccessToken requests a new app access token from the Twitch API, replacing
+// the given token that was found to be invalid: if another goroutine has already
+// replaced that token in the meantime, we leave the new token as-is
+func (f *twitchFetcher) refreshAppAccessToken(invalidToken string) error {
+	f.tokenMu.Lock()
+	defer f.tokenMu.Unlock()
+	if f.c.GetAppAccessToken() != invalidToken {
+		return nil
+	}
+
+	res, err := f.c.RequestAppAccessToken(nil)
+	if err == nil && res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("got status %d: %s", res.StatusCode, res.ErrorMessage)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get app access token from Twitch API: %w", err)
+	}
+
+	f.c.SetAppAccessToken(res.Data.AccessToken)
+	return nil
+}
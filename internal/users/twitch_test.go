@@ -0,0 +1,200 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golden-vcr/tapes/internal/users/helixfake"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_twitchFetcher_FetchDisplayNames(t *testing.T) {
+	api := newFakeTwitchApi(250)
+	f, err := NewTwitchFetcher("fake-client-id", "fake-client-secret", api.httpClient())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, api.numTokensIssued)
+
+	// Requesting more than 100 users should be split into multiple batches, no more
+	// than maxConcurrentRequests of which are in flight at once, and unknown users
+	// should be omitted
+	got, err := f.FetchDisplayNames(context.Background(), append(api.ids(), "9999"))
+	assert.NoError(t, err)
+	assert.Equal(t, api.displayNamesById, got)
+	sort.Ints(api.batchSizes)
+	assert.Equal(t, []int{51, 100, 100}, api.batchSizes)
+	assert.LessOrEqual(t, api.maxInFlight, maxConcurrentRequests)
+	assert.Equal(t, 1, api.numTokensIssued)
+}
+
+func Test_twitchFetcher_FetchDisplayNames_tokenExpiry(t *testing.T) {
+	api := newFakeTwitchApi(250)
+	f, err := NewTwitchFetcher("fake-client-id", "fake-client-secret", api.httpClient())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, api.numTokensIssued)
+
+	// If our token expires, we should get a new one and carry on: even though every
+	// batch is rejected with the expired token, only one new token should be requested
+	api.expireTokens()
+	got, err := f.FetchDisplayNames(context.Background(), api.ids())
+	assert.NoError(t, err)
+	assert.Equal(t, api.displayNamesById, got)
+	assert.Equal(t, 2, api.numTokensIssued)
+}
+
+func Test_twitchFetcher_FetchDisplayNames_canceled(t *testing.T) {
+	api := newFakeTwitchApi(250)
+	f, err := NewTwitchFetcher("fake-client-id", "fake-client-secret", api.httpClient())
+	assert.NoError(t, err)
+
+	// If the context is canceled, no further requests should be made, and every ID
+	// should be reported as failed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := f.FetchDisplayNames(ctx, api.ids())
+	assert.Empty(t, got)
+	var partialErr *PartialFetchError
+	assert.ErrorAs(t, err, &partialErr)
+	assert.Len(t, partialErr.FailedIds, 250)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, api.batchSizes)
+}
+
+func Test_twitchFetcher_FetchDisplayNames_helixfake(t *testing.T) {
+	s := helixfake.NewServer(map[string]string{"1000": "JoeBob", "1001": "BigBilly"})
+	defer s.Close()
+
+	f, err := NewTwitchFetcher("fake-client-id", "fake-client-secret", s.HTTPClient())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.NumTokensIssued())
+
+	got, err := f.FetchDisplayNames(context.Background(), []string{"1000", "1001", "9999"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"1000": "JoeBob", "1001": "BigBilly"}, got)
+	assert.Equal(t, 1, s.NumUsersRequests())
+}
+
+func Test_twitchFetcher_FetchDisplayNames_partialFailure(t *testing.T) {
+	s := helixfake.NewServer(map[string]string{"1000": "JoeBob"})
+	defer s.Close()
+
+	f, err := NewTwitchFetcher("fake-client-id", "fake-client-secret", s.HTTPClient())
+	assert.NoError(t, err)
+
+	// If a batch fails, the IDs in that batch should be reported as failed
+	s.Close()
+	got, err := f.FetchDisplayNames(context.Background(), []string{"1000"})
+	assert.Empty(t, got)
+	var partialErr *PartialFetchError
+	assert.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, []string{"1000"}, partialErr.FailedIds)
+}
+
+// fakeTwitchApi is a minimal stand-in for the Twitch API endpoints used by
+// twitchFetcher, which records the requests it receives
+type fakeTwitchApi struct {
+	displayNamesById map[string]string
+
+	mu              sync.Mutex
+	validToken      string
+	numTokensIssued int
+	batchSizes      []int
+	inFlight        int
+	maxInFlight     int
+}
+
+// newFakeTwitchApi initializes a fakeTwitchApi with the given number of known users
+func newFakeTwitchApi(numUsers int) *fakeTwitchApi {
+	displayNamesById := make(map[string]string, numUsers)
+	for i := 0; i < numUsers; i++ {
+		displayNamesById[fmt.Sprintf("%d", 1000+i)] = fmt.Sprintf("User%d", i)
+	}
+	return &fakeTwitchApi{displayNamesById: displayNamesById}
+}
+
+// ids returns the IDs of all users known to the fake API
+func (a *fakeTwitchApi) ids() []string {
+	ids := make([]string, 0, len(a.displayNamesById))
+	for id := range a.displayNamesById {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// expireTokens invalidates the current app access token
+func (a *fakeTwitchApi) expireTokens() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.validToken = ""
+}
+
+// httpClient returns an HTTP client that serves all requests from the fake API
+func (a *fakeTwitchApi) httpClient() *http.Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", a.handleToken)
+	mux.HandleFunc("/helix/users", a.handleUsers)
+	return &http.Client{Transport: handlerTransport{mux}}
+}
+
+func (a *fakeTwitchApi) handleToken(res http.ResponseWriter, req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.numTokensIssued++
+	a.validToken = fmt.Sprintf("token-%d", a.numTokensIssued)
+	res.Header().Set("content-type", "application/json")
+	fmt.Fprintf(res, `{"access_token":%q,"expires_in":3600,"token_type":"bearer"}`, a.validToken)
+}
+
+func (a *fakeTwitchApi) handleUsers(res http.ResponseWriter, req *http.Request) {
+	ids := req.URL.Query()["id"]
+	a.mu.Lock()
+	valid := a.validToken != "" && req.Header.Get("Authorization") == "Bearer "+a.validToken
+	if valid {
+		a.batchSizes = append(a.batchSizes, len(ids))
+		a.inFlight++
+		if a.inFlight > a.maxInFlight {
+			a.maxInFlight = a.inFlight
+		}
+	}
+	a.mu.Unlock()
+
+	res.Header().Set("content-type", "application/json")
+	if !valid {
+		res.WriteHeader(http.StatusUnauthorized)
+		res.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`))
+		return
+	}
+
+	// Linger briefly so that concurrent requests overlap
+	time.Sleep(10 * time.Millisecond)
+	users := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if displayName, ok := a.displayNamesById[id]; ok {
+			users = append(users, fmt.Sprintf(`{"id":%q,"display_name":%q}`, id, displayName))
+		}
+	}
+	fmt.Fprintf(res, `{"data":[%s]}`, strings.Join(users, ","))
+
+	a.mu.Lock()
+	a.inFlight--
+	a.mu.Unlock()
+}
+
+// handlerTransport is an http.RoundTripper that serves every request with an
+// http.Handler, without making any network requests
+type handlerTransport struct {
+	h http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}